@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 	"os"
@@ -9,14 +10,21 @@ import (
 	"codex/internal/config"
 	codexContext "codex/internal/context"
 	"codex/internal/logging"
+	"codex/internal/prompt"
 	"codex/internal/providers"
+	"codex/internal/redact"
 
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
 var (
 	screenshot  bool
 	currentRepo bool
+	askStyle    string
+	askYes      bool
+	dryRun      bool
+	printPrompt bool
 )
 
 // askCmd represents the ask command
@@ -32,19 +40,38 @@ Codex will automatically gather context from:
   - Current filesystem location
   - Screenshot (if --screenshot flag is used)
 
+Answer style is chosen with --style (or 'style:' in the config file):
+  terse    - a value and where it came from (default)
+  explain  - a grounded explanation, good with --current-repo
+  code     - a snippet or command that fits your setup
+Custom presets can be added as files in ~/.config/codex/prompts/<name>.md.
+
+Before sending, the estimated tokens and cost are printed. Queries above
+'confirm_threshold_usd' or 'confirm_threshold_tokens' ask for confirmation;
+pass --yes to skip the prompt (required when stdin is not a terminal).
+
+--dry-run gathers and summarizes context exactly as a real query would, then
+prints a manifest of included, truncated and omitted files and exits without
+any network call. --print-prompt prints the full rendered prompt instead.
+
 Examples:
   codex ask "What's my tmux prefix key?"
   codex ask --screenshot "How do I achieve this layout?"
-  codex ask --current-repo "Explain this codebase structure"
-  codex ask "What CLI tools do I have for JSON processing?"`,
+  codex ask --current-repo --style explain "Explain this codebase structure"
+  codex ask "What CLI tools do I have for JSON processing?"
+  codex ask --dry-run "Where is my git signing key set?"`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		question := strings.Join(args, " ")
+		if printPrompt {
+			dryRun = true
+		}
 
 		logging.Logger.Debug().
 			Str("question", question).
 			Bool("screenshot", screenshot).
 			Bool("current_repo", currentRepo).
+			Bool("dry_run", dryRun).
 			Msg("Processing ask command")
 
 		// Load configuration
@@ -63,28 +90,44 @@ Examples:
 			Int("configured_repos", len(cfg.ConfiguredRepos)).
 			Msg("Configuration loaded")
 
+		// Resolve the answer style into a system prompt
+		providerCfg := providers.ConfigFromApp(cfg)
+		style, err := resolveStyle(cfg, askStyle)
+		if err != nil {
+			return err
+		}
+		providerCfg.SystemPrompt = style.System
+
+		logging.Logger.Debug().
+			Str("style", style.Name).
+			Str("style_source", style.Source).
+			Msg("Answer style selected")
+
 		// Create AI provider
-		var provider providers.Provider
-		switch cfg.Provider {
-		case "anthropic":
-			if cfg.Model != "" {
-				provider = providers.NewAnthropicProviderWithModel(cfg.AnthropicKey, cfg.Model)
-			} else {
-				provider = providers.NewAnthropicProvider(cfg.AnthropicKey)
-			}
-		default:
-			return fmt.Errorf("unsupported provider: %s", cfg.Provider)
+		provider, err := providers.NewProvider(providerCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create provider: %w", err)
 		}
 
-		// Validate provider
-		if err := provider.Validate(); err != nil {
-			return fmt.Errorf("provider validation failed: %w", err)
+		// Validate provider (some backends check connectivity, so not on a dry run)
+		if !dryRun {
+			if err := provider.Validate(); err != nil {
+				return fmt.Errorf("provider validation failed: %w", err)
+			}
 		}
 
 		logging.Logger.Debug().Str("provider", provider.Name()).Msg("Provider initialized")
 
-		// Gather context
+		// Gather context, budgeting with the model's own tokenizer
+		tokenizer := providers.TokenizerFor(providerCfg.EffectiveModel())
 		gatherer := codexContext.NewGatherer(cfg)
+		gatherer.SetTokenCounter(tokenizer.Count)
+		gatherer.SetSectionRenderer(prompt.RenderSettings)
+
+		logging.Logger.Debug().
+			Str("tokenizer", tokenizer.Name()).
+			Int("token_budget", cfg.ContextTokenBudget()).
+			Msg("Token counting configured")
 
 		workingDir, err := os.Getwd()
 		if err != nil {
@@ -98,6 +141,7 @@ Examples:
 			IncludeDotfiles:    cfg.DotfilesPath != "",
 			CaptureScreenshot:  screenshot,
 			WorkingDir:         workingDir,
+			Offline:            dryRun,
 		}
 
 		logging.Logger.Debug().Msg("Gathering context...")
@@ -111,6 +155,42 @@ Examples:
 			Bool("has_current_repo", ctx.CurrentRepo != nil).
 			Msg("Context gathered")
 
+		// Strip credentials before anything is estimated, printed or sent
+		if cfg.RedactSecrets {
+			redacted := redact.Context(ctx)
+			logRedactions(ctx)
+			logging.Logger.Debug().Int("redacted", redacted).Msg("Secrets redacted")
+		}
+
+		// Estimate size and cost before committing to the request
+		estimate, err := providers.EstimateQuery(provider, question, ctx)
+		if err != nil {
+			return err
+		}
+		if estimate.CostErr != nil {
+			logging.Logger.Debug().Err(estimate.CostErr).Msg("Could not price estimate")
+		}
+		fmt.Fprintf(os.Stderr, "Context: %s\n\n", estimate)
+
+		if printPrompt {
+			fmt.Print(prompt.NewBuilder(providerCfg.SystemPrompt).Build(question, ctx).Render())
+			return nil
+		}
+		if dryRun {
+			return codexContext.WriteManifest(os.Stdout, ctx, tokenizer.Count)
+		}
+
+		if !askYes && estimate.Exceeds(cfg.ConfirmThresholdUSD, cfg.ConfirmThresholdTokens) {
+			confirmed, err := confirmSend(estimate)
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Fprintln(os.Stderr, "Aborted, query not sent.")
+				return nil
+			}
+		}
+
 		// Send query to provider
 		logging.Logger.Debug().Msg("Sending query to provider...")
 
@@ -121,14 +201,121 @@ Examples:
 
 		logging.Logger.Debug().Msg("Query completed successfully")
 
+		printUsage(provider)
+
 		return nil
 	},
 }
 
+// printUsage reports the tokens and cost of the answer on stderr, keeping stdout to the answer itself
+func printUsage(provider providers.Provider) {
+	usage := provider.LastUsage()
+	if usage == (providers.Usage{}) {
+		// The backend did not report usage
+		return
+	}
+
+	tokens := fmt.Sprintf("%d in, %d out", usage.InputTokens, usage.OutputTokens)
+	if usage.CacheReadTokens > 0 || usage.CacheWriteTokens > 0 {
+		tokens += fmt.Sprintf(", %d cache read, %d cache write", usage.CacheReadTokens, usage.CacheWriteTokens)
+	}
+
+	cost, err := provider.GetUsageCost(usage)
+	logging.Logger.Debug().Str("pricing_version", providers.PricingVersion).Msg("Pricing usage")
+	if err != nil {
+		logging.Logger.Debug().Err(err).Msg("Could not price usage")
+		fmt.Fprintf(os.Stderr, "\nTokens: %s | Cost: unknown\n", tokens)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nTokens: %s | Cost: $%.4f\n", tokens, cost)
+}
+
+// confirmSend asks on the terminal whether to send a query over the confirmation
+// thresholds. Without a terminal to ask on, it fails rather than sending silently.
+func confirmSend(estimate *providers.Estimate) (bool, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) && !isatty.IsCygwinTerminal(os.Stdin.Fd()) {
+		return false, fmt.Errorf("query estimated at %s exceeds the confirmation threshold; pass --yes to send it non-interactively", estimate)
+	}
+
+	fmt.Fprintf(os.Stderr, "This query exceeds the confirmation threshold (%s). Send it? [y/N] ", estimate)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// resolveStyle picks the system prompt: --style flag, then system_prompt, then style, then the default
+func resolveStyle(cfg *config.Config, flagStyle string) (*prompt.Style, error) {
+	if flagStyle == "" && cfg.SystemPrompt != "" {
+		return &prompt.Style{Name: "custom", System: cfg.SystemPrompt, Source: prompt.SourceConfig}, nil
+	}
+
+	name := flagStyle
+	if name == "" {
+		name = cfg.Style
+	}
+
+	style, err := prompt.LoadStyle(name, config.GetPromptsPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load answer style: %w", err)
+	}
+	return style, nil
+}
+
 func init() {
 	rootCmd.AddCommand(askCmd)
 
 	// Local flags for the ask command
 	askCmd.Flags().BoolVarP(&screenshot, "screenshot", "s", false, "capture a screenshot for visual context")
 	askCmd.Flags().BoolVarP(&currentRepo, "current-repo", "r", false, "include current working directory repository as context")
+	askCmd.Flags().StringVar(&askStyle, "style", "", "answer style preset: terse, explain, code, or a custom preset name")
+	askCmd.Flags().BoolVarP(&askYes, "yes", "y", false, "send without asking, even above the confirmation threshold")
+	askCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the context manifest and exit without calling the provider")
+	askCmd.Flags().BoolVar(&printPrompt, "print-prompt", false, "print the rendered prompt and exit without calling the provider (implies --dry-run)")
+}
+
+// logRedactions logs each redacted secret's file or option, line and rule at
+// debug level
+func logRedactions(ctx *codexContext.Context) {
+	repos := append([]*codexContext.RepositoryContext{}, ctx.ConfiguredRepos...)
+	if ctx.CurrentRepo != nil {
+		repos = append(repos, ctx.CurrentRepo)
+	}
+
+	for _, repo := range repos {
+		if repo.Contents == nil {
+			continue
+		}
+		for _, file := range repo.Contents.Files {
+			for _, r := range file.Redactions {
+				logging.Logger.Debug().
+					Str("file", file.Path).
+					Int("line", r.Line).
+					Str("rule", r.Rule).
+					Msg("Redacted secret")
+			}
+		}
+	}
+
+	var parsed []codexContext.Redaction
+	if ctx.NixConfig != nil {
+		parsed = append(parsed, ctx.NixConfig.Redactions...)
+	}
+	if ctx.Dotfiles != nil {
+		parsed = append(parsed, ctx.Dotfiles.Redactions...)
+	}
+	for _, r := range parsed {
+		logging.Logger.Debug().
+			Str("source", r.Source).
+			Int("line", r.Line).
+			Str("rule", r.Rule).
+			Msg("Redacted secret")
+	}
 }
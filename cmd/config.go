@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"codex/internal/config"
 	"codex/internal/logging"
@@ -10,6 +11,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	addRepoInclude []string
+	addRepoExclude []string
+)
+
 // configCmd represents the config command group
 var configCmd = &cobra.Command{
 	Use:   "config",
@@ -124,6 +130,9 @@ func init() {
 	configCmd.AddCommand(configAddRepoCmd)
 	configCmd.AddCommand(configListReposCmd)
 	configCmd.AddCommand(configRemoveRepoCmd)
+
+	configAddRepoCmd.Flags().StringArrayVar(&addRepoInclude, "include", nil, "only read files matching this gitignore-style glob (repeatable)")
+	configAddRepoCmd.Flags().StringArrayVar(&addRepoExclude, "exclude", nil, "never read files matching this gitignore-style glob (repeatable)")
 }
 
 // configAddRepoCmd adds a repository to the configured repos list
@@ -133,16 +142,23 @@ var configAddRepoCmd = &cobra.Command{
 	Long: `Add a repository to be included as context in all queries.
 Can be a local path or a remote git URL.
 
+Files ignored by the repository's .gitignore or .codexignore are never read.
+--include and --exclude narrow it further with gitignore-style globs relative
+to the repository root.
+
 Examples:
   codex config add-repo /path/to/local/repo
   codex config add-repo https://github.com/user/repo
-  codex config add-repo git@github.com:user/repo.git`,
+  codex config add-repo git@github.com:user/repo.git
+  codex config add-repo ~/nixos --include 'modules/**/*.nix' --exclude '*.lock'`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		source := args[0]
 
 		logging.Logger.Debug().
 			Str("source", source).
+			Strs("include", addRepoInclude).
+			Strs("exclude", addRepoExclude).
 			Msg("Adding repository to configuration")
 
 		// Load config
@@ -154,7 +170,7 @@ Examples:
 		}
 
 		// Add repo
-		if err := cfg.AddRepo(source); err != nil {
+		if err := cfg.AddRepoWithFilters(source, addRepoInclude, addRepoExclude); err != nil {
 			logging.Logger.Error().Err(err).Msg("Failed to add repository")
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
@@ -213,6 +229,12 @@ var configListReposCmd = &cobra.Command{
 			if repo.Type == "remote" && repo.CachePath != "" {
 				fmt.Printf("   Cached at: %s\n", repo.CachePath)
 			}
+			if len(repo.Include) > 0 {
+				fmt.Printf("   Include:   %s\n", strings.Join(repo.Include, ", "))
+			}
+			if len(repo.Exclude) > 0 {
+				fmt.Printf("   Exclude:   %s\n", strings.Join(repo.Exclude, ", "))
+			}
 		}
 	},
 }
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"codex/internal/config"
+	codexContext "codex/internal/context"
+	"codex/internal/logging"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	keybindsSearch string
+	keybindsJSON   bool
+)
+
+// keybindsCmd lists key bindings parsed from the dotfiles, without a provider
+var keybindsCmd = &cobra.Command{
+	Use:   "keybinds [tool]",
+	Short: "List and search the key bindings in your dotfiles",
+	Long: `List the key bindings parsed from your dotfiles and home-manager configuration,
+with the file and line that set each one. Everything is read locally; no
+provider is called and no network access is needed.
+
+With a tool name (tmux, neovim, sway, kitty, zsh, ...) only that tool's
+bindings are listed. --search ranks bindings by how well their key, command,
+description or mode match the terms, allowing for typos and for the way each
+tool spells modifiers: "ctrl+t" also finds C-t, <C-t> and ^T.
+
+Examples:
+  codex keybinds
+  codex keybinds tmux
+  codex keybinds --search "split window"
+  codex keybinds neovim --search telescope --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool := ""
+		if len(args) > 0 {
+			tool = args[0]
+		}
+
+		logging.Logger.Debug().
+			Str("tool", tool).
+			Str("search", keybindsSearch).
+			Msg("Listing key bindings")
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if cfg.DotfilesPath == "" {
+			return fmt.Errorf("no dotfiles path configured; set dotfiles_path in %s or CODEX_DOTFILES", config.GetConfigPath())
+		}
+
+		dotfiles, err := codexContext.NewGatherer(cfg).GatherDotfiles()
+		if err != nil {
+			return err
+		}
+
+		if tool != "" && !hasTool(dotfiles.Keybindings, tool) {
+			return fmt.Errorf("no key bindings found for %q; tools with bindings: %s", tool, keybindTools(dotfiles.Keybindings))
+		}
+
+		matches := codexContext.SearchKeybinds(dotfiles.Keybindings, tool, keybindsSearch)
+
+		logging.Logger.Debug().
+			Str("dotfiles", dotfiles.DotfilesPath).
+			Int("matches", len(matches)).
+			Msg("Key bindings matched")
+
+		if keybindsJSON {
+			if matches == nil {
+				matches = []codexContext.KeybindMatch{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(matches)
+		}
+
+		if len(matches) == 0 {
+			if keybindsSearch != "" {
+				fmt.Fprintf(os.Stderr, "No key bindings match %q.\n", keybindsSearch)
+			} else {
+				fmt.Fprintf(os.Stderr, "No key bindings found in %s.\n", dotfiles.DotfilesPath)
+			}
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "Key bindings in %s, with files relative to it:\n\n", dotfiles.DotfilesPath)
+		return codexContext.WriteKeybinds(os.Stdout, matches)
+	},
+}
+
+// hasTool reports whether tool, in any case, has key bindings
+func hasTool(binds map[string][]codexContext.Keybind, tool string) bool {
+	for name := range binds {
+		if strings.EqualFold(name, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// keybindTools lists the tools that have key bindings, or "none"
+func keybindTools(binds map[string][]codexContext.Keybind) string {
+	tools := make([]string, 0, len(binds))
+	for name := range binds {
+		tools = append(tools, name)
+	}
+	if len(tools) == 0 {
+		return "none"
+	}
+	sort.Strings(tools)
+	return strings.Join(tools, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(keybindsCmd)
+
+	keybindsCmd.Flags().StringVar(&keybindsSearch, "search", "", "fuzzy-search keys, commands, descriptions and modes")
+	keybindsCmd.Flags().BoolVar(&keybindsJSON, "json", false, "print the bindings as JSON")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"codex/internal/config"
+	"codex/internal/logging"
+	"codex/internal/providers"
+
+	"github.com/spf13/cobra"
+)
+
+// providersCmd represents the providers command group
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "Inspect available AI providers",
+	Long:  `Inspect the AI provider backends codex can send queries to.`,
+}
+
+// providersListCmd lists every registered provider backend
+var providersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available AI providers",
+	Long: `List every AI provider backend, its default model and the settings it requires.
+The currently configured provider is marked with *.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		logging.Logger.Debug().Msg("Listing providers")
+
+		// Load config
+		cfg, err := config.Load()
+		if err != nil {
+			logging.Logger.Error().Err(err).Msg("Failed to load configuration")
+			fmt.Fprintf(os.Stderr, "Error: failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+		providerCfg := providers.ConfigFromApp(cfg)
+
+		fmt.Println("Available Providers")
+		fmt.Println("===================")
+		fmt.Println()
+
+		for _, backend := range providers.Backends() {
+			marker := " "
+			if backend.Name == cfg.Provider {
+				marker = "*"
+			}
+
+			fmt.Printf("%s %s - %s\n", marker, backend.Name, backend.Description)
+			fmt.Printf("    Default model: %s\n", getConfigValue(backend.DefaultModel, "none (model must be set)"))
+
+			if len(backend.Requires) == 0 {
+				fmt.Println("    Requires:      nothing")
+			}
+			for i, req := range backend.Requires {
+				label := "    Requires:     "
+				if i > 0 {
+					label = "                  "
+				}
+				status := "missing"
+				if req.Value(providerCfg) != "" {
+					status = "set"
+				}
+				fmt.Printf("%s %s [%s]\n", label, req.Describe(), status)
+			}
+			fmt.Println()
+		}
+
+		fmt.Printf("Select a provider with 'provider:' in %s or CODEX_PROVIDER.\n", config.GetConfigPath())
+		fmt.Printf("Costs use list prices as of %s; correct them with 'pricing:' in the same file.\n", providers.PricingVersion)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(providersCmd)
+	providersCmd.AddCommand(providersListCmd)
+}
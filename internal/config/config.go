@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+
+	"codex/internal/ignore"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
@@ -14,6 +18,18 @@ type ConfiguredRepo struct {
 	Source    string `yaml:"source"`     // Local path or remote URL
 	Type      string `yaml:"type"`       // "local" or "remote"
 	CachePath string `yaml:"cache_path"` // For remote repos, where they're cached locally
+
+	// Gitignore-style globs relative to the repo root, applied on top of its ignore files
+	Include []string `yaml:"include,omitempty"` // If set, only matching files are read
+	Exclude []string `yaml:"exclude,omitempty"` // Matching files are never read
+}
+
+// ModelPricing overrides the built-in price of a model, in USD per million tokens
+type ModelPricing struct {
+	Input      float64 `yaml:"input"`
+	Output     float64 `yaml:"output"`
+	CacheRead  float64 `yaml:"cache_read,omitempty"`
+	CacheWrite float64 `yaml:"cache_write,omitempty"`
 }
 
 // Config represents the application configuration
@@ -26,12 +42,25 @@ type Config struct {
 	ConfiguredRepos []ConfiguredRepo `yaml:"configured_repos"`
 
 	// AI Provider settings
-	Provider     string `yaml:"provider"` // "anthropic", "openai", "ollama"
+	Provider     string `yaml:"provider"`        // Registered provider name, see `codex providers list`
 	Model        string `yaml:"model,omitempty"` // Model name (optional, uses provider default if not set)
 	AnthropicKey string `yaml:"anthropic_key,omitempty"`
 	OpenAIKey    string `yaml:"openai_key,omitempty"`
+	OpenAIURL    string `yaml:"openai_url,omitempty"` // Base URL for the OpenAI API (optional, for proxies)
 	OllamaURL    string `yaml:"ollama_url,omitempty"`
 
+	// OpenAI-compatible endpoint settings (llama.cpp, vLLM, LM Studio, gateways)
+	BaseURL string            `yaml:"base_url,omitempty"` // e.g. http://localhost:8080/v1
+	APIKey  string            `yaml:"api_key,omitempty"`  // Optional bearer token
+	Headers map[string]string `yaml:"headers,omitempty"`  // Extra headers sent with every request
+
+	// Answer style settings
+	Style        string `yaml:"style,omitempty"`         // Preset name: terse, explain, code, or a file in ~/.config/codex/prompts
+	SystemPrompt string `yaml:"system_prompt,omitempty"` // Literal system prompt, overrides style
+
+	// Pricing overrides keyed by model name or prefix (e.g. "claude-3-5-haiku")
+	Pricing map[string]ModelPricing `yaml:"pricing,omitempty"`
+
 	// Database settings
 	DatabasePath string `yaml:"database_path"`
 
@@ -39,21 +68,44 @@ type Config struct {
 	CacheTTL int `yaml:"cache_ttl"` // in hours
 
 	// Context settings
-	MaxContextSize int `yaml:"max_context_size"` // Maximum context size in bytes (0 = no limit)
+	MaxContextTokens int `yaml:"max_context_tokens,omitempty"` // Maximum context size in tokens (takes precedence)
+	MaxContextSize   int `yaml:"max_context_size"`             // Maximum context size in bytes (0 = no limit), used if max_context_tokens is unset
+
+	// Pre-flight confirmation settings: ask before sending queries estimated above these limits
+	ConfirmThresholdUSD    float64 `yaml:"confirm_threshold_usd"`              // Estimated cost in USD (0 = never ask)
+	ConfirmThresholdTokens int     `yaml:"confirm_threshold_tokens,omitempty"` // Estimated prompt tokens (0 = never ask)
+
+	// Flake inputs locked longer ago than this are flagged as stale (0 = never)
+	FlakeStaleAfterDays int `yaml:"flake_stale_after_days"`
+
+	// Privacy settings
+	RedactSecrets bool `yaml:"redact_secrets"` // Replace detected credentials with placeholders before sending (default on)
 }
 
 // Default configuration values
 const (
-	DefaultProvider      = "anthropic"
-	DefaultCacheTTL      = 24 // 24 hours
-	DefaultOllamaURL     = "http://localhost:11434"
-	DefaultConfigDir     = ".config/codex"
-	DefaultDataDir       = ".local/share/codex"
-	DefaultConfigFile    = "config.yaml"
-	DefaultDatabaseFile  = "codex.db"
-	DefaultMaxContextSize = 500 * 1024 // 500KB (~125K tokens) - conservative default
+	DefaultProvider            = "anthropic"
+	DefaultCacheTTL            = 24 // 24 hours
+	DefaultOllamaURL           = "http://localhost:11434"
+	DefaultConfigDir           = ".config/codex"
+	DefaultDataDir             = ".local/share/codex"
+	DefaultConfigFile          = "config.yaml"
+	DefaultPromptsDir          = "prompts"
+	DefaultDatabaseFile        = "codex.db"
+	DefaultMaxContextSize      = 500 * 1024 // 500KB (~125K tokens) - conservative default
+	DefaultConfirmThresholdUSD = 0.10       // Ask before sending queries estimated above 10 cents
+	DefaultFlakeStaleAfterDays = 90         // Flag flake inputs locked over three months ago
 )
 
+// ContextTokenBudget returns the context limit in tokens (0 = no limit).
+// max_context_tokens wins; otherwise max_context_size is converted at ~4 bytes per token.
+func (cfg *Config) ContextTokenBudget() int {
+	if cfg.MaxContextTokens > 0 {
+		return cfg.MaxContextTokens
+	}
+	return cfg.MaxContextSize / 4
+}
+
 // Load reads configuration from file and environment variables
 // Environment variables take precedence over config file values
 func Load() (*Config, error) {
@@ -66,10 +118,13 @@ func Load() (*Config, error) {
 	}
 
 	cfg := &Config{
-		Provider:       DefaultProvider,
-		CacheTTL:       DefaultCacheTTL,
-		OllamaURL:      DefaultOllamaURL,
-		MaxContextSize: DefaultMaxContextSize,
+		Provider:            DefaultProvider,
+		CacheTTL:            DefaultCacheTTL,
+		OllamaURL:           DefaultOllamaURL,
+		MaxContextSize:      DefaultMaxContextSize,
+		ConfirmThresholdUSD: DefaultConfirmThresholdUSD,
+		RedactSecrets:       true,
+		FlakeStaleAfterDays: DefaultFlakeStaleAfterDays,
 	}
 
 	// Set default paths
@@ -128,9 +183,21 @@ func loadFromEnv(cfg *Config) {
 	if val := os.Getenv("OPENAI_API_KEY"); val != "" {
 		cfg.OpenAIKey = val
 	}
+	if val := os.Getenv("CODEX_OPENAI_URL"); val != "" {
+		cfg.OpenAIURL = val
+	}
 	if val := os.Getenv("CODEX_OLLAMA_URL"); val != "" {
 		cfg.OllamaURL = val
 	}
+	if val := os.Getenv("CODEX_BASE_URL"); val != "" {
+		cfg.BaseURL = val
+	}
+	if val := os.Getenv("CODEX_API_KEY"); val != "" {
+		cfg.APIKey = val
+	}
+	if val := os.Getenv("CODEX_STYLE"); val != "" {
+		cfg.Style = val
+	}
 	if val := os.Getenv("CODEX_DATABASE_PATH"); val != "" {
 		cfg.DatabasePath = val
 	}
@@ -160,6 +227,28 @@ func (cfg *Config) Save() error {
 	return nil
 }
 
+// ProviderValidator checks the provider-specific settings of a configuration
+type ProviderValidator func(cfg *Config) error
+
+// providerValidators holds a validator per provider name. The providers
+// package fills it as backends register, so config does not import it.
+var providerValidators = make(map[string]ProviderValidator)
+
+// RegisterProvider makes a provider name valid and attaches its settings check
+func RegisterProvider(name string, validate ProviderValidator) {
+	providerValidators[name] = validate
+}
+
+// ProviderNames returns the names of all registered providers, sorted
+func ProviderNames() []string {
+	names := make([]string, 0, len(providerValidators))
+	for name := range providerValidators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Validate checks if the configuration is valid
 func (cfg *Config) Validate() error {
 	// Check if paths exist (if set)
@@ -175,23 +264,13 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
-	// Validate provider
-	switch cfg.Provider {
-	case "anthropic":
-		if cfg.AnthropicKey == "" {
-			return fmt.Errorf("anthropic provider requires ANTHROPIC_API_KEY")
-		}
-	case "openai":
-		if cfg.OpenAIKey == "" {
-			return fmt.Errorf("openai provider requires OPENAI_API_KEY")
-		}
-	case "ollama":
-		// Ollama doesn't require API key, but URL should be valid
-		if cfg.OllamaURL == "" {
-			cfg.OllamaURL = DefaultOllamaURL
-		}
-	default:
-		return fmt.Errorf("unknown provider: %s (must be anthropic, openai, or ollama)", cfg.Provider)
+	// Validate provider against the registered backends
+	validate, ok := providerValidators[cfg.Provider]
+	if !ok {
+		return fmt.Errorf("unknown provider: %s (must be one of: %s)", cfg.Provider, strings.Join(ProviderNames(), ", "))
+	}
+	if err := validate(cfg); err != nil {
+		return err
 	}
 
 	return nil
@@ -207,6 +286,15 @@ func GetConfigPath() string {
 	return filepath.Join(homeDir, DefaultConfigDir, DefaultConfigFile)
 }
 
+// GetPromptsPath returns the directory holding custom style preset files
+func GetPromptsPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return DefaultPromptsDir
+	}
+	return filepath.Join(homeDir, DefaultConfigDir, DefaultPromptsDir)
+}
+
 // GetDatabasePath returns the full path to the database file
 func GetDatabasePath() string {
 	homeDir, err := os.UserHomeDir()
@@ -254,6 +342,18 @@ func GetRepoCachePath() string {
 
 // AddRepo adds a repository to the configured repos list
 func (cfg *Config) AddRepo(source string) error {
+	return cfg.AddRepoWithFilters(source, nil, nil)
+}
+
+// AddRepoWithFilters adds a repository that only contributes files matching
+// include (if non-empty) and not matching exclude
+func (cfg *Config) AddRepoWithFilters(source string, include, exclude []string) error {
+	for _, glob := range append(append([]string{}, include...), exclude...) {
+		if err := ignore.ValidatePattern(glob); err != nil {
+			return err
+		}
+	}
+
 	// Check if already exists
 	for _, repo := range cfg.ConfiguredRepos {
 		if repo.Source == source {
@@ -287,6 +387,8 @@ func (cfg *Config) AddRepo(source string) error {
 		Source:    source,
 		Type:      repoType,
 		CachePath: cachePath,
+		Include:   include,
+		Exclude:   exclude,
 	})
 
 	return nil
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"codex/internal/ignore"
 )
 
 // FileContent represents a single file's content
@@ -13,13 +15,39 @@ type FileContent struct {
 	RelativePath string `json:"relative_path"`
 	Content      string `json:"content"`
 	Size         int    `json:"size"`
+	Tokens       int    `json:"tokens,omitempty"` // Filled in by the summarizer
+
+	// Set by the summarizer when the file was cut to fit the token budget
+	Truncated      bool `json:"truncated,omitempty"`
+	OriginalSize   int  `json:"original_size,omitempty"`
+	OriginalTokens int  `json:"original_tokens,omitempty"`
+
+	// Secrets replaced with placeholders before sending
+	Redactions []Redaction `json:"redactions,omitempty"`
+}
+
+// Redaction records a secret removed from a file's content, or from a
+// parsed Nix option, dotfiles setting, key binding or shell definition
+type Redaction struct {
+	Rule   string `json:"rule"`             // Which detector matched, e.g. "aws-access-key"
+	Line   int    `json:"line"`             // 1-based line where the secret was found; 0 if unknown
+	Source string `json:"source,omitempty"` // File, or option path if the file is unknown; empty for repository files
+}
+
+// OmittedFile records a file the summarizer dropped to fit the token budget
+type OmittedFile struct {
+	RelativePath string `json:"relative_path"`
+	Size         int    `json:"size"`
+	Tokens       int    `json:"tokens"`
 }
 
 // RepoContents represents all files from a repository
 type RepoContents struct {
-	Files      []FileContent `json:"files"`
-	TotalSize  int           `json:"total_size"`
-	TotalFiles int           `json:"total_files"`
+	Files       []FileContent `json:"files"`
+	TotalSize   int           `json:"total_size"`
+	TotalFiles  int           `json:"total_files"`
+	TotalTokens int           `json:"total_tokens,omitempty"` // Filled in by the summarizer
+	Omitted     []OmittedFile `json:"omitted,omitempty"`      // Files dropped by the summarizer
 }
 
 // ContentReader reads repository contents with intelligent filtering
@@ -40,11 +68,64 @@ func NewContentReader() *ContentReader {
 
 // ReadRepoContents reads all relevant files from a repository
 func (cr *ContentReader) ReadRepoContents(repoPath string) (*RepoContents, error) {
+	return cr.ReadRepoContentsNear(repoPath, repoPath)
+}
+
+// ReadRepoContentsFiltered reads a repository keeping only files that match an
+// include glob (if any are given) and none of the exclude globs. Globs use
+// gitignore syntax relative to the repository root and override ignore files.
+func (cr *ContentReader) ReadRepoContentsFiltered(repoPath string, include, exclude []string) (*RepoContents, error) {
+	matcher := newMatcher(repoPath)
+	matcher.SetIncludes(include)
+	matcher.AddOverrides(exclude)
+
+	return cr.readOutward(repoPath, repoPath, matcher)
+}
+
+// ReadRepoContentsNear reads a repository outward from focusDir: the focus
+// directory first, then each enclosing directory up to the repository root.
+// Files near focusDir are read before the total size limit cuts reading short.
+func (cr *ContentReader) ReadRepoContentsNear(repoPath, focusDir string) (*RepoContents, error) {
+	return cr.readOutward(repoPath, focusDir, newMatcher(repoPath))
+}
+
+// readOutward reads the files matcher allows, from focusDir outward to repoPath
+func (cr *ContentReader) readOutward(repoPath, focusDir string, matcher *ignore.Matcher) (*RepoContents, error) {
 	contents := &RepoContents{
 		Files: make([]FileContent, 0),
 	}
 
-	err := filepath.Walk(repoPath, func(path string, info os.FileInfo, err error) error {
+	repoPath = filepath.Clean(repoPath)
+	focusDir = filepath.Clean(focusDir)
+	if !isWithin(repoPath, focusDir) {
+		focusDir = repoPath
+	}
+
+	skip := "" // Subtree already read
+	for dir := focusDir; ; dir = filepath.Dir(dir) {
+		if err := cr.readTree(repoPath, dir, skip, matcher, contents); err != nil {
+			return nil, fmt.Errorf("failed to read repository contents: %w", err)
+		}
+		if dir == repoPath || contents.TotalSize > int(cr.maxTotalSize) {
+			break
+		}
+		skip = dir
+	}
+
+	return contents, nil
+}
+
+// newMatcher creates the ignore matcher for a repository: gitignore rules plus .codexignore files
+func newMatcher(repoPath string) *ignore.Matcher {
+	matcher := ignore.New(repoPath)
+	matcher.AddIgnoreFileName(ignore.CodexignoreFile)
+	return matcher
+}
+
+// readTree adds the files under root to contents, skipping the subtree at skip
+// and anything matcher ignores. Relative paths are taken from repoPath.
+func (cr *ContentReader) readTree(repoPath, root, skip string, matcher *ignore.Matcher, contents *RepoContents) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip files we can't read
 		}
@@ -54,15 +135,25 @@ func (cr *ContentReader) ReadRepoContents(repoPath string) (*RepoContents, error
 			return filepath.SkipAll
 		}
 
+		relPath, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			relPath = path
+		}
+
 		// Skip directories
 		if info.IsDir() {
+			// Skip a subtree that was already read
+			if path == skip {
+				return filepath.SkipDir
+			}
+
 			// Skip hidden directories unless includeHidden is true
 			if !cr.includeHidden && strings.HasPrefix(info.Name(), ".") {
 				return filepath.SkipDir
 			}
 
-			// Always skip common build/cache directories
-			if cr.shouldSkipDirectory(info.Name()) {
+			// Skip ignored directories (defaults, .gitignore and friends)
+			if cr.shouldSkipDirectory(matcher, relPath) {
 				return filepath.SkipDir
 			}
 
@@ -70,15 +161,11 @@ func (cr *ContentReader) ReadRepoContents(repoPath string) (*RepoContents, error
 		}
 
 		// Skip files that should be ignored
-		if cr.shouldSkipFile(path, info) {
+		if matcher.Match(relPath, false) || cr.shouldSkipFile(path, info) {
 			return nil
 		}
 
 		// Read the file
-		relPath, err := filepath.Rel(repoPath, path)
-		if err != nil {
-			relPath = path
-		}
 
 		content, err := os.ReadFile(path)
 		if err != nil {
@@ -103,88 +190,20 @@ func (cr *ContentReader) ReadRepoContents(repoPath string) (*RepoContents, error
 
 		return nil
 	})
+}
 
+// isWithin reports whether path is dir or inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read repository contents: %w", err)
+		return false
 	}
-
-	return contents, nil
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
 // shouldSkipDirectory returns true if the directory should be skipped
-func (cr *ContentReader) shouldSkipDirectory(name string) bool {
-	skipDirs := []string{
-		// Version control
-		".git",
-
-		// Dependencies
-		"node_modules",
-		"vendor",
-
-		// Build/Dist
-		"dist",
-		"build",
-		"target",
-		".next",
-		".nuxt",
-		"out",
-
-		// Caches
-		".cache",
-		".npm",
-		".yarn",
-		".gradle",
-		".m2",
-		".turbo",
-		"tmp",
-		"temp",
-		".tmp",
-		"Cache",           // Electron/browser cache
-		"GPUCache",        // GPU cache
-		"Code Cache",      // Code cache
-		"DawnCache",       // WebGPU cache
-		"IndexedDB",       // Browser IndexedDB
-		"LocalStorage",    // Browser local storage
-		"SessionStorage",  // Browser session storage
-		"Service Worker",  // Service worker cache
-		"adblock",         // Adblock lists
-
-		// Python
-		"__pycache__",
-		".pytest_cache",
-		".mypy_cache",
-		".venv",
-		"venv",
-		".tox",
-
-		// Coverage/Testing
-		"coverage",
-		".coverage",
-		".nyc_output",
-
-		// IDE/Editor
-		".idea",
-		".vscode",
-		".DS_Store",
-
-		// Browser/Electron specific
-		"Dictionaries",    // Spell check dictionaries
-		"WebStorage",      // Web storage
-		"Partitions",      // Browser partitions
-		"DawnGraphiteCache",
-		"DawnWebGPUCache",
-
-		// Nix
-		"result", // Nix result symlinks
-	}
-
-	for _, skip := range skipDirs {
-		if name == skip {
-			return true
-		}
-	}
-
-	return false
+func (cr *ContentReader) shouldSkipDirectory(matcher *ignore.Matcher, relPath string) bool {
+	return matcher.Match(relPath, true)
 }
 
 // shouldSkipFile returns true if the file should be skipped
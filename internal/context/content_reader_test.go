@@ -0,0 +1,147 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeTree creates files under root from a map of relative path to content
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadRepoContentsNearReadsFocusFirst(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"flake.nix":          "{ }",
+		"a/first.go":         "package a",
+		"cmd/tool/main.go":   "package main",
+		"cmd/tool/flags.go":  "package main",
+		"cmd/other/other.go": "package other",
+	})
+
+	cr := NewContentReader()
+	contents, err := cr.ReadRepoContentsNear(root, filepath.Join(root, "cmd", "tool"))
+	if err != nil {
+		t.Fatalf("ReadRepoContentsNear failed: %v", err)
+	}
+
+	if contents.TotalFiles != 5 {
+		t.Fatalf("Expected every file to be read once, got %d", contents.TotalFiles)
+	}
+
+	order := make(map[string]int)
+	for i, file := range contents.Files {
+		order[filepath.ToSlash(file.RelativePath)] = i
+	}
+	if order["cmd/tool/main.go"] > 1 || order["cmd/tool/flags.go"] > 1 {
+		t.Errorf("Expected focus directory files first, got order %v", order)
+	}
+	if order["cmd/other/other.go"] > order["flake.nix"] || order["cmd/other/other.go"] > order["a/first.go"] {
+		t.Errorf("Expected sibling directory before the repo root, got order %v", order)
+	}
+}
+
+func TestReadRepoContentsNearStopsAtSizeLimit(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"aaa/far.sh":  "echo 12345",
+		"zzz/near.sh": "echo 12345",
+	})
+
+	cr := NewContentReader()
+	cr.maxTotalSize = 5
+
+	contents, err := cr.ReadRepoContentsNear(root, filepath.Join(root, "zzz"))
+	if err != nil {
+		t.Fatalf("ReadRepoContentsNear failed: %v", err)
+	}
+	if len(contents.Files) != 1 || filepath.ToSlash(contents.Files[0].RelativePath) != "zzz/near.sh" {
+		t.Errorf("Expected only the file near the focus directory, got %+v", contents.Files)
+	}
+}
+
+func TestReadRepoContentsNearIgnoresOutsideFocus(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"main.go": "package main"})
+
+	contents, err := NewContentReader().ReadRepoContentsNear(root, t.TempDir())
+	if err != nil {
+		t.Fatalf("ReadRepoContentsNear failed: %v", err)
+	}
+	if contents.TotalFiles != 1 {
+		t.Errorf("Expected the repository to be read from its root, got %d files", contents.TotalFiles)
+	}
+}
+
+func TestReadRepoContentsHonorsIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":               "*.generated.go\n",
+		".git/config":              "[core]",
+		"main.go":                  "package main",
+		"api.generated.go":         "package main",
+		"node_modules/x/index.js":  "module.exports = {}",
+		"web/.gitignore":           "/dist-local/\n",
+		"web/dist-local/bundle.js": "x",
+		"web/app.js":               "x",
+	})
+
+	contents, err := NewContentReader().ReadRepoContents(root)
+	if err != nil {
+		t.Fatalf("ReadRepoContents failed: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, file := range contents.Files {
+		got[filepath.ToSlash(file.RelativePath)] = true
+	}
+	for _, want := range []string{"main.go", "web/app.js", ".gitignore"} {
+		if !got[want] {
+			t.Errorf("Expected %s to be read, got %v", want, got)
+		}
+	}
+	for _, unwanted := range []string{"api.generated.go", ".git/config", "node_modules/x/index.js", "web/dist-local/bundle.js"} {
+		if got[unwanted] {
+			t.Errorf("Expected %s to be ignored", unwanted)
+		}
+	}
+}
+
+func TestReadRepoContentsFiltered(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".codexignore":             "secrets.nix\n",
+		"flake.nix":                "{ }",
+		"secrets.nix":              "{ token = \"x\"; }",
+		"modules/desktop/sway.nix": "{ }",
+		"modules/gen/big.nix":      "{ }",
+		"scripts/setup.sh":         "echo",
+	})
+
+	contents, err := NewContentReader().ReadRepoContentsFiltered(root, []string{"*.nix"}, []string{"modules/gen/"})
+	if err != nil {
+		t.Fatalf("ReadRepoContentsFiltered failed: %v", err)
+	}
+
+	var got []string
+	for _, file := range contents.Files {
+		got = append(got, filepath.ToSlash(file.RelativePath))
+	}
+	sort.Strings(got)
+	if want := "flake.nix,modules/desktop/sway.nix"; strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %v", want, got)
+	}
+}
@@ -1,15 +1,23 @@
 package context
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"time"
 
 	"codex/internal/config"
+	"codex/internal/dotfiles"
+	"codex/internal/logging"
+	"codex/internal/nix"
 )
 
 // Gatherer collects context information from various sources
 type Gatherer struct {
 	nixConfigPath   string
+	staleAfterDays  int
 	dotfilesPath    string
 	configuredRepos []config.ConfiguredRepo
 	repoFetcher     *RepoFetcher
@@ -20,12 +28,13 @@ type Gatherer struct {
 // NewGatherer creates a new context gatherer
 func NewGatherer(cfg *config.Config) *Gatherer {
 	var summarizer *ContextSummarizer
-	if cfg.MaxContextSize > 0 {
-		summarizer = NewContextSummarizer(cfg.MaxContextSize)
+	if budget := cfg.ContextTokenBudget(); budget > 0 {
+		summarizer = NewContextSummarizer(budget)
 	}
 
 	return &Gatherer{
 		nixConfigPath:   cfg.NixConfigPath,
+		staleAfterDays:  cfg.FlakeStaleAfterDays,
 		dotfilesPath:    cfg.DotfilesPath,
 		configuredRepos: cfg.ConfiguredRepos,
 		repoFetcher:     NewRepoFetcher(),
@@ -34,6 +43,22 @@ func NewGatherer(cfg *config.Config) *Gatherer {
 	}
 }
 
+// SetTokenCounter sets the function used to budget context in tokens,
+// normally the tokenizer of the provider's model
+func (g *Gatherer) SetTokenCounter(counter TokenCounter) {
+	if g.summarizer != nil {
+		g.summarizer.SetTokenCounter(counter)
+	}
+}
+
+// SetSectionRenderer sets how the Nix configuration and dotfiles are
+// rendered when counting the part of the budget they take
+func (g *Gatherer) SetSectionRenderer(render SectionRenderer) {
+	if g.summarizer != nil {
+		g.summarizer.SetSectionRenderer(render)
+	}
+}
+
 // Gather collects all requested context information
 func (g *Gatherer) Gather(opts GatherOptions) (*Context, error) {
 	ctx := &Context{
@@ -41,7 +66,7 @@ func (g *Gatherer) Gather(opts GatherOptions) (*Context, error) {
 	}
 
 	// Always gather configured repositories
-	configuredRepos, err := g.gatherConfiguredRepos()
+	configuredRepos, err := g.gatherConfiguredRepos(opts.Offline)
 	if err != nil {
 		// Log warning but continue
 		// TODO: Add proper logging
@@ -74,8 +99,8 @@ func (g *Gatherer) Gather(opts GatherOptions) (*Context, error) {
 	if opts.IncludeNixConfig && g.nixConfigPath != "" {
 		nixCtx, err := g.gatherNixConfig()
 		if err != nil {
-			// Log warning but continue
-			// TODO: Add proper logging
+			// Continue without it, saying why in the manifest
+			ctx.Warnings = append(ctx.Warnings, fmt.Sprintf("Nix configuration %s left out: %v", g.nixConfigPath, err))
 		} else {
 			ctx.NixConfig = nixCtx
 		}
@@ -85,8 +110,7 @@ func (g *Gatherer) Gather(opts GatherOptions) (*Context, error) {
 	if opts.IncludeDotfiles && g.dotfilesPath != "" {
 		dotfilesCtx, err := g.gatherDotfiles()
 		if err != nil {
-			// Log warning but continue
-			// TODO: Add proper logging
+			ctx.Warnings = append(ctx.Warnings, fmt.Sprintf("Dotfiles %s left out: %v", g.dotfilesPath, err))
 		} else {
 			ctx.Dotfiles = dotfilesCtx
 		}
@@ -109,12 +133,18 @@ func (g *Gatherer) Gather(opts GatherOptions) (*Context, error) {
 	return ctx, nil
 }
 
-// gatherConfiguredRepos fetches all configured repositories
-func (g *Gatherer) gatherConfiguredRepos() ([]*RepositoryContext, error) {
+// gatherConfiguredRepos fetches all configured repositories. Offline, remote
+// repos are only used if already cached.
+func (g *Gatherer) gatherConfiguredRepos(offline bool) ([]*RepositoryContext, error) {
 	var repos []*RepositoryContext
 
 	for _, configuredRepo := range g.configuredRepos {
-		repoPath, err := g.repoFetcher.FetchRepo(configuredRepo)
+		fetch := g.repoFetcher.FetchRepo
+		if offline {
+			fetch = g.repoFetcher.LocateRepo
+		}
+
+		repoPath, err := fetch(configuredRepo)
 		if err != nil {
 			// Log error but continue with other repos
 			// TODO: Add proper logging
@@ -125,7 +155,7 @@ func (g *Gatherer) gatherConfiguredRepos() ([]*RepositoryContext, error) {
 		remote, _ := getGitRemote(repoPath)
 
 		// Read repository contents
-		contents, err := g.contentReader.ReadRepoContents(repoPath)
+		contents, err := g.contentReader.ReadRepoContentsFiltered(repoPath, configuredRepo.Include, configuredRepo.Exclude)
 		if err != nil {
 			// Log error but continue without contents
 			// TODO: Add proper logging
@@ -144,7 +174,8 @@ func (g *Gatherer) gatherConfiguredRepos() ([]*RepositoryContext, error) {
 	return repos, nil
 }
 
-// gatherCurrentRepo collects current working directory repository information
+// gatherCurrentRepo collects the working directory's repository, reading
+// files near the working directory first
 func (g *Gatherer) gatherCurrentRepo(workingDir string) (*RepositoryContext, error) {
 	repoPath, err := findGitRepository(workingDir)
 	if err != nil {
@@ -153,10 +184,31 @@ func (g *Gatherer) gatherCurrentRepo(workingDir string) (*RepositoryContext, err
 
 	remote, _ := getGitRemote(repoPath)
 
+	focusDir := repoPath
+	if workingDir != "" {
+		if abs, err := filepath.Abs(workingDir); err == nil {
+			focusDir = abs
+		}
+	}
+
+	contents, err := g.contentReader.ReadRepoContentsNear(repoPath, focusDir)
+	if err != nil {
+		// Log error but continue without contents
+		// TODO: Add proper logging
+		contents = nil
+	}
+
+	relFocus, err := filepath.Rel(repoPath, focusDir)
+	if err != nil {
+		relFocus = "."
+	}
+
 	return &RepositoryContext{
-		Path:   repoPath,
-		Remote: remote,
-		Type:   "current",
+		Path:     repoPath,
+		Remote:   remote,
+		Type:     "current",
+		Contents: contents,
+		FocusDir: relFocus,
 	}, nil
 }
 
@@ -173,21 +225,154 @@ func (g *Gatherer) gatherFilesystem(workingDir string) (*FilesystemContext, erro
 
 // gatherNixConfig parses Nix configuration
 func (g *Gatherer) gatherNixConfig() (*NixContext, error) {
-	// TODO: Implement Nix config parsing
-	// - Detect if flake or traditional config
-	// - Parse .nix files
-	// - Extract installed packages
-	// - Extract system configuration
-	return nil, fmt.Errorf("not yet implemented")
+	cfg, err := nix.ParseConfig(g.nixConfigPath)
+	if err != nil {
+		logging.Logger.Debug().Err(err).Str("path", g.nixConfigPath).Msg("Could not parse Nix configuration")
+		return nil, err
+	}
+	logNixWarnings(g.nixConfigPath, cfg.Warnings)
+
+	sources := make(map[string]OptionSource, len(cfg.Sources))
+	for key, src := range cfg.Sources {
+		sources[key] = OptionSource{File: src.File, Line: src.Line}
+	}
+
+	return &NixContext{
+		ConfigPath:   g.nixConfigPath,
+		IsFlake:      cfg.IsFlake,
+		Packages:     cfg.Packages,
+		SystemConfig: cfg.Options,
+		Sources:      sources,
+		Files:        cfg.Files,
+		Inputs:       flakeInputs(cfg.Inputs, g.staleAfterDays, time.Now()),
+		StaleAfter:   g.staleAfterDays,
+		Warnings:     cfg.Warnings,
+		LastParsed:   time.Now(),
+		CacheKey:     cfg.Hash,
+	}, nil
+}
+
+// logNixWarnings logs the files under path that could not be read or parsed,
+// whose packages and options are missing from the context
+func logNixWarnings(path string, warnings []string) {
+	for _, warning := range warnings {
+		logging.Logger.Debug().Str("path", path).Str("warning", warning).Msg("Nix file skipped")
+	}
+}
+
+// flakeInputs converts parsed flake inputs, flagging those locked more than
+// staleAfterDays before now
+func flakeInputs(inputs []nix.FlakeInput, staleAfterDays int, now time.Time) []FlakeInput {
+	var converted []FlakeInput
+	for _, in := range inputs {
+		input := FlakeInput{
+			Name:         in.Name,
+			URL:          in.URL,
+			Follows:      in.Follows,
+			InputFollows: in.InputFollows,
+			Rev:          in.Rev,
+			LastModified: in.LastModified,
+		}
+		if staleAfterDays > 0 && !in.LastModified.IsZero() {
+			input.Stale = now.Sub(in.LastModified) > time.Duration(staleAfterDays)*24*time.Hour
+		}
+		converted = append(converted, input)
+	}
+	return converted
 }
 
 // gatherDotfiles parses dotfiles configuration
 func (g *Gatherer) gatherDotfiles() (*DotfilesContext, error) {
-	// TODO: Implement dotfiles parsing
-	// - Detect if home-manager or plain dotfiles
-	// - Parse common config files
-	// - Extract keybindings
-	return nil, fmt.Errorf("not yet implemented")
+	if _, err := os.Stat(g.dotfilesPath); err != nil {
+		return nil, fmt.Errorf("failed to read dotfiles: %w", err)
+	}
+
+	result := &DotfilesContext{
+		DotfilesPath: g.dotfilesPath,
+		LastParsed:   time.Now(),
+	}
+
+	// Home-manager programs; plain dotfiles have no Nix entry point
+	cfg, err := nix.ParseConfig(g.dotfilesPath)
+	if err == nil {
+		result.IsHomeManager = cfg.IsHomeManager()
+		result.Configs, result.Sources = programConfigs(cfg.Programs())
+		result.CacheKey = cfg.Hash
+		result.Warnings = cfg.Warnings
+		logNixWarnings(g.dotfilesPath, cfg.Warnings)
+	} else {
+		logging.Logger.Debug().Err(err).Str("path", g.dotfilesPath).Msg("Could not parse home-manager configuration")
+		if !errors.Is(err, nix.ErrNoEntryPoint) {
+			result.Warnings = append(result.Warnings, err.Error())
+		}
+		cfg = nil
+	}
+
+	// Config files, read in one walk that skips what the ignore files exclude.
+	// Settings such as a terminal's font and theme join the programs' configs.
+	extracted := dotfiles.ExtractAll(g.dotfilesPath, cfg)
+	result.Configs, result.Sources = addSettings(result.Configs, result.Sources, extracted.Settings)
+	result.Keybindings = keybindings(extracted.Keybinds)
+	result.Shells = shells(extracted.Shells)
+	return result, nil
+}
+
+// GatherDotfiles reads only the dotfiles path, for lookups such as listing
+// key bindings that are answered locally without gathering anything else
+func (g *Gatherer) GatherDotfiles() (*DotfilesContext, error) {
+	if g.dotfilesPath == "" {
+		return nil, fmt.Errorf("no dotfiles path configured")
+	}
+	return g.gatherDotfiles()
+}
+
+// programConfigs converts parsed programs to settings keyed by program name,
+// and their sources keyed "<program>.<setting>"
+func programConfigs(programs map[string]*nix.Program) (map[string]any, map[string]OptionSource) {
+	if len(programs) == 0 {
+		return nil, nil
+	}
+
+	configs := make(map[string]any, len(programs))
+	sources := make(map[string]OptionSource)
+	for name, program := range programs {
+		configs[name] = program.Settings
+		for setting, src := range program.Sources {
+			sources[name+"."+setting] = OptionSource{File: src.File, Line: src.Line}
+		}
+	}
+	return configs, sources
+}
+
+// addSettings adds settings read from config files to the programs' configs
+// and sources. Where home-manager makes the same setting, its value is kept.
+func addSettings(configs map[string]any, sources map[string]OptionSource, extracted map[string][]dotfiles.Setting) (map[string]any, map[string]OptionSource) {
+	if len(extracted) == 0 {
+		return configs, sources
+	}
+	if configs == nil {
+		configs = make(map[string]any, len(extracted))
+	}
+	if sources == nil {
+		sources = make(map[string]OptionSource)
+	}
+
+	for tool, list := range extracted {
+		// Copied so the parsed Nix config is left alone
+		settings := make(map[string]any)
+		if existing, ok := configs[tool].(map[string]any); ok {
+			maps.Copy(settings, existing)
+		}
+		for _, s := range list {
+			if _, ok := settings[s.Key]; ok {
+				continue
+			}
+			settings[s.Key] = s.Value
+			sources[tool+"."+s.Key] = OptionSource{File: s.File, Line: s.Line}
+		}
+		configs[tool] = settings
+	}
+	return configs, sources
 }
 
 // captureScreenshot captures a screenshot
@@ -198,3 +383,55 @@ func (g *Gatherer) captureScreenshot() (*Screenshot, error) {
 	// - Save to temp location
 	return nil, fmt.Errorf("not yet implemented")
 }
+
+// keybindings converts extracted key bindings, keyed by tool
+func keybindings(extracted map[string][]dotfiles.Keybind) map[string][]Keybind {
+	if len(extracted) == 0 {
+		return nil
+	}
+
+	binds := make(map[string][]Keybind, len(extracted))
+	for tool, list := range extracted {
+		for _, b := range list {
+			binds[tool] = append(binds[tool], Keybind{
+				Key:         b.Key,
+				Command:     b.Command,
+				Description: b.Description,
+				Mode:        b.Mode,
+				File:        b.File,
+				Line:        b.Line,
+			})
+		}
+	}
+	return binds
+}
+
+// shells converts extracted shell definitions, keyed by shell
+func shells(extracted map[string]*dotfiles.Shell) map[string]*ShellContext {
+	if len(extracted) == 0 {
+		return nil
+	}
+
+	result := make(map[string]*ShellContext, len(extracted))
+	for name, shell := range extracted {
+		sc := &ShellContext{
+			Aliases:   shellDefinitions(shell.Aliases),
+			Functions: shellDefinitions(shell.Functions),
+			Exports:   shellDefinitions(shell.Exports),
+		}
+		for _, p := range shell.Plugins {
+			sc.Plugins = append(sc.Plugins, ShellPlugin{Manager: p.Manager, Name: p.Name, File: p.File, Line: p.Line})
+		}
+		result[name] = sc
+	}
+	return result
+}
+
+// shellDefinitions converts extracted aliases, functions or exports
+func shellDefinitions(defs []dotfiles.Definition) []ShellDefinition {
+	var result []ShellDefinition
+	for _, d := range defs {
+		result = append(result, ShellDefinition{Name: d.Name, Value: d.Value, File: d.File, Line: d.Line})
+	}
+	return result
+}
@@ -0,0 +1,184 @@
+package context
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codex/internal/nix"
+)
+
+func TestFlakeInputsStale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	inputs := []nix.FlakeInput{
+		{Name: "fresh", Rev: "a", LastModified: now.AddDate(0, 0, -30)},
+		{Name: "old", Rev: "b", LastModified: now.AddDate(0, 0, -120)},
+		{Name: "follower", Follows: "fresh"},
+	}
+
+	got := flakeInputs(inputs, 90, now)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 inputs, got %d", len(got))
+	}
+	if got[0].Stale || !got[1].Stale || got[2].Stale {
+		t.Errorf("Expected only the input locked 120 days ago to be stale, got %+v", got)
+	}
+
+	for _, in := range flakeInputs(inputs, 0, now) {
+		if in.Stale {
+			t.Errorf("Expected no stale inputs when disabled, got %+v", in)
+		}
+	}
+}
+
+func TestGatherDotfilesHomeManager(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"home.nix": `{ ... }: {
+  programs.git = {
+    enable = true;
+    userName = "Me";
+  };
+  programs.bash.enable = false;
+}
+`,
+	})
+
+	g := &Gatherer{dotfilesPath: dir}
+	dotfiles, err := g.gatherDotfiles()
+	if err != nil {
+		t.Fatalf("gatherDotfiles failed: %v", err)
+	}
+
+	if !dotfiles.IsHomeManager {
+		t.Error("Expected a home-manager configuration")
+	}
+	if len(dotfiles.Configs) != 1 {
+		t.Fatalf("Expected only git to be configured, got %v", dotfiles.Configs)
+	}
+	if git := dotfiles.Configs["git"].(map[string]any); git["userName"] != "Me" {
+		t.Errorf("Unexpected git settings: %v", git)
+	}
+	if src := dotfiles.Sources["git.userName"]; src.File != "home.nix" || src.Line != 4 {
+		t.Errorf("Expected userName from home.nix:4, got %+v", src)
+	}
+	if dotfiles.CacheKey == "" {
+		t.Error("Expected a cache key")
+	}
+}
+
+func TestGatherDotfilesKeybindings(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".tmux.conf": "set -g prefix C-a\nbind | split-window -h\n",
+	})
+
+	g := &Gatherer{dotfilesPath: dir}
+	dotfiles, err := g.gatherDotfiles()
+	if err != nil {
+		t.Fatalf("gatherDotfiles failed: %v", err)
+	}
+
+	if dotfiles.IsHomeManager || len(dotfiles.Configs) != 0 {
+		t.Errorf("Expected plain dotfiles, got %+v", dotfiles)
+	}
+	want := Keybind{Key: "|", Command: "split-window -h", Mode: "prefix", File: ".tmux.conf", Line: 2}
+	if binds := dotfiles.Keybindings["tmux"]; len(binds) != 2 || binds[1] != want {
+		t.Errorf("Expected the prefix and %+v, got %+v", want, binds)
+	}
+}
+
+func TestGatherDotfilesShells(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".zshrc": "alias glog='git log --oneline'\nexport EDITOR=nvim\nsource $ZSH/oh-my-zsh.sh\n",
+	})
+
+	g := &Gatherer{dotfilesPath: dir}
+	dotfiles, err := g.gatherDotfiles()
+	if err != nil {
+		t.Fatalf("gatherDotfiles failed: %v", err)
+	}
+
+	zsh := dotfiles.Shells["zsh"]
+	if zsh == nil {
+		t.Fatalf("Expected zsh definitions, got %+v", dotfiles.Shells)
+	}
+	want := ShellDefinition{Name: "glog", Value: "git log --oneline", File: ".zshrc", Line: 1}
+	if len(zsh.Aliases) != 1 || zsh.Aliases[0] != want {
+		t.Errorf("Expected alias %+v, got %+v", want, zsh.Aliases)
+	}
+	if len(zsh.Exports) != 1 || zsh.Exports[0].Value != "nvim" {
+		t.Errorf("Expected EDITOR to be exported, got %+v", zsh.Exports)
+	}
+	if len(zsh.Plugins) != 1 || zsh.Plugins[0].Manager != "oh-my-zsh" {
+		t.Errorf("Expected oh-my-zsh, got %+v", zsh.Plugins)
+	}
+}
+
+func TestGatherDotfilesTerminalSettings(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".config/kitty/kitty.conf": "font_family Iosevka\nmap ctrl+shift+t new_tab\n",
+	})
+
+	g := &Gatherer{dotfilesPath: dir}
+	dotfiles, err := g.gatherDotfiles()
+	if err != nil {
+		t.Fatalf("gatherDotfiles failed: %v", err)
+	}
+
+	kitty, ok := dotfiles.Configs["kitty"].(map[string]any)
+	if !ok || kitty["font_family"] != "Iosevka" {
+		t.Fatalf("Expected kitty's font in Configs, got %+v", dotfiles.Configs)
+	}
+	want := OptionSource{File: ".config/kitty/kitty.conf", Line: 1}
+	if src := dotfiles.Sources["kitty.font_family"]; src != want {
+		t.Errorf("Expected font_family set at %+v, got %+v", want, src)
+	}
+	if binds := dotfiles.Keybindings["kitty"]; len(binds) != 1 || binds[0].Key != "ctrl+shift+t" {
+		t.Errorf("Expected kitty's binding, got %+v", binds)
+	}
+}
+
+func TestGatherDotfilesRequiresPath(t *testing.T) {
+	if _, err := (&Gatherer{}).GatherDotfiles(); err == nil {
+		t.Error("Expected an error without a dotfiles path")
+	}
+}
+
+func TestGatherReportsNixWarnings(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"configuration.nix": "{ ... }: {\n  imports = [ ./broken.nix ];\n  networking.hostName = \"laptop\";\n}\n",
+		"broken.nix":        "{ ... }: {\n  services.openssh.enable = \n",
+		"home/home.nix":     "{ ... }: {\n  imports = [ ./missing.nix ];\n}\n",
+		"plain/.tmux.conf":  "bind | split-window -h\n",
+	})
+
+	g := &Gatherer{nixConfigPath: dir, dotfilesPath: filepath.Join(dir, "home")}
+	ctx, err := g.Gather(GatherOptions{IncludeNixConfig: true, IncludeDotfiles: true})
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	if w := ctx.NixConfig.Warnings; len(w) != 1 || !strings.Contains(w[0], "broken.nix") {
+		t.Errorf("Expected a warning for broken.nix, got %v", w)
+	}
+	if w := ctx.Dotfiles.Warnings; len(w) != 1 || !strings.Contains(w[0], "missing.nix") {
+		t.Errorf("Expected a warning for missing.nix, got %v", w)
+	}
+
+	// Plain dotfiles have no Nix configuration, which is not a problem
+	g = &Gatherer{nixConfigPath: filepath.Join(dir, "nowhere"), dotfilesPath: filepath.Join(dir, "plain")}
+	ctx, err = g.Gather(GatherOptions{IncludeNixConfig: true, IncludeDotfiles: true})
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	if len(ctx.Dotfiles.Warnings) != 0 {
+		t.Errorf("Expected no warnings for plain dotfiles, got %v", ctx.Dotfiles.Warnings)
+	}
+	if ctx.NixConfig != nil || len(ctx.Warnings) != 1 || !strings.Contains(ctx.Warnings[0], "nowhere") {
+		t.Errorf("Expected the missing Nix configuration to be reported, got %v", ctx.Warnings)
+	}
+}
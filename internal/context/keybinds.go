@@ -0,0 +1,203 @@
+package context
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// KeybindMatch is a key binding listed or found by SearchKeybinds
+type KeybindMatch struct {
+	Tool string `json:"tool"`
+	Keybind
+	Score int `json:"score,omitempty"` // Higher is a closer match; 0 when listing
+}
+
+// keyModifiers maps the spellings of modifiers across tools to one name, so
+// a search for ctrl+t finds C-t, <C-t>, ^T and Control+T alike
+var keyModifiers = map[string]string{
+	"c": "ctrl", "ctrl": "ctrl", "control": "ctrl",
+	"m": "alt", "a": "alt", "alt": "alt", "meta": "alt", "mod1": "alt", "option": "alt", "opt": "alt",
+	"s": "shift", "shift": "shift",
+	"d": "super", "super": "super", "mod4": "super", "cmd": "super", "command": "super", "logo": "super", "win": "super",
+}
+
+// SearchKeybinds lists the key bindings of tool, or of every tool if tool
+// is empty, that match query. Each word of the query must match the key,
+// command, description or mode, as a substring or failing that as a
+// subsequence of its letters; bindings are ranked by how closely they
+// match. An empty query lists every binding by tool, in file order.
+func SearchKeybinds(binds map[string][]Keybind, tool, query string) []KeybindMatch {
+	tools := make([]string, 0, len(binds))
+	for name := range binds {
+		if tool == "" || strings.EqualFold(name, tool) {
+			tools = append(tools, name)
+		}
+	}
+	sort.Strings(tools)
+
+	words := strings.Fields(strings.ToLower(query))
+	var matches []KeybindMatch
+	for _, name := range tools {
+		for _, b := range binds[name] {
+			score, ok := keybindScore(b, words)
+			if ok {
+				matches = append(matches, KeybindMatch{Tool: name, Keybind: b, Score: score})
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// keybindScore scores a binding against every word of a query, reporting
+// whether all of them matched
+func keybindScore(b Keybind, words []string) (int, bool) {
+	keys := []string{strings.ToLower(b.Key), normalizeKey(b.Key)}
+	fields := []string{strings.ToLower(b.Description), strings.ToLower(b.Command), strings.ToLower(b.Mode)}
+
+	total := 0
+	for _, word := range words {
+		best := 0
+		if normalizeKey(word) == keys[1] {
+			best = 2000 // The same key however it is spelled
+		}
+		// Keys are short, so nearly any letters in order would match one.
+		// A match must end where a key or modifier does, so ctrl+a does not
+		// find ctrl+alt+t.
+		for _, key := range keys {
+			if word != "" {
+				best = max(best, substringScore(word+"+", key+"+"))
+			}
+		}
+		for _, field := range fields {
+			best = max(best, fuzzyScore(word, field))
+		}
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+	return total, true
+}
+
+// fuzzyScore scores how well word matches text: an exact match best, then
+// a substring nearer the start, then the letters of word in order with the
+// fewest others between them. 0 means no match.
+func fuzzyScore(word, text string) int {
+	if score := substringScore(word, text); score > 0 || word == "" {
+		return score
+	}
+
+	first, last, j := -1, -1, 0
+	for i := 0; i < len(text) && j < len(word); i++ {
+		if text[i] == word[j] {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			j++
+		}
+	}
+	gaps := last - first + 1 - len(word)
+	if j < len(word) || gaps > 2*len(word) {
+		return 0 // Letters scattered across a long command match nearly anything
+	}
+	return max(500-gaps*10, 1)
+}
+
+// substringScore scores word as all of text, or as part of it nearer the
+// start scoring higher. 0 means no match.
+func substringScore(word, text string) int {
+	switch i := strings.Index(text, word); {
+	case word == "" || i < 0:
+		return 0
+	case word == text:
+		return 1500
+	default:
+		return 1000 - min(i, 499)
+	}
+}
+
+// normalizeKey spells a key combination the same way whatever tool it is
+// from: lower case modifiers from keyModifiers joined with +, e.g. C-t,
+// <C-t>, ^T and Control+T all become ctrl+t
+func normalizeKey(key string) string {
+	k := strings.ToLower(strings.TrimSpace(key))
+	if len(k) > 2 && strings.HasPrefix(k, "<") && strings.HasSuffix(k, ">") {
+		k = k[1 : len(k)-1]
+	}
+
+	var mods []string
+	for {
+		switch {
+		// Emacs, tmux and vim style: C-t, M-x, S-Tab
+		case len(k) > 2 && k[1] == '-' && keyModifiers[k[:1]] != "":
+			mods, k = append(mods, keyModifiers[k[:1]]), k[2:]
+			continue
+		// Caret and fish notation: ^t, \ct
+		case len(k) == 2 && k[0] == '^', len(k) == 3 && strings.HasPrefix(k, `\c`):
+			mods, k = append(mods, "ctrl"), k[len(k)-1:]
+			continue
+		// Escape prefix: ^[x, \ex
+		case len(k) == 3 && strings.HasPrefix(k, "^["), len(k) == 3 && strings.HasPrefix(k, `\e`):
+			mods, k = append(mods, "alt"), k[2:]
+			continue
+		}
+		break
+	}
+
+	parts := strings.FieldsFunc(k, func(r rune) bool { return r == '+' || r == '|' || r == ' ' })
+	if len(parts) == 0 {
+		parts = []string{k} // The key is itself +, | or a space
+	}
+	for i, part := range parts[:len(parts)-1] {
+		if mod := keyModifiers[part]; mod != "" {
+			parts[i] = mod
+		}
+	}
+	return strings.Join(append(mods, parts...), "+")
+}
+
+// WriteKeybinds lists key bindings grouped by tool, one per line with its
+// mode, command, description and the file and line that set it
+func WriteKeybinds(w io.Writer, matches []KeybindMatch) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	// Grouped in the order each tool first appears, so the best match leads
+	var tools []string
+	byTool := make(map[string][]KeybindMatch)
+	for _, m := range matches {
+		if _, seen := byTool[m.Tool]; !seen {
+			tools = append(tools, m.Tool)
+		}
+		byTool[m.Tool] = append(byTool[m.Tool], m)
+	}
+
+	for i, tool := range tools {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "%s (%d)\n", tool, len(byTool[tool]))
+		for _, m := range byTool[tool] {
+			mode := ""
+			if m.Mode != "" {
+				mode = "[" + m.Mode + "]"
+			}
+			command := m.Command
+			if m.Description != "" {
+				command += "  # " + m.Description
+			}
+			source := m.File
+			if m.Line > 0 {
+				source = fmt.Sprintf("%s:%d", m.File, m.Line)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", m.Key, mode, command, source)
+		}
+	}
+
+	return tw.Flush()
+}
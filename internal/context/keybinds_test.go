@@ -0,0 +1,107 @@
+package context
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var testKeybinds = map[string][]Keybind{
+	"tmux": {
+		{Key: "C-a", Command: "send-prefix", Mode: "prefix", File: "tmux/.tmux.conf", Line: 4},
+		{Key: "|", Command: `split-window -h -c "#{pane_current_path}"`, Mode: "prefix", File: "tmux/.tmux.conf", Line: 7},
+	},
+	"neovim": {
+		{Key: "<C-t>", Command: "<cmd>Telescope find_files<cr>", Description: "Find files", Mode: "n", File: "init.lua", Line: 3},
+	},
+	"kitty": {
+		{Key: "ctrl+alt+enter", Command: "new_window_with_cwd", File: "kitty.conf", Line: 2},
+	},
+}
+
+func TestSearchKeybindsLists(t *testing.T) {
+	got := SearchKeybinds(testKeybinds, "", "")
+	var order []string
+	for _, m := range got {
+		order = append(order, m.Tool+" "+m.Key)
+	}
+	want := []string{"kitty ctrl+alt+enter", "neovim <C-t>", "tmux C-a", "tmux |"}
+	if strings.Join(order, ", ") != strings.Join(want, ", ") {
+		t.Errorf("SearchKeybinds() listed %q, want %q", order, want)
+	}
+
+	if got := SearchKeybinds(testKeybinds, "TMUX", ""); len(got) != 2 || got[0].Tool != "tmux" {
+		t.Errorf("Expected only tmux bindings, got %+v", got)
+	}
+}
+
+func TestSearchKeybinds(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string // Keys in ranked order
+	}{
+		{"split", []string{"|"}},
+		{"find files", []string{"<C-t>"}},
+		{"telscope", []string{"<C-t>"}},  // Letters in order
+		{"ctrl+t", []string{"<C-t>"}},    // However the modifier is spelled
+		{"^a", []string{"C-a"}},          // Caret notation
+		{"ctrl+a", []string{"C-a"}},      // Not ctrl+alt+enter
+		{"prefix", []string{"C-a", "|"}}, // By mode
+		{"window", []string{"ctrl+alt+enter", "|"}},
+		{"nothing-like-this", nil},
+	}
+
+	for _, tt := range tests {
+		var keys []string
+		for _, m := range SearchKeybinds(testKeybinds, "", tt.query) {
+			keys = append(keys, m.Key)
+			if m.Score <= 0 {
+				t.Errorf("Expected a positive score for %q matching %q, got %d", tt.query, m.Key, m.Score)
+			}
+		}
+		if strings.Join(keys, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("SearchKeybinds(%q) = %q, want %q", tt.query, keys, tt.want)
+		}
+	}
+}
+
+func TestNormalizeKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"C-a":             "ctrl+a",
+		"<C-S-t>":         "ctrl+shift+t",
+		"M-x":             "alt+x",
+		"^T":              "ctrl+t",
+		`\cf`:             "ctrl+f",
+		`\ex`:             "alt+x",
+		"Control+Shift+N": "ctrl+shift+n",
+		"Mod4+Return":     "super+return",
+		"CTRL|SHIFT+t":    "ctrl+shift+t",
+		"SUPER SHIFT+Q":   "super+shift+q",
+		"|":               "|",
+		"<leader>ff":      "<leader>ff",
+	} {
+		if got := normalizeKey(key); got != want {
+			t.Errorf("normalizeKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestWriteKeybinds(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteKeybinds(&out, SearchKeybinds(testKeybinds, "", "")); err != nil {
+		t.Fatalf("WriteKeybinds failed: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"kitty (1)\n",
+		"tmux (2)\n",
+		"[prefix]",
+		"<cmd>Telescope find_files<cr>  # Find files",
+		"tmux/.tmux.conf:7",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
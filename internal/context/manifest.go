@@ -0,0 +1,143 @@
+package context
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteManifest lists what a gathered context would send: every repository
+// file that is included, truncated or omitted, with byte and token counts.
+// Files the summarizer did not count are counted with count.
+func WriteManifest(w io.Writer, ctx *Context, count TokenCounter) error {
+	if count == nil {
+		count = EstimateTokenCount
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if ctx != nil {
+		for _, warning := range ctx.Warnings {
+			fmt.Fprintf(tw, "Warning: %s\n\n", warning)
+		}
+		for _, repo := range ctx.ConfiguredRepos {
+			writeRepoManifest(tw, "Configured repository", repo, count)
+		}
+		if ctx.CurrentRepo != nil {
+			writeRepoManifest(tw, "Current repository", ctx.CurrentRepo, count)
+		}
+		if nix := ctx.NixConfig; nix != nil {
+			fmt.Fprintf(tw, "Nix configuration: %s\n  %d files parsed, %d packages, %d options",
+				nix.ConfigPath, len(nix.Files), len(nix.Packages), len(nix.SystemConfig))
+			if len(nix.Inputs) > 0 {
+				stale := 0
+				for _, in := range nix.Inputs {
+					if in.Stale {
+						stale++
+					}
+				}
+				fmt.Fprintf(tw, ", %d flake inputs (%d stale)", len(nix.Inputs), stale)
+			}
+			writeFindings(tw, nix.Redactions, nix.Warnings)
+		}
+		if dotfiles := ctx.Dotfiles; dotfiles != nil {
+			fmt.Fprintf(tw, "Dotfiles: %s\n  ", dotfiles.DotfilesPath)
+			if dotfiles.IsHomeManager {
+				fmt.Fprint(tw, "home-manager, ")
+			}
+			binds := 0
+			for _, list := range dotfiles.Keybindings {
+				binds += len(list)
+			}
+			aliases := 0
+			for _, shell := range dotfiles.Shells {
+				aliases += len(shell.Aliases)
+			}
+			fmt.Fprintf(tw, "%d programs, %d key bindings, %d shell aliases", len(dotfiles.Configs), binds, aliases)
+			writeFindings(tw, dotfiles.Redactions, dotfiles.Warnings)
+		}
+		if ctx.Filesystem != nil && ctx.Filesystem.CurrentDir != "" {
+			fmt.Fprintf(tw, "Working directory: %s\n", ctx.Filesystem.CurrentDir)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// writeRepoManifest writes one repository's section of the manifest
+func writeRepoManifest(w io.Writer, label string, repo *RepositoryContext, count TokenCounter) {
+	name := repo.Path
+	if repo.Source != "" && repo.Source != repo.Path {
+		name = fmt.Sprintf("%s (%s)", repo.Source, repo.Path)
+	}
+	fmt.Fprintf(w, "%s: %s\n", label, name)
+
+	if repo.Contents == nil {
+		fmt.Fprintln(w, "  no contents read")
+		fmt.Fprintln(w)
+		return
+	}
+
+	totalTokens, redacted := 0, 0
+	for _, file := range repo.Contents.Files {
+		tokens := file.Tokens
+		if tokens == 0 {
+			tokens = count(file.Content)
+		}
+		totalTokens += tokens
+
+		if file.Truncated {
+			fmt.Fprintf(w, "  truncated\t%s\t%d B\t%d tok (from %d B, %d tok)\n",
+				file.RelativePath, file.Size, tokens, file.OriginalSize, file.OriginalTokens)
+		} else {
+			fmt.Fprintf(w, "  included\t%s\t%d B\t%d tok\n", file.RelativePath, file.Size, tokens)
+		}
+
+		for _, r := range file.Redactions {
+			fmt.Fprintf(w, "  redacted\t%s:%d\t%s\n", file.RelativePath, r.Line, r.Rule)
+		}
+		redacted += len(file.Redactions)
+	}
+
+	for _, file := range repo.Contents.Omitted {
+		fmt.Fprintf(w, "  omitted\t%s\t%d B\t%d tok\n", file.RelativePath, file.Size, file.Tokens)
+	}
+
+	fmt.Fprintf(w, "  %d files sent, %d omitted: %d bytes, ~%d tokens",
+		len(repo.Contents.Files), len(repo.Contents.Omitted), totalSize(repo.Contents.Files), totalTokens)
+	if redacted > 0 {
+		fmt.Fprintf(w, ", %d redacted", redacted)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w)
+}
+
+// writeFindings ends a Nix or dotfiles summary line with the number of
+// secrets redacted from it, then lists where each was and the files that
+// could not be read or parsed
+func writeFindings(w io.Writer, redactions []Redaction, warnings []string) {
+	if len(redactions) > 0 {
+		fmt.Fprintf(w, ", %d redacted", len(redactions))
+	}
+	fmt.Fprintln(w)
+	for _, r := range redactions {
+		source := r.Source
+		if r.Line > 0 {
+			source = fmt.Sprintf("%s:%d", r.Source, r.Line)
+		}
+		fmt.Fprintf(w, "  redacted\t%s\t%s\n", source, r.Rule)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "  skipped\t%s\n", warning)
+	}
+	fmt.Fprintln(w)
+}
+
+// totalSize sums the sizes of files
+func totalSize(files []FileContent) int {
+	total := 0
+	for _, file := range files {
+		total += file.Size
+	}
+	return total
+}
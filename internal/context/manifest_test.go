@@ -0,0 +1,69 @@
+package context
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteManifest(t *testing.T) {
+	ctx := &Context{
+		ConfiguredRepos: []*RepositoryContext{{
+			Path:   "/cache/dotfiles",
+			Source: "https://example.com/dotfiles.git",
+			Contents: &RepoContents{
+				Files: []FileContent{
+					{RelativePath: "flake.nix", Content: "{ }", Size: 3, Tokens: 2, Redactions: []Redaction{{Rule: "github-token", Line: 3}}},
+					{RelativePath: "big.nix", Content: "cut", Size: 3, Tokens: 1, Truncated: true, OriginalSize: 9000, OriginalTokens: 2500},
+				},
+				Omitted: []OmittedFile{{RelativePath: "huge.txt", Size: 50000, Tokens: 12000}},
+			},
+		}},
+		CurrentRepo: &RepositoryContext{Path: "/src/app"},
+		Warnings:    []string{"Dotfiles /home/me/old left out: no such directory"},
+		Filesystem:  &FilesystemContext{CurrentDir: "/src/app/cmd"},
+		NixConfig: &NixContext{
+			ConfigPath:   "/etc/nixos",
+			Files:        []string{"configuration.nix"},
+			Packages:     []string{"git", "vim"},
+			SystemConfig: map[string]any{"networking.hostName": "laptop"},
+			Inputs:       []FlakeInput{{Name: "nixpkgs", Stale: true}, {Name: "home-manager"}},
+			Redactions:   []Redaction{{Rule: "github-token", Source: "inputs.private.url"}},
+			Warnings:     []string{"hosts/broken.nix:3: unexpected end of input"},
+		},
+		Dotfiles: &DotfilesContext{
+			DotfilesPath:  "/home/me/dotfiles",
+			IsHomeManager: true,
+			Configs:       map[string]any{"git": map[string]any{}, "tmux": map[string]any{}},
+			Keybindings:   map[string][]Keybind{"tmux": {{Key: "C-a"}, {Key: "|"}}},
+			Shells:        map[string]*ShellContext{"zsh": {Aliases: []ShellDefinition{{Name: "gl"}}}},
+			Redactions:    []Redaction{{Rule: "secret-assignment", Line: 12, Source: "zsh/env.zsh"}},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := WriteManifest(&out, ctx, nil); err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"Configured repository: https://example.com/dotfiles.git (/cache/dotfiles)",
+		"included   flake.nix",
+		"truncated  big.nix",
+		"(from 9000 B, 2500 tok)",
+		"omitted    huge.txt",
+		"redacted   flake.nix:3",
+		"2 files sent, 1 omitted: 6 bytes, ~3 tokens, 1 redacted",
+		"Current repository: /src/app\n  no contents read",
+		"Nix configuration: /etc/nixos\n  1 files parsed, 2 packages, 1 options, 2 flake inputs (1 stale), 1 redacted\n  redacted  inputs.private.url  github-token",
+		"  skipped   hosts/broken.nix:3: unexpected end of input",
+		"Warning: Dotfiles /home/me/old left out: no such directory",
+		"Dotfiles: /home/me/dotfiles\n  home-manager, 2 programs, 2 key bindings, 1 shell aliases, 1 redacted\n  redacted  zsh/env.zsh:12  secret-assignment",
+		"Working directory: /src/app/cmd",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected manifest to contain %q, got:\n%s", want, got)
+		}
+	}
+}
@@ -36,6 +36,19 @@ func (rf *RepoFetcher) FetchRepo(repo config.ConfiguredRepo) (string, error) {
 	return rf.fetchRemoteRepo(repo)
 }
 
+// LocateRepo returns a repository's path without touching the network: local
+// repos as configured, remote repos only if a cached clone already exists
+func (rf *RepoFetcher) LocateRepo(repo config.ConfiguredRepo) (string, error) {
+	if repo.Type == "local" {
+		return rf.FetchRepo(repo)
+	}
+
+	if _, err := os.Stat(filepath.Join(repo.CachePath, ".git")); err != nil {
+		return "", fmt.Errorf("remote repository not cached: %s", repo.Source)
+	}
+	return repo.CachePath, nil
+}
+
 // fetchRemoteRepo clones or updates a remote repository
 func (rf *RepoFetcher) fetchRemoteRepo(repo config.ConfiguredRepo) (string, error) {
 	cachePath := repo.CachePath
@@ -1,27 +1,92 @@
 package context
 
 import (
+	"encoding/json"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 )
 
+// TokenCounter counts the tokens in a piece of text. Providers supply one
+// that matches their model; EstimateTokenCount is used otherwise.
+type TokenCounter func(text string) int
+
+// EstimateTokenCount is the fallback token counter: ~4 bytes per token
+func EstimateTokenCount(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// SectionRenderer renders what a context sends besides repository files,
+// such as its Nix configuration and dotfiles, as the prompt would show it
+type SectionRenderer func(ctx *Context) string
+
+// minTruncatedTokens is the smallest remaining budget worth filling with a truncated file
+const minTruncatedTokens = 128
+
 // ContextSummarizer provides intelligent context summarization
 type ContextSummarizer struct {
-	maxContextSize int // Maximum context size in bytes
+	maxTokens   int          // Maximum context size in tokens
+	countTokens TokenCounter // Counts tokens for budgeting
+	focusDir    string       // Repo-relative directory whose neighbourhood is preferred
+	render      SectionRenderer
 }
 
-// NewContextSummarizer creates a new context summarizer
-func NewContextSummarizer(maxSize int) *ContextSummarizer {
+// NewContextSummarizer creates a new context summarizer with a token budget
+func NewContextSummarizer(maxTokens int) *ContextSummarizer {
 	return &ContextSummarizer{
-		maxContextSize: maxSize,
+		maxTokens:   maxTokens,
+		countTokens: EstimateTokenCount,
+	}
+}
+
+// SetTokenCounter sets the function used to count tokens
+func (cs *ContextSummarizer) SetTokenCounter(counter TokenCounter) {
+	if counter == nil {
+		counter = EstimateTokenCount
+	}
+	cs.countTokens = counter
+}
+
+// SetSectionRenderer sets how the Nix configuration and dotfiles are
+// rendered, so the tokens they take are counted before the rest of the
+// budget is split across repositories. Without one they are counted as
+// JSON, which overestimates them.
+func (cs *ContextSummarizer) SetSectionRenderer(render SectionRenderer) {
+	cs.render = render
+}
+
+// SetFocus makes files near dir (relative to the repository root) preferred
+// when the budget forces a choice. "" or "." disables the preference.
+func (cs *ContextSummarizer) SetFocus(dir string) {
+	cs.focusDir = filepath.ToSlash(filepath.Clean(dir))
+	if cs.focusDir == "." {
+		cs.focusDir = ""
 	}
 }
 
 // SummarizeRepoContents creates a summarized version of repository contents
 func (cs *ContextSummarizer) SummarizeRepoContents(contents *RepoContents) *RepoContents {
-	if contents == nil || contents.TotalSize <= cs.maxContextSize {
-		return contents // No need to summarize
+	if contents == nil {
+		return contents
+	}
+
+	// Count every file once; the counts are kept for reporting
+	counted := make([]FileContent, len(contents.Files))
+	totalTokens := 0
+	for i, file := range contents.Files {
+		file.Tokens = cs.countTokens(file.Content)
+		counted[i] = file
+		totalTokens += file.Tokens
+	}
+
+	if totalTokens <= cs.maxTokens {
+		return &RepoContents{
+			Files:       counted,
+			TotalSize:   contents.TotalSize,
+			TotalFiles:  contents.TotalFiles,
+			TotalTokens: totalTokens,
+		}
 	}
 
 	summarized := &RepoContents{
@@ -31,23 +96,36 @@ func (cs *ContextSummarizer) SummarizeRepoContents(contents *RepoContents) *Repo
 	}
 
 	// Prioritize files by importance
-	prioritized := cs.prioritizeFiles(contents.Files)
+	prioritized := cs.prioritizeFiles(counted)
 
-	// Add files until we hit the size limit
-	for _, file := range prioritized {
-		if summarized.TotalSize+file.Size > cs.maxContextSize {
+	// Add files until we hit the token budget
+	for i, file := range prioritized {
+		if summarized.TotalTokens+file.Tokens > cs.maxTokens {
 			// Try to add a truncated version
-			remaining := cs.maxContextSize - summarized.TotalSize
-			if remaining > 500 { // Only add if we have at least 500 bytes left
+			rest := prioritized[i:]
+			remaining := cs.maxTokens - summarized.TotalTokens
+			if remaining > minTruncatedTokens {
 				truncated := cs.truncateFile(file, remaining)
 				summarized.Files = append(summarized.Files, truncated)
 				summarized.TotalSize += truncated.Size
+				summarized.TotalTokens += truncated.Tokens
+				rest = rest[1:]
+			}
+
+			// Record everything left out so it can be reported
+			for _, omitted := range rest {
+				summarized.Omitted = append(summarized.Omitted, OmittedFile{
+					RelativePath: omitted.RelativePath,
+					Size:         omitted.Size,
+					Tokens:       omitted.Tokens,
+				})
 			}
 			break
 		}
 
 		summarized.Files = append(summarized.Files, file)
 		summarized.TotalSize += file.Size
+		summarized.TotalTokens += file.Tokens
 	}
 
 	return summarized
@@ -109,7 +187,8 @@ func (cs *ContextSummarizer) calculatePriority(file FileContent) int {
 		"flake.nix", "configuration.nix", "home.nix", "hardware-configuration.nix",
 		".bashrc", ".zshrc", ".vimrc", ".nvimrc", "init.vim", "init.lua",
 		"config.toml", "config.yaml", "config.yml", "config.json",
-		".gitconfig", ".tmux.conf", "alacritty.yml", "kitty.conf",
+		".gitconfig", ".tmux.conf", "alacritty.yml", "alacritty.toml", "kitty.conf",
+		"wezterm.lua", ".wezterm.lua", "foot.ini",
 	}
 	for _, cf := range configFiles {
 		if filename == cf {
@@ -160,6 +239,9 @@ func (cs *ContextSummarizer) calculatePriority(file FileContent) int {
 		score += 150
 	}
 
+	// Boost files near the working directory
+	score += cs.proximityScore(dir)
+
 	// Reduce priority for test files (but don't exclude them)
 	if strings.Contains(strings.ToLower(filename), "test") {
 		score -= 50
@@ -175,47 +257,108 @@ func (cs *ContextSummarizer) calculatePriority(file FileContent) int {
 	return score
 }
 
-// truncateFile creates a truncated version of a file
-func (cs *ContextSummarizer) truncateFile(file FileContent, maxSize int) FileContent {
-	if file.Size <= maxSize {
+// proximityScore scores a repo-relative directory by how few steps separate it
+// from the focus directory: 1000 in the focus directory, 250 less per step
+func (cs *ContextSummarizer) proximityScore(dir string) int {
+	if cs.focusDir == "" {
+		return 0
+	}
+
+	fileParts := splitPath(dir)
+	focusParts := splitPath(cs.focusDir)
+
+	common := 0
+	for common < len(fileParts) && common < len(focusParts) && fileParts[common] == focusParts[common] {
+		common++
+	}
+	steps := (len(fileParts) - common) + (len(focusParts) - common)
+
+	if score := 1000 - 250*steps; score > 0 {
+		return score
+	}
+	return 0
+}
+
+// splitPath splits a relative directory into its elements ("." has none)
+func splitPath(dir string) []string {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if dir == "." {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// truncateFile creates a truncated version of a file that fits in maxTokens
+func (cs *ContextSummarizer) truncateFile(file FileContent, maxTokens int) FileContent {
+	if file.Tokens <= maxTokens {
 		return file
 	}
 
 	// Reserve space for truncation message
 	truncMsg := "\n\n... [truncated] ...\n"
-	availableSize := maxSize - len(truncMsg)
-	if availableSize <= 0 {
+	availableTokens := maxTokens - cs.countTokens(truncMsg)
+	if availableTokens <= 0 || file.Tokens == 0 {
+		content := "[file too large to include]"
 		return FileContent{
-			Path:         file.Path,
-			RelativePath: file.RelativePath,
-			Content:      "[file too large to include]",
-			Size:         len("[file too large to include]"),
+			Path:           file.Path,
+			RelativePath:   file.RelativePath,
+			Content:        content,
+			Size:           len(content),
+			Tokens:         cs.countTokens(content),
+			Truncated:      true,
+			OriginalSize:   file.Size,
+			OriginalTokens: file.Tokens,
 		}
 	}
 
-	// Take first part of file
+	// Take the first part of the file, scaling bytes by the file's own token
+	// density and shrinking until the counter agrees it fits
+	availableSize := runeBoundary(file.Content, len(file.Content)*availableTokens/file.Tokens)
 	truncated := file.Content[:availableSize] + truncMsg
+	tokens := cs.countTokens(truncated)
+	for tokens > maxTokens && availableSize > 0 {
+		availableSize = runeBoundary(file.Content, availableSize*9/10)
+		truncated = file.Content[:availableSize] + truncMsg
+		tokens = cs.countTokens(truncated)
+	}
 
 	return FileContent{
-		Path:         file.Path,
-		RelativePath: file.RelativePath,
-		Content:      truncated,
-		Size:         len(truncated),
+		Path:           file.Path,
+		RelativePath:   file.RelativePath,
+		Content:        truncated,
+		Size:           len(truncated),
+		Tokens:         tokens,
+		Truncated:      true,
+		OriginalSize:   file.Size,
+		OriginalTokens: file.Tokens,
+	}
+}
+
+// runeBoundary moves n back to the start of a UTF-8 sequence so s[:n] stays valid
+func runeBoundary(s string, n int) int {
+	if n >= len(s) {
+		return len(s)
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
 	}
+	return n
 }
 
-// SummarizeContext applies summarization to an entire context
+// SummarizeContext applies summarization to an entire context. The Nix
+// configuration and dotfiles are sent whole, so their tokens are taken out
+// of the budget first and repositories share what is left.
 func (cs *ContextSummarizer) SummarizeContext(ctx *Context) *Context {
 	if ctx == nil {
 		return ctx
 	}
 
 	summarized := &Context{
-		Timestamp:   ctx.Timestamp,
-		Filesystem:  ctx.Filesystem,
-		NixConfig:   ctx.NixConfig,
-		Dotfiles:    ctx.Dotfiles,
-		Screenshot:  ctx.Screenshot,
+		Timestamp:  ctx.Timestamp,
+		Filesystem: ctx.Filesystem,
+		NixConfig:  ctx.NixConfig,
+		Dotfiles:   ctx.Dotfiles,
+		Screenshot: ctx.Screenshot,
 	}
 
 	// Calculate how much space to allocate per repo
@@ -228,11 +371,12 @@ func (cs *ContextSummarizer) SummarizeContext(ctx *Context) *Context {
 		return summarized
 	}
 
-	sizePerRepo := cs.maxContextSize / totalRepos
+	tokensPerRepo := max(cs.maxTokens-cs.SectionTokens(ctx), 0) / totalRepos
 
 	// Summarize configured repos
 	if len(ctx.ConfiguredRepos) > 0 {
-		repoSummarizer := NewContextSummarizer(sizePerRepo)
+		repoSummarizer := NewContextSummarizer(tokensPerRepo)
+		repoSummarizer.SetTokenCounter(cs.countTokens)
 		summarized.ConfiguredRepos = make([]*RepositoryContext, len(ctx.ConfiguredRepos))
 		for i, repo := range ctx.ConfiguredRepos {
 			summarizedRepo := &RepositoryContext{
@@ -248,26 +392,56 @@ func (cs *ContextSummarizer) SummarizeContext(ctx *Context) *Context {
 
 	// Summarize current repo
 	if ctx.CurrentRepo != nil {
-		repoSummarizer := NewContextSummarizer(sizePerRepo)
+		repoSummarizer := NewContextSummarizer(tokensPerRepo)
+		repoSummarizer.SetTokenCounter(cs.countTokens)
+		repoSummarizer.SetFocus(ctx.CurrentRepo.FocusDir)
 		summarized.CurrentRepo = &RepositoryContext{
 			Path:     ctx.CurrentRepo.Path,
 			Remote:   ctx.CurrentRepo.Remote,
 			Source:   ctx.CurrentRepo.Source,
 			Type:     ctx.CurrentRepo.Type,
 			Contents: repoSummarizer.SummarizeRepoContents(ctx.CurrentRepo.Contents),
+			FocusDir: ctx.CurrentRepo.FocusDir,
 		}
 	}
 
 	return summarized
 }
 
-// EstimateTokens provides a rough estimate of token count
+// SectionTokens counts the tokens of the Nix configuration and dotfiles of
+// ctx, rendered by the section renderer or as JSON if none is set
+func (cs *ContextSummarizer) SectionTokens(ctx *Context) int {
+	if ctx == nil || ctx.NixConfig == nil && ctx.Dotfiles == nil {
+		return 0
+	}
+	if cs.render != nil {
+		return cs.countTokens(cs.render(ctx))
+	}
+
+	data, err := json.Marshal(struct {
+		NixConfig *NixContext      `json:"nix_config,omitempty"`
+		Dotfiles  *DotfilesContext `json:"dotfiles,omitempty"`
+	}{ctx.NixConfig, ctx.Dotfiles})
+	if err != nil {
+		return 0
+	}
+	return cs.countTokens(string(data))
+}
+
+// EstimateTokens returns the token count of repository contents
 func (cs *ContextSummarizer) EstimateTokens(contents *RepoContents) int {
 	if contents == nil {
 		return 0
 	}
-	// Rough estimate: ~4 characters per token
-	return contents.TotalSize / 4
+	if contents.TotalTokens > 0 {
+		return contents.TotalTokens
+	}
+
+	total := 0
+	for _, file := range contents.Files {
+		total += cs.countTokens(file.Content)
+	}
+	return total
 }
 
 // FormatSummaryStats returns a human-readable summary of what was included
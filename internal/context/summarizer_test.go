@@ -0,0 +1,176 @@
+package context
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// wordCounter counts whitespace-separated words, making budgets easy to reason about
+func wordCounter(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestSummarizeRepoContentsWithinBudget(t *testing.T) {
+	contents := &RepoContents{
+		Files: []FileContent{
+			{RelativePath: "a.go", Content: "one two three", Size: 13},
+		},
+		TotalFiles: 1,
+		TotalSize:  13,
+	}
+
+	cs := NewContextSummarizer(10)
+	cs.SetTokenCounter(wordCounter)
+
+	got := cs.SummarizeRepoContents(contents)
+	if len(got.Files) != 1 || got.TotalTokens != 3 || got.Files[0].Tokens != 3 {
+		t.Errorf("Expected contents unchanged with token counts, got %+v", got)
+	}
+}
+
+func TestSummarizeRepoContentsBudgetsTokens(t *testing.T) {
+	// Both files are the same size in bytes but very different in tokens
+	dense := strings.Repeat("a ", 300)
+	sparse := strings.Repeat("b", 600)
+
+	contents := &RepoContents{
+		Files: []FileContent{
+			{RelativePath: "dense.go", Content: dense, Size: len(dense)},
+			{RelativePath: "flake.nix", Content: sparse, Size: len(sparse)},
+		},
+		TotalFiles: 2,
+		TotalSize:  len(dense) + len(sparse),
+	}
+
+	cs := NewContextSummarizer(200)
+	cs.SetTokenCounter(wordCounter)
+
+	got := cs.SummarizeRepoContents(contents)
+	if got.TotalTokens > 200 {
+		t.Errorf("Expected at most 200 tokens, got %d", got.TotalTokens)
+	}
+	if len(got.Files) != 2 {
+		t.Fatalf("Expected flake.nix plus truncated dense.go, got %d files", len(got.Files))
+	}
+	if got.Files[0].RelativePath != "flake.nix" || got.Files[0].Tokens != 1 {
+		t.Errorf("Expected flake.nix first at 1 token, got %+v", got.Files[0].RelativePath)
+	}
+	if !strings.Contains(got.Files[1].Content, "[truncated]") {
+		t.Error("Expected dense.go to be truncated")
+	}
+	if !got.Files[1].Truncated || got.Files[1].OriginalSize != len(dense) || got.Files[1].OriginalTokens != 300 {
+		t.Errorf("Expected truncation to be recorded, got %+v", got.Files[1])
+	}
+}
+
+func TestSummarizeRepoContentsRecordsOmitted(t *testing.T) {
+	contents := &RepoContents{
+		Files: []FileContent{
+			{RelativePath: "flake.nix", Content: strings.Repeat("a ", 100), Size: 200},
+			{RelativePath: "notes.txt", Content: strings.Repeat("b ", 100), Size: 200},
+			{RelativePath: "more.txt", Content: strings.Repeat("c ", 100), Size: 200},
+		},
+		TotalFiles: 3,
+		TotalSize:  600,
+	}
+
+	// Room for flake.nix only; what's left is too small to truncate into
+	cs := NewContextSummarizer(150)
+	cs.SetTokenCounter(wordCounter)
+
+	got := cs.SummarizeRepoContents(contents)
+	if len(got.Files) != 1 || got.Files[0].RelativePath != "flake.nix" {
+		t.Fatalf("Expected only flake.nix, got %+v", got.Files)
+	}
+	if len(got.Omitted) != 2 || got.Omitted[0].Tokens != 100 || got.Omitted[0].Size != 200 {
+		t.Errorf("Expected two omitted files with counts, got %+v", got.Omitted)
+	}
+}
+
+func TestTruncateFileKeepsValidUTF8(t *testing.T) {
+	content := strings.Repeat("ü", 1000)
+	file := FileContent{RelativePath: "x", Content: content, Size: len(content), Tokens: EstimateTokenCount(content)}
+
+	cs := NewContextSummarizer(0)
+	truncated := cs.truncateFile(file, 150)
+	if !utf8.ValidString(truncated.Content) {
+		t.Error("Expected truncated content to be valid UTF-8")
+	}
+	if truncated.Tokens > 150 {
+		t.Errorf("Expected at most 150 tokens, got %d", truncated.Tokens)
+	}
+}
+
+func TestSummarizerPrefersFilesNearFocus(t *testing.T) {
+	body := strings.Repeat("x ", 100)
+	contents := &RepoContents{
+		Files: []FileContent{
+			{RelativePath: "pkg/other/other.go", Content: body, Size: len(body)},
+			{RelativePath: "pkg/api/handler.go", Content: body, Size: len(body)},
+			{RelativePath: "docs/far/away/notes.go", Content: body, Size: len(body)},
+		},
+		TotalFiles: 3,
+	}
+
+	cs := NewContextSummarizer(100)
+	cs.SetTokenCounter(wordCounter)
+	cs.SetFocus("pkg/api")
+
+	got := cs.SummarizeRepoContents(contents)
+	if len(got.Files) != 1 || got.Files[0].RelativePath != "pkg/api/handler.go" {
+		t.Errorf("Expected the file in the focus directory to be kept, got %+v", got.Files)
+	}
+
+	if near, far := cs.proximityScore("pkg/other"), cs.proximityScore("docs/far/away"); near <= far {
+		t.Errorf("Expected sibling directory (%d) to outscore a distant one (%d)", near, far)
+	}
+}
+
+func TestSummarizeContextBudgetsNixAndDotfiles(t *testing.T) {
+	repo := func(path string) *RepositoryContext {
+		content := strings.Repeat("a ", 300)
+		return &RepositoryContext{Path: path, Contents: &RepoContents{
+			Files:      []FileContent{{RelativePath: "main.go", Content: content, Size: len(content)}},
+			TotalFiles: 1,
+			TotalSize:  len(content),
+		}}
+	}
+	ctx := &Context{
+		ConfiguredRepos: []*RepositoryContext{repo("/a")},
+		CurrentRepo:     repo("/b"),
+		NixConfig:       &NixContext{ConfigPath: "/etc/nixos"},
+		Dotfiles:        &DotfilesContext{DotfilesPath: "/dotfiles"},
+	}
+
+	// 400 of the 1000 tokens go to the settings, leaving 300 per repository
+	cs := NewContextSummarizer(1000)
+	cs.SetTokenCounter(wordCounter)
+	cs.SetSectionRenderer(func(ctx *Context) string { return strings.Repeat("opt ", 400) })
+
+	got := cs.SummarizeContext(ctx)
+	for _, r := range []*RepositoryContext{got.ConfiguredRepos[0], got.CurrentRepo} {
+		if tokens := r.Contents.TotalTokens; tokens > 300 || tokens < 250 {
+			t.Errorf("%s: expected close to 300 tokens, got %d", r.Path, tokens)
+		}
+	}
+	if got.NixConfig != ctx.NixConfig || got.Dotfiles != ctx.Dotfiles {
+		t.Error("Expected Nix configuration and dotfiles to be kept whole")
+	}
+
+	// Settings larger than the budget leave no room for files
+	cs.SetSectionRenderer(func(ctx *Context) string { return strings.Repeat("opt ", 2000) })
+	got = cs.SummarizeContext(ctx)
+	if files := got.CurrentRepo.Contents.Files; len(files) != 0 {
+		t.Errorf("Expected every file omitted, got %d", len(files))
+	}
+
+	// Without a renderer the settings are still counted
+	cs.SetSectionRenderer(nil)
+	if cs.SectionTokens(ctx) == 0 {
+		t.Error("Expected settings to be counted without a renderer")
+	}
+	if cs.SectionTokens(&Context{}) != 0 {
+		t.Error("Expected no tokens without Nix configuration or dotfiles")
+	}
+}
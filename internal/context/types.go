@@ -11,6 +11,7 @@ type Context struct {
 	NixConfig         *NixContext          `json:"nix_config,omitempty"`
 	Dotfiles          *DotfilesContext     `json:"dotfiles,omitempty"`
 	Screenshot        *Screenshot          `json:"screenshot,omitempty"`
+	Warnings          []string             `json:"warnings,omitempty"` // Sources that could not be gathered at all
 }
 
 // RepositoryContext contains git repository information
@@ -20,6 +21,7 @@ type RepositoryContext struct {
 	Source   string        `json:"source,omitempty"` // Original source (URL or path)
 	Type     string        `json:"type,omitempty"`   // "local" or "remote" or "current"
 	Contents *RepoContents `json:"contents,omitempty"` // Actual file contents
+	FocusDir string        `json:"focus_dir,omitempty"` // Working directory relative to Path (current repo only)
 }
 
 // FilesystemContext contains current directory and file information
@@ -31,22 +33,73 @@ type FilesystemContext struct {
 
 // NixContext contains parsed Nix configuration
 type NixContext struct {
-	ConfigPath       string              `json:"config_path"`
-	IsFlake          bool                `json:"is_flake"`
-	Packages         []string            `json:"packages,omitempty"`
-	SystemConfig     map[string]any      `json:"system_config,omitempty"`
-	LastParsed       time.Time           `json:"last_parsed"`
-	CacheKey         string              `json:"cache_key"`
+	ConfigPath   string                  `json:"config_path"`
+	IsFlake      bool                    `json:"is_flake"`
+	Packages     []string                `json:"packages,omitempty"`
+	SystemConfig map[string]any          `json:"system_config,omitempty"`
+	Sources      map[string]OptionSource `json:"sources,omitempty"`          // Where each SystemConfig option was set
+	Files        []string                `json:"files,omitempty"`            // Files parsed, relative to ConfigPath
+	Inputs       []FlakeInput            `json:"inputs,omitempty"`           // Flake inputs, sorted by name
+	StaleAfter   int                     `json:"stale_after_days,omitempty"` // Days after which a locked input is flagged stale (0 = never)
+	Redactions   []Redaction             `json:"redactions,omitempty"`       // Secrets removed from options, packages and inputs
+	Warnings     []string                `json:"warnings,omitempty"`         // Files that could not be read or parsed
+	LastParsed   time.Time               `json:"last_parsed"`
+	CacheKey     string                  `json:"cache_key"`
+}
+
+// FlakeInput is a flake input and the revision it is locked to
+type FlakeInput struct {
+	Name         string            `json:"name"`
+	URL          string            `json:"url,omitempty"`
+	Follows      string            `json:"follows,omitempty"`       // Input this one follows instead of being locked
+	InputFollows map[string]string `json:"input_follows,omitempty"` // Its own inputs redirected to ours
+	Rev          string            `json:"rev,omitempty"`           // Empty when not locked
+	LastModified time.Time         `json:"last_modified,omitempty"`
+	Stale        bool              `json:"stale,omitempty"` // Locked revision is more than StaleAfter days old
+}
+
+// OptionSource is the file and line where a configuration option was set
+type OptionSource struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
 }
 
 // DotfilesContext contains parsed dotfiles configuration
 type DotfilesContext struct {
-	DotfilesPath     string              `json:"dotfiles_path"`
-	IsHomeManager    bool                `json:"is_home_manager"`
-	Configs          map[string]any      `json:"configs,omitempty"`
-	Keybindings      map[string][]Keybind `json:"keybindings,omitempty"`
-	LastParsed       time.Time           `json:"last_parsed"`
-	CacheKey         string              `json:"cache_key"`
+	DotfilesPath  string                   `json:"dotfiles_path"`
+	IsHomeManager bool                     `json:"is_home_manager"`
+	Configs       map[string]any           `json:"configs,omitempty"`     // Settings keyed by program, e.g. Configs["git"]["userName"]
+	Sources       map[string]OptionSource  `json:"sources,omitempty"`     // Where each setting was set, keyed "<program>.<setting>"
+	Keybindings   map[string][]Keybind     `json:"keybindings,omitempty"` // Keyed by tool, e.g. Keybindings["tmux"]
+	Shells        map[string]*ShellContext `json:"shells,omitempty"`      // Keyed by shell, e.g. Shells["zsh"]
+	Redactions    []Redaction              `json:"redactions,omitempty"`  // Secrets removed from settings, bindings and definitions
+	Warnings      []string                 `json:"warnings,omitempty"`    // Why home-manager options could not all be read
+	LastParsed    time.Time                `json:"last_parsed"`
+	CacheKey      string                   `json:"cache_key"`
+}
+
+// ShellContext contains what a shell's startup files define
+type ShellContext struct {
+	Aliases   []ShellDefinition `json:"aliases,omitempty"` // Including fish abbreviations
+	Functions []ShellDefinition `json:"functions,omitempty"`
+	Exports   []ShellDefinition `json:"exports,omitempty"` // Exported environment variables
+	Plugins   []ShellPlugin     `json:"plugins,omitempty"`
+}
+
+// ShellDefinition is an alias, function or environment variable
+type ShellDefinition struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"` // Expansion, function body or variable value
+	File  string `json:"file,omitempty"`
+	Line  int    `json:"line,omitempty"`
+}
+
+// ShellPlugin is a shell plugin, or a plugin manager loaded by itself
+type ShellPlugin struct {
+	Manager string `json:"manager"`        // e.g. "oh-my-zsh" or "zinit"
+	Name    string `json:"name,omitempty"` // Empty for the manager itself
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
 }
 
 // Keybind represents a keyboard binding
@@ -54,7 +107,9 @@ type Keybind struct {
 	Key         string `json:"key"`
 	Command     string `json:"command"`
 	Description string `json:"description,omitempty"`
-	Mode        string `json:"mode,omitempty"`
+	Mode        string `json:"mode,omitempty"` // Key table or mode, e.g. "prefix" for tmux
+	File        string `json:"file,omitempty"` // Where the binding was set, relative to DotfilesPath
+	Line        int    `json:"line,omitempty"`
 }
 
 // Screenshot contains screenshot data
@@ -73,4 +128,5 @@ type GatherOptions struct {
 	IncludeDotfiles    bool
 	CaptureScreenshot  bool
 	WorkingDir         string // If empty, uses current directory
+	Offline            bool   // Use cached remote repos as-is instead of cloning or fetching
 }
@@ -0,0 +1,192 @@
+package dotfiles
+
+import (
+	"path"
+	"strings"
+
+	"codex/internal/nix"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register(Extractor{
+		Tool:     "alacritty",
+		Match:    isAlacrittyConfig,
+		Parse:    terminalParser(parseAlacritty).keybinds,
+		Settings: terminalParser(parseAlacritty).settings,
+		Nix:      alacrittyNix,
+	})
+}
+
+// alacrittySettings are the font and theme settings kept, by dotted path.
+// Themes are usually imported.
+var alacrittySettings = []string{
+	"font.normal.family", "font.bold.family", "font.italic.family", "font.size",
+	"import", "general.import", "window.opacity",
+}
+
+// alacrittyBindings are where bindings are listed: keyboard.bindings since
+// 0.13, key_bindings before
+var alacrittyBindings = [][]string{{"key_bindings"}, {"keyboard", "bindings"}}
+
+// isAlacrittyConfig matches alacritty.toml and alacritty.yml, and TOML and
+// YAML files in an alacritty directory
+func isAlacrittyConfig(rel string) bool {
+	switch path.Ext(rel) {
+	case ".toml", ".yml", ".yaml":
+		return strings.TrimSuffix(path.Base(rel), path.Ext(rel)) == "alacritty" || inDir(rel, "alacritty")
+	}
+	return false
+}
+
+// parseAlacritty reads the bindings and font settings of a TOML config, or
+// of a YAML one as alacritty used before 0.13
+func parseAlacritty(src, file string, first int) *terminalConfig {
+	var doc docValue
+	if path.Ext(file) == ".toml" {
+		doc = parseTOML(src, first)
+	} else {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(src), &node); err != nil {
+			return &terminalConfig{}
+		}
+		doc = yamlDoc(&node, first-1)
+	}
+	return alacrittyConfig(doc, file)
+}
+
+// alacrittyConfig reads the bindings and font settings of a parsed config
+func alacrittyConfig(doc docValue, file string) *terminalConfig {
+	t := &terminalConfig{}
+	for _, at := range alacrittyBindings {
+		list, _ := doc.get(at...)
+		for _, item := range list.list() {
+			if b, ok := alacrittyBind(item, file); ok {
+				t.binds = replaceBind(t.binds, b)
+			}
+		}
+	}
+
+	for _, key := range alacrittySettings {
+		if v, ok := doc.get(strings.Split(key, ".")...); ok {
+			t.set(key, v.plain(), file, v.line)
+		}
+	}
+	return t
+}
+
+// alacrittyBind reads a binding: { key, mods, mode, and one of action, chars
+// or command }
+func alacrittyBind(item docValue, file string) (Keybind, bool) {
+	key := item.str("key")
+	if key == "" {
+		return Keybind{}, false
+	}
+	b := Keybind{Key: keyCombo(item.str("mods"), "|", key), Mode: item.str("mode"), File: file, Line: item.line}
+
+	command, _ := item.get("command")
+	switch {
+	case item.str("action") != "":
+		b.Command = item.str("action")
+	case item.str("chars") != "":
+		b.Command, b.Description = item.str("chars"), "Sends text"
+	case command.value != nil:
+		// A program, or { program, args }
+		b.Command = command.str("program")
+		if s, ok := command.value.(string); ok {
+			b.Command = s
+		}
+		if args, ok := command.get("args"); ok {
+			for _, arg := range args.list() {
+				if s, ok := arg.value.(string); ok {
+					b.Command += " " + s
+				}
+			}
+		}
+		b.Description = "Runs command"
+	}
+	if b.Command == "" {
+		return Keybind{}, false
+	}
+	b.Command = oneLine(b.Command, maxDefinitionValue)
+	return b, true
+}
+
+// yamlDoc converts a YAML node, adding offset to its line numbers
+func yamlDoc(node *yaml.Node, offset int) docValue {
+	v := docValue{line: node.Line + offset}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			return yamlDoc(node.Content[0], offset)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			return yamlDoc(node.Alias, offset)
+		}
+	case yaml.MappingNode:
+		table := make(map[string]docValue)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			table[node.Content[i].Value] = yamlDoc(node.Content[i+1], offset)
+		}
+		v.value = table
+	case yaml.SequenceNode:
+		items := make([]docValue, len(node.Content))
+		for i, item := range node.Content {
+			items[i] = yamlDoc(item, offset)
+		}
+		v.value = items
+	case yaml.ScalarNode:
+		var value any
+		if node.Decode(&value) == nil {
+			if n, ok := value.(int); ok {
+				value = int64(n)
+			}
+			v.value = value
+		}
+	}
+	return v
+}
+
+// nixDoc converts a home-manager setting, whose attribute sets have dotted
+// keys, to a value of nested tables at line
+func nixDoc(value any, line int) docValue {
+	switch value := value.(type) {
+	case []any:
+		items := make([]docValue, len(value))
+		for i, item := range value {
+			items[i] = nixDoc(item, line)
+		}
+		return docValue{value: items, line: line}
+	case map[string]any:
+		table := make(map[string]docValue)
+		for key, item := range value {
+			path := strings.Split(key, ".")
+			tomlTable(table, path[:len(path)-1], line)[path[len(path)-1]] = nixDoc(item, line)
+		}
+		return docValue{value: table, line: line}
+	}
+	return docValue{value: value, line: line}
+}
+
+// alacrittyNix reads the bindings listed in home-manager's
+// programs.alacritty.settings
+func alacrittyNix(cfg *nix.Config) []Keybind {
+	program := cfg.Programs()["alacritty"]
+	if program == nil {
+		return nil
+	}
+
+	var binds []Keybind
+	for _, at := range alacrittyBindings {
+		setting := "settings." + strings.Join(at, ".")
+		src := program.Sources[setting]
+		for _, item := range nixDoc(program.Settings[setting], src.Line).list() {
+			if b, ok := alacrittyBind(item, src.File); ok {
+				binds = replaceBind(binds, b)
+			}
+		}
+	}
+	return binds
+}
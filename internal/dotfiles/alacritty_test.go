@@ -0,0 +1,82 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAlacrittyTOML(t *testing.T) {
+	src := `[general]
+import = ["~/.config/alacritty/themes/gruvbox_dark.toml"]
+
+[font]
+size = 12.0
+normal = { family = "Iosevka", style = "Regular" }
+
+[[keyboard.bindings]]
+key = "N"
+mods = "Control|Shift"
+action = "SpawnNewInstance"
+
+[[keyboard.bindings]]
+key = "Back"
+mods = "Alt"
+chars = "\u001b\u007f"
+
+[[keyboard.bindings]]
+key = "Return"
+mods = "Super"
+mode = "~Vi"
+command = { program = "alacritty", args = ["--working-directory", "~"] }
+`
+
+	got := parseAlacritty(src, "alacritty.toml", 1)
+	want := []Keybind{
+		{Key: "Control+Shift+N", Command: "SpawnNewInstance", File: "alacritty.toml", Line: 8},
+		{Key: "Alt+Back", Command: "\x1b\x7f", Description: "Sends text", File: "alacritty.toml", Line: 13},
+		{Key: "Super+Return", Command: "alacritty --working-directory ~", Description: "Runs command", Mode: "~Vi", File: "alacritty.toml", Line: 18},
+	}
+	if !reflect.DeepEqual(got.binds, want) {
+		t.Errorf("parseAlacritty() binds =\n%+v\nwant\n%+v", got.binds, want)
+	}
+
+	wantSettings := []Setting{
+		{Key: "font.normal.family", Value: "Iosevka", File: "alacritty.toml", Line: 6},
+		{Key: "font.size", Value: 12.0, File: "alacritty.toml", Line: 5},
+		{Key: "general.import", Value: []any{"~/.config/alacritty/themes/gruvbox_dark.toml"}, File: "alacritty.toml", Line: 2},
+	}
+	if !reflect.DeepEqual(got.settings, wantSettings) {
+		t.Errorf("parseAlacritty() settings =\n%+v\nwant\n%+v", got.settings, wantSettings)
+	}
+}
+
+func TestParseAlacrittyYAML(t *testing.T) {
+	src := `font:
+  normal:
+    family: Hack
+  size: 10
+
+key_bindings:
+  - { key: V, mods: Control|Shift, action: Paste }
+  - key: T
+    mods: Command
+    command: { program: open, args: ["-a", "Alacritty"] }
+`
+
+	got := parseAlacritty(src, "alacritty.yml", 1)
+	want := []Keybind{
+		{Key: "Control+Shift+V", Command: "Paste", File: "alacritty.yml", Line: 7},
+		{Key: "Command+T", Command: "open -a Alacritty", Description: "Runs command", File: "alacritty.yml", Line: 8},
+	}
+	if !reflect.DeepEqual(got.binds, want) {
+		t.Errorf("parseAlacritty() binds =\n%+v\nwant\n%+v", got.binds, want)
+	}
+
+	wantSettings := []Setting{
+		{Key: "font.normal.family", Value: "Hack", File: "alacritty.yml", Line: 3},
+		{Key: "font.size", Value: int64(10), File: "alacritty.yml", Line: 4},
+	}
+	if !reflect.DeepEqual(got.settings, wantSettings) {
+		t.Errorf("parseAlacritty() settings =\n%+v\nwant\n%+v", got.settings, wantSettings)
+	}
+}
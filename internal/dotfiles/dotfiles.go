@@ -0,0 +1,275 @@
+// Package dotfiles extracts key bindings from tool configuration files in a
+// dotfiles repository and from the same tools configured through Nix, along
+// with the aliases, functions, variables and plugins of shell startup files
+// and the font and theme settings of terminal emulators.
+package dotfiles
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"codex/internal/ignore"
+	"codex/internal/nix"
+)
+
+// Keybind is a key binding read from a tool's configuration
+type Keybind struct {
+	Key         string
+	Command     string
+	Description string
+	Mode        string // Key table or mode the binding is active in, e.g. "prefix" for tmux
+	File        string // Relative to the dotfiles root
+	Line        int
+
+	// variable marks a variable definition, such as sway's set $mod, carried
+	// to Resolve so files that include the one setting it can be expanded.
+	// Resolve drops these; they are never returned.
+	variable bool
+}
+
+// Setting is a notable setting read from a tool's config file, such as a
+// terminal's font or theme
+type Setting struct {
+	Key   string // e.g. "font_family" or "font.normal.family"
+	Value any
+	File  string
+	Line  int
+}
+
+// Extractor reads the key bindings of one tool
+type Extractor struct {
+	Tool  string
+	Match func(rel string) bool                      // Reports whether a file, relative to the dotfiles root, is a config file of the tool
+	Parse func(src, file string, line int) []Keybind // Parses config text from file whose first line is line
+	Nix   func(cfg *nix.Config) []Keybind            // Reads bindings set through Nix options (optional)
+
+	// Resolve rewrites the bindings read from all files once they are
+	// collected, e.g. to expand a leader key set in another file (optional)
+	Resolve func(binds []Keybind) []Keybind
+
+	// Settings reads notable settings from config text (optional). Settings
+	// made through Nix are already in the program's options.
+	Settings func(src, file string, line int) []Setting
+}
+
+// extractors holds every extractor, keyed by tool name
+var extractors = make(map[string]Extractor)
+
+// Register makes an extractor available. Each tool registers itself from an
+// init function in its own file. Register panics if the tool is already
+// registered or the extractor cannot read anything.
+func Register(e Extractor) {
+	if e.Tool == "" || (e.Parse == nil && e.Nix == nil && e.Settings == nil) {
+		panic("dotfiles: Register requires a tool and a parser")
+	}
+	if _, exists := extractors[e.Tool]; exists {
+		panic("dotfiles: Register called twice for " + e.Tool)
+	}
+	extractors[e.Tool] = e
+}
+
+// Tools returns the names of all tools with an extractor, sorted
+func Tools() []string {
+	tools := make([]string, 0, len(extractors))
+	for tool := range extractors {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// maxConfigSize is the largest config file read; anything bigger is not hand-written
+const maxConfigSize = 1 << 20
+
+// skipDirs are never searched for config files, whatever the ignore files say
+var skipDirs = map[string]bool{".git": true, "node_modules": true, ".direnv": true, "result": true}
+
+// Extracted is everything read from a dotfiles repository: key bindings and
+// settings keyed by tool, and what each shell defines keyed by shell
+type Extracted struct {
+	Keybinds map[string][]Keybind
+	Settings map[string][]Setting
+	Shells   map[string]*Shell
+}
+
+// ExtractAll reads the key bindings, settings and shell definitions of every
+// tool in one walk of the config files under root and, if cfg is not nil,
+// from the Nix configuration there. Files the repository's .gitignore or
+// .codexignore files exclude are not read. Tools and shells that set nothing
+// are left out.
+func ExtractAll(root string, cfg *nix.Config) *Extracted {
+	x := &Extracted{
+		Keybinds: make(map[string][]Keybind),
+		Settings: make(map[string][]Setting),
+		Shells:   make(map[string]*Shell),
+	}
+	tools := Tools()
+
+	walk(root, func(path, rel string) {
+		var src string
+		read, ok := false, false
+		for _, tool := range tools {
+			e := extractors[tool]
+			shell, isShell := shellExtractors[tool]
+			if e.Match == nil || !e.Match(rel) {
+				continue
+			}
+			if !read {
+				src, ok = readConfig(path)
+				read = true
+			}
+			if !ok {
+				return
+			}
+
+			// A shell's file is parsed once for its bindings and definitions
+			if isShell {
+				x.shell(tool).merge(shell.parse(src, rel, 1))
+				continue
+			}
+			if e.Parse != nil {
+				x.Keybinds[tool] = append(x.Keybinds[tool], e.Parse(src, rel, 1)...)
+			}
+			if e.Settings != nil {
+				for _, s := range e.Settings(src, rel, 1) {
+					x.Settings[tool] = slices.DeleteFunc(x.Settings[tool], func(old Setting) bool { return old.Key == s.Key })
+					x.Settings[tool] = append(x.Settings[tool], s)
+				}
+			}
+		}
+	})
+
+	if cfg != nil {
+		for _, tool := range tools {
+			e := extractors[tool]
+			if shell, isShell := shellExtractors[tool]; isShell {
+				x.shell(tool).merge(shellNix(cfg, tool, shell))
+			} else if e.Nix != nil {
+				x.Keybinds[tool] = append(x.Keybinds[tool], e.Nix(cfg)...)
+			}
+		}
+	}
+
+	for name, s := range x.Shells {
+		x.Keybinds[name] = append(x.Keybinds[name], s.binds...)
+		if len(s.Aliases)+len(s.Functions)+len(s.Exports)+len(s.Plugins) == 0 {
+			delete(x.Shells, name)
+		}
+	}
+	for tool, binds := range x.Keybinds {
+		if resolve := extractors[tool].Resolve; resolve != nil {
+			binds = resolve(binds)
+			x.Keybinds[tool] = binds
+		}
+		if len(binds) == 0 {
+			delete(x.Keybinds, tool)
+		}
+	}
+	return x
+}
+
+// shell returns what the shell name defines so far
+func (x *Extracted) shell(name string) *Shell {
+	if x.Shells[name] == nil {
+		x.Shells[name] = &Shell{}
+	}
+	return x.Shells[name]
+}
+
+// walk calls fn with every regular file under root, and its path relative to
+// root with forward slashes. It skips skipDirs and what the ignore files
+// under root exclude, as repositories read for context do.
+func walk(root string, fn func(path, rel string)) {
+	matcher := ignore.New(root)
+	matcher.AddIgnoreFileName(ignore.CodexignoreFile)
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+
+		if d.IsDir() {
+			if skipDirs[d.Name()] || matcher.Match(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || matcher.Match(rel, false) {
+			return nil
+		}
+		fn(path, filepath.ToSlash(rel))
+		return nil
+	})
+}
+
+// readConfig reads a config file, unless it is too big to be hand-written
+func readConfig(path string) (string, bool) {
+	if info, err := os.Stat(path); err != nil || info.Size() > maxConfigSize {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// parseSetting parses a config text setting of a Nix-configured program,
+// such as programs.tmux.extraConfig, counting lines from where it was set
+func parseSetting(program *nix.Program, setting string, parse func(src, file string, line int) []Keybind) []Keybind {
+	text, ok := program.Settings[setting].(string)
+	if !ok {
+		return nil
+	}
+	src := program.Sources[setting]
+	return parse(text, src.File, max(src.Line, 1))
+}
+
+// oneLine collapses whitespace in text and shortens it to max bytes
+func oneLine(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > max {
+		for max > 0 && !utf8.RuneStart(text[max]) {
+			max--
+		}
+		text = text[:max] + "..."
+	}
+	return text
+}
+
+// removeBinds drops the bindings matching fn
+func removeBinds(binds []Keybind, fn func(Keybind) bool) []Keybind {
+	kept := binds[:0]
+	for _, b := range binds {
+		if !fn(b) {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
+// replaceBind adds a binding, replacing an earlier one of the same key in the
+// same mode
+func replaceBind(binds []Keybind, b Keybind) []Keybind {
+	binds = removeBinds(binds, func(old Keybind) bool { return !old.variable && old.Key == b.Key && old.Mode == b.Mode })
+	return append(binds, b)
+}
+
+// keyCombo joins modifiers, separated by any of seps, and a key with "+",
+// e.g. "CTRL|SHIFT" and "t" into CTRL+SHIFT+t
+func keyCombo(mods, seps, key string) string {
+	parts := strings.FieldsFunc(mods, func(r rune) bool { return strings.ContainsRune(seps, r) })
+	if key = strings.TrimSpace(key); key != "" {
+		parts = append(parts, key)
+	}
+	return strings.Join(parts, "+")
+}
@@ -0,0 +1,187 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codex/internal/nix"
+)
+
+func TestExtractFiles(t *testing.T) {
+	got := ExtractAll(filepath.Join("testdata", "stow"), nil).Keybinds
+
+	want := []Keybind{
+		{Key: "C-a", Command: tmuxPrefixCommand, Description: "Prefix key", Mode: "root", File: "tmux/.tmux.conf", Line: 3},
+		{Key: "C-a", Command: "send-prefix", Mode: "prefix", File: "tmux/.tmux.conf", Line: 4},
+		{Key: "|", Command: `split-window -h -c "#{pane_current_path}"`, Mode: "prefix", File: "tmux/.tmux.conf", Line: 7},
+		{Key: "-", Command: `split-window -v -c "#{pane_current_path}"`, Mode: "prefix", File: "tmux/.tmux.conf", Line: 8},
+	}
+	if !reflect.DeepEqual(got["tmux"], want) {
+		t.Errorf("tmux bindings =\n%+v\nwant\n%+v", got["tmux"], want)
+	}
+
+	// The leader is set in init.lua and used in a lazy.nvim spec
+	wantNeovim := []Keybind{
+		{Key: "<Space>", Command: vimLeaderCommand, Description: "Leader key", File: "nvim/.config/nvim/init.lua", Line: 1},
+		{Key: "<Space>ff", Command: "<cmd>Telescope find_files<cr>", Description: "Find files", Mode: "n", File: "nvim/.config/nvim/lua/plugins/telescope.lua", Line: 4},
+	}
+	if !reflect.DeepEqual(got["neovim"], wantNeovim) {
+		t.Errorf("neovim bindings =\n%+v\nwant\n%+v", got["neovim"], wantNeovim)
+	}
+
+	// $mod is set in config and used in config.d
+	wantSway := []Keybind{
+		{Key: "Mod4+Return", Command: "exec foot", File: "sway/.config/sway/config", Line: 5},
+		{Key: "Mod4+Shift+q", Command: "kill", File: "sway/.config/sway/config", Line: 6},
+		{Key: "h", Command: "resize shrink width 10px", Mode: "resize", File: "sway/.config/sway/config", Line: 9},
+		{Key: "Escape", Command: `mode "default"`, Mode: "resize", File: "sway/.config/sway/config", Line: 10},
+		{Key: "Mod4+r", Command: `mode "resize"`, File: "sway/.config/sway/config", Line: 12},
+		{Key: "Mod4+d", Command: "exec wofi --show drun", File: "sway/.config/sway/config.d/launcher", Line: 2},
+	}
+	if !reflect.DeepEqual(got["sway"], wantSway) {
+		t.Errorf("sway bindings =\n%+v\nwant\n%+v", got["sway"], wantSway)
+	}
+
+	if n := len(got["hyprland"]); n != 6 {
+		t.Errorf("Expected 6 hyprland bindings, got %d: %+v", n, got["hyprland"])
+	}
+
+	// Shell bindings, including those set inside fish_user_key_bindings
+	wantFish := []Keybind{
+		{Key: `\cf`, Command: "forward-char", File: "fish/.config/fish/config.fish", Line: 9},
+		{Key: `\ce`, Command: "end-of-line", Mode: "insert", File: "fish/.config/fish/config.fish", Line: 10},
+	}
+	if !reflect.DeepEqual(got["fish"], wantFish) {
+		t.Errorf("fish bindings =\n%+v\nwant\n%+v", got["fish"], wantFish)
+	}
+	if n := len(got["zsh"]); n != 2 {
+		t.Errorf("Expected 2 zsh bindings, got %d: %+v", n, got["zsh"])
+	}
+
+	// kitty_mod is set in a file kitty.conf includes
+	wantKitty := []Keybind{{Key: "ctrl+alt+t", Command: "new_tab_with_cwd", File: "kitty/.config/kitty/kitty.conf", Line: 2}}
+	if !reflect.DeepEqual(got["kitty"], wantKitty) {
+		t.Errorf("kitty bindings =\n%+v\nwant\n%+v", got["kitty"], wantKitty)
+	}
+	if n := len(got["alacritty"]); n != 1 {
+		t.Errorf("Expected 1 alacritty binding, got %d: %+v", n, got["alacritty"])
+	}
+
+	if len(got) != 8 {
+		t.Errorf("Expected only tmux, neovim, sway, hyprland, zsh, fish, kitty and alacritty bindings, got %v", got)
+	}
+}
+
+func TestExtractSettings(t *testing.T) {
+	got := ExtractAll(filepath.Join("testdata", "stow"), nil).Settings
+
+	// theme.conf is read after kitty.conf and sets the font again
+	want := map[string][]Setting{
+		"alacritty": {{Key: "font.size", Value: 11.0, File: "alacritty/.config/alacritty/alacritty.toml", Line: 2}},
+		"kitty": {
+			{Key: "include", Value: "theme.conf", File: "kitty/.config/kitty/kitty.conf", Line: 3},
+			{Key: "font_family", Value: "JetBrains Mono", File: "kitty/.config/kitty/theme.conf", Line: 2},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractAll().Settings =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestExtractAllSkipsIgnoredFiles(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		".gitignore":                     "private/\n",
+		".codexignore":                   "work.zsh\n",
+		"tmux/.tmux.conf":                "bind | split-window -h\n",
+		"private/tmux/.tmux.conf":        "bind S run-shell secret\n",
+		"zsh/.zshrc":                     "alias gs='git status'\n",
+		"zsh/work.zsh":                   "export WORK_TOKEN=abc\n",
+		"kitty/.config/kitty/kitty.conf": "font_size 12\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := ExtractAll(root, nil)
+	if binds := got.Keybinds["tmux"]; len(binds) != 1 || binds[0].File != "tmux/.tmux.conf" {
+		t.Errorf("Expected only the tracked tmux binding, got %+v", binds)
+	}
+	zsh := got.Shells["zsh"]
+	if zsh == nil || len(zsh.Aliases) != 1 || len(zsh.Exports) != 0 {
+		t.Errorf("Expected the alias but not the .codexignore'd export, got %+v", zsh)
+	}
+	if settings := got.Settings["kitty"]; len(settings) != 1 || settings[0].Key != "font_size" {
+		t.Errorf("Expected kitty settings from the same walk, got %+v", settings)
+	}
+}
+
+func TestExtractHomeManager(t *testing.T) {
+	root := filepath.Join("testdata", "home")
+	cfg, err := nix.ParseConfig(root)
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	got := ExtractAll(root, cfg).Keybinds
+	want := []Keybind{
+		{Key: "C-a", Command: tmuxPrefixCommand, Description: "Prefix key", Mode: "root", File: "home.nix", Line: 6},
+		{Key: "a", Command: "send-prefix", Mode: "prefix", File: "home.nix", Line: 6},
+		{Key: "H", Command: "resize-pane -L 5", Description: "Repeatable", Mode: "prefix", File: "home.nix", Line: 9},
+	}
+	if !reflect.DeepEqual(got["tmux"], want) {
+		t.Errorf("tmux bindings =\n%+v\nwant\n%+v", got["tmux"], want)
+	}
+
+	wantNeovim := []Keybind{
+		{Key: ",", Command: vimLeaderCommand, Description: "Leader key", File: "home.nix", Line: 16},
+		{Key: ",w", Command: "<cmd>w<cr>", Description: "Save", Mode: "n", File: "home.nix", Line: 17},
+	}
+	if !reflect.DeepEqual(got["neovim"], wantNeovim) {
+		t.Errorf("neovim bindings =\n%+v\nwant\n%+v", got["neovim"], wantNeovim)
+	}
+
+	// ${modifier} and ${config...terminal} are read from the module's options
+	wantSway := []Keybind{
+		{Key: "Mod4+Return", Command: "exec foot", File: "home.nix", Line: 27},
+		{Key: "Escape", Command: "mode default", Mode: "resize", File: "home.nix", Line: 31},
+		{Key: "XF86AudioMute", Command: "exec wpctl set-mute @DEFAULT_AUDIO_SINK@ toggle", File: "home.nix", Line: 35},
+	}
+	if !reflect.DeepEqual(got["sway"], wantSway) {
+		t.Errorf("sway bindings =\n%+v\nwant\n%+v", got["sway"], wantSway)
+	}
+
+	wantHyprland := []Keybind{
+		{Key: "SUPER+F", Command: "exec firefox", File: "home.nix", Line: 43},
+		{Key: "SUPER+SHIFT+Q", Command: "killactive", File: "home.nix", Line: 43},
+	}
+	if !reflect.DeepEqual(got["hyprland"], wantHyprland) {
+		t.Errorf("hyprland bindings =\n%+v\nwant\n%+v", got["hyprland"], wantHyprland)
+	}
+
+	wantKitty := []Keybind{{Key: "ctrl+alt+enter", Command: "new_window_with_cwd", File: "home.nix", Line: 84}}
+	if !reflect.DeepEqual(got["kitty"], wantKitty) {
+		t.Errorf("kitty bindings =\n%+v\nwant\n%+v", got["kitty"], wantKitty)
+	}
+
+	wantAlacritty := []Keybind{{Key: "Control+Shift+N", Command: "SpawnNewInstance", File: "home.nix", Line: 90}}
+	if !reflect.DeepEqual(got["alacritty"], wantAlacritty) {
+		t.Errorf("alacritty bindings =\n%+v\nwant\n%+v", got["alacritty"], wantAlacritty)
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Register to panic for a duplicate tool")
+		}
+	}()
+	Register(Extractor{Tool: "tmux", Parse: parseTmux})
+}
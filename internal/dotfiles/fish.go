@@ -0,0 +1,226 @@
+package dotfiles
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+func init() {
+	registerShell("fish", shellExtractor{
+		match:    isFishConfig,
+		parse:    parseFish,
+		settings: []string{"shellInit", "loginShellInit", "interactiveShellInit", "shellInitLast"},
+	})
+}
+
+// fishPluginsFile lists the plugins fisher installs, one per line
+const fishPluginsFile = "fish_plugins"
+
+// fishBlocks are the commands that open a block closed by end
+var fishBlocks = map[string]bool{"function": true, "if": true, "for": true, "while": true, "switch": true, "begin": true}
+
+// fishKeywords may start a command without being the command
+var fishKeywords = map[string]bool{"and": true, "or": true, "not": true, "else": true, "command": true, "builtin": true}
+
+// isFishConfig matches config.fish, and .fish files and fish_plugins in a fish directory
+func isFishConfig(rel string) bool {
+	base := path.Base(rel)
+	return base == "config.fish" || inDir(rel, "fish") && (path.Ext(rel) == ".fish" || base == fishPluginsFile)
+}
+
+// fishFunction is a function definition being read
+type fishFunction struct {
+	def   Definition
+	body  []string // Its commands
+	depth int      // Blocks open, including its own
+}
+
+// parseFish reads the aliases, abbreviations, functions, exports, plugins and
+// key bindings of a fish script. As in bash and zsh, only key bindings are
+// read inside functions, where fish_user_key_bindings sets them.
+func parseFish(src, file string, first int) *Shell {
+	shell := &Shell{}
+	if path.Base(file) == fishPluginsFile {
+		for i, line := range strings.Split(src, "\n") {
+			if name := strings.TrimSpace(line); name != "" && !strings.HasPrefix(name, "#") {
+				shell.addPlugin(Plugin{Manager: "fisher", Name: name, File: file, Line: first + i})
+			}
+		}
+		return shell
+	}
+
+	var blocks []string
+	var fn *fishFunction
+	for _, l := range shLines(src, first) {
+		for _, args := range shCommands(l.text, true) {
+			elseIf := false
+			for len(args) > 0 && fishKeywords[args[0]] {
+				elseIf = args[0] == "else"
+				args = args[1:]
+			}
+			if len(args) == 0 {
+				continue
+			}
+
+			switch {
+			case args[0] == "function" && fn == nil:
+				blocks = append(blocks, args[0])
+				if len(args) > 1 {
+					fn = &fishFunction{def: Definition{Name: args[1], File: file, Line: l.line}, depth: len(blocks)}
+				}
+				continue
+			case fishBlocks[args[0]] && !(elseIf && args[0] == "if"):
+				blocks = append(blocks, args[0])
+			case args[0] == "end":
+				if len(blocks) > 0 {
+					blocks = blocks[:len(blocks)-1]
+				}
+				if fn != nil && len(blocks) < fn.depth {
+					fn.def.Value = oneLine(strings.Join(fn.body, "; "), maxDefinitionValue)
+					shell.Functions = setDefinition(shell.Functions, fn.def)
+					fn = nil
+					continue
+				}
+			}
+
+			if fn != nil {
+				fn.body = append(fn.body, strings.Join(args, " "))
+				if args[0] == "bind" {
+					fishBind(shell, args[1:], file, l.line)
+				}
+				continue
+			}
+			fishCommand(shell, args, file, l.line)
+		}
+	}
+	return shell
+}
+
+// fishCommand reads one top-level command
+func fishCommand(shell *Shell, args []string, file string, line int) {
+	define := func(defs []Definition, name, value string) []Definition {
+		return setDefinition(defs, Definition{Name: name, Value: oneLine(value, maxDefinitionValue), File: file, Line: line})
+	}
+
+	switch args[0] {
+	case "alias":
+		args = slices.DeleteFunc(args[1:], func(arg string) bool { return strings.HasPrefix(arg, "-") })
+		if len(args) == 0 {
+			return
+		}
+		if name, value, ok := strings.Cut(args[0], "="); ok {
+			shell.Aliases = define(shell.Aliases, name, value)
+		} else if len(args) > 1 {
+			shell.Aliases = define(shell.Aliases, args[0], strings.Join(args[1:], " "))
+		}
+
+	case "abbr":
+		var words []string
+		function := ""
+		for i := 1; i < len(args); i++ {
+			switch arg := args[i]; arg {
+			case "-e", "--erase", "-l", "--list", "-s", "--show", "-q", "--query", "--rename":
+				return
+			case "-f", "--function":
+				if i+1 < len(args) {
+					i++
+					function = args[i]
+				}
+			case "-p", "--position", "-r", "--regex", "-c", "--command":
+				i++ // Takes a value
+			default:
+				if !strings.HasPrefix(arg, "-") {
+					words = append(words, arg)
+				}
+			}
+		}
+		if len(words) == 0 {
+			return
+		}
+		value := strings.Join(words[1:], " ")
+		if value == "" {
+			value = function
+		}
+		if value != "" {
+			shell.Aliases = define(shell.Aliases, words[0], value)
+		}
+
+	case "set":
+		exported := false
+		args = args[1:]
+		for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+			flag := args[0]
+			args = args[1:]
+			switch {
+			case flag == "--export":
+				exported = true
+			case flag == "--erase", flag == "--unexport", flag == "--query":
+				return
+			case !strings.HasPrefix(flag, "--"):
+				if strings.ContainsAny(flag, "equ") {
+					return // Erases, queries or unexports
+				}
+				exported = exported || strings.Contains(flag, "x")
+			}
+		}
+		if exported && len(args) > 0 {
+			shell.Exports = define(shell.Exports, args[0], strings.Join(args[1:], " "))
+		}
+
+	case "bind":
+		fishBind(shell, args[1:], file, line)
+
+	case "fisher", "omf":
+		manager := args[0]
+		if len(args) < 3 || (args[1] != "install" && args[1] != "add") {
+			return
+		}
+		for _, name := range args[2:] {
+			if !strings.HasPrefix(name, "-") {
+				shell.addPlugin(Plugin{Manager: manager, Name: name, File: file, Line: line})
+			}
+		}
+	}
+}
+
+// fishBind reads a bind command: bind [-M mode] [-m new-mode] [-e] key command...
+func fishBind(shell *Shell, args []string, file string, line int) {
+	mode, newMode, erase := "", "", false
+flags:
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		flag := args[0]
+		args = args[1:]
+		switch flag {
+		case "-M", "--mode":
+			if len(args) > 0 {
+				mode, args = args[0], args[1:]
+			}
+		case "-m", "--sets-mode":
+			if len(args) > 0 {
+				newMode, args = args[0], args[1:]
+			}
+		case "-e", "--erase":
+			erase = true
+		case "-k", "--key", "--preset", "--user", "-s", "--silent":
+		case "--":
+			break flags
+		default:
+			return // Lists bindings, modes or key names
+		}
+	}
+
+	if erase {
+		shell.binds = removeBinds(shell.binds, func(b Keybind) bool { return slices.Contains(args, b.Key) && b.Mode == mode })
+		return
+	}
+	if len(args) < 2 {
+		return // Shows a binding
+	}
+
+	b := Keybind{Key: args[0], Command: strings.Join(args[1:], " "), Mode: mode, File: file, Line: line}
+	if newMode != "" {
+		b.Description = "Switches to " + newMode + " mode"
+	}
+	shell.binds = replaceBind(shell.binds, b)
+}
@@ -0,0 +1,67 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFish(t *testing.T) {
+	src := `set -gx EDITOR nvim
+set -x PATH $HOME/bin $PATH
+set -g fish_greeting ''
+set -e OLD
+
+alias ll 'ls -lah'
+alias gs='git status'
+abbr -a --position anywhere -- L '| less'
+abbr --add gco git checkout
+abbr -a !! --function last_history_item
+
+if status is-interactive
+    abbr -a gp git push
+else if test -n "$SSH"
+    abbr -a gp echo nope
+end
+
+function mkcd --description 'Make and enter'
+    if test -n "$argv"
+        mkdir -p $argv; and cd $argv
+    end
+end
+
+function fish_user_key_bindings
+    bind -M insert -m default jk 'commandline -f repaint'
+    bind \cg 'git status'
+    bind -e \cg
+end
+
+fisher install jorgebucaran/nvm.fish
+`
+
+	got := parseFish(src, "config.fish", 1)
+	want := &Shell{
+		Aliases: []Definition{
+			{Name: "ll", Value: "ls -lah", File: "config.fish", Line: 6},
+			{Name: "gs", Value: "git status", File: "config.fish", Line: 7},
+			{Name: "L", Value: "| less", File: "config.fish", Line: 8},
+			{Name: "gco", Value: "git checkout", File: "config.fish", Line: 9},
+			{Name: "!!", Value: "last_history_item", File: "config.fish", Line: 10},
+			{Name: "gp", Value: "echo nope", File: "config.fish", Line: 15},
+		},
+		Functions: []Definition{
+			{Name: "mkcd", Value: `if test -n $argv; mkdir -p $argv; cd $argv; end`, File: "config.fish", Line: 18},
+			{Name: "fish_user_key_bindings", Value: "bind -M insert -m default jk commandline -f repaint; bind \\cg git status; bind -e \\cg", File: "config.fish", Line: 24},
+		},
+		Exports: []Definition{
+			{Name: "EDITOR", Value: "nvim", File: "config.fish", Line: 1},
+			{Name: "PATH", Value: "$HOME/bin $PATH", File: "config.fish", Line: 2},
+		},
+		Plugins: []Plugin{{Manager: "fisher", Name: "jorgebucaran/nvm.fish", File: "config.fish", Line: 30}},
+		binds: []Keybind{
+			{Key: "jk", Command: "commandline -f repaint", Description: "Switches to default mode", Mode: "insert", File: "config.fish", Line: 25},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFish() =\n%+v\nwant\n%+v", got, want)
+	}
+}
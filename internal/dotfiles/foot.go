@@ -0,0 +1,130 @@
+package dotfiles
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+func init() {
+	Register(Extractor{
+		Tool:     "foot",
+		Match:    isFootConfig,
+		Parse:    terminalParser(parseFoot).keybinds,
+		Settings: terminalParser(parseFoot).settings,
+		Nix:      footNix,
+	})
+}
+
+// footBindingSections maps the sections holding key bindings to the mode
+// they apply in
+var footBindingSections = map[string]string{
+	"key-bindings":    "",
+	"search-bindings": "search",
+	"url-bindings":    "url",
+	"text-bindings":   "",
+}
+
+// footSettings are the font and theme settings kept, by section. Keys before
+// any section are in main.
+var footSettings = map[string]bool{
+	"main.font": true, "main.font-bold": true, "main.font-italic": true, "main.include": true,
+	"colors.alpha": true,
+}
+
+// isFootConfig matches foot.ini and .ini files in a foot directory
+func isFootConfig(rel string) bool {
+	return path.Base(rel) == "foot.ini" || path.Ext(rel) == ".ini" && inDir(rel, "foot")
+}
+
+// parseFoot reads the binding sections of foot.ini, where each line binds an
+// action to one or more key combinations, and its font settings
+func parseFoot(src, file string, first int) *terminalConfig {
+	t := &terminalConfig{}
+	section := "main"
+	for i, raw := range strings.Split(src, "\n") {
+		line := first + i
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if mode, ok := footBindingSections[section]; ok {
+			t.binds = footBinding(t.binds, section, key, value, mode, file, line)
+		} else if footSettings[section+"."+key] {
+			name := section + "." + key
+			if section == "main" {
+				name = key
+			}
+			t.set(name, value, file, line)
+		}
+	}
+	return t
+}
+
+// footBinding reads action=combos, where the combos may follow a command in
+// brackets, as in pipe-scrollback=[sh -c "xurls | fuzzel"] Control+Shift+u. A
+// combo of none unbinds the action. Text bindings send the text instead.
+func footBinding(binds []Keybind, section, action, value, mode, file string, line int) []Keybind {
+	command, description := action, ""
+	if section == "text-bindings" {
+		description = "Sends text"
+	}
+	if strings.HasPrefix(value, "[") {
+		if end := strings.Index(value, "]"); end > 0 {
+			command += " " + strings.TrimSpace(value[1:end])
+			value = value[end+1:]
+		}
+	}
+
+	for _, combo := range strings.Fields(value) {
+		if combo != "none" {
+			binds = replaceBind(binds, Keybind{Key: combo, Command: command, Description: description, Mode: mode, File: file, Line: line})
+		}
+	}
+	return binds
+}
+
+// footNix reads home-manager's programs.foot settings for the binding sections
+func footNix(cfg *nix.Config) []Keybind {
+	program := cfg.Programs()["foot"]
+	if program == nil {
+		return nil
+	}
+
+	settings := make([]string, 0, len(program.Settings))
+	for setting := range program.Settings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		a, b := program.Sources[settings[i]], program.Sources[settings[j]]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return settings[i] < settings[j]
+	})
+
+	var binds []Keybind
+	for _, setting := range settings {
+		section, action, ok := strings.Cut(strings.TrimPrefix(setting, "settings."), ".")
+		mode, isBindings := footBindingSections[section]
+		value, isString := program.Settings[setting].(string)
+		if !ok || !isBindings || !isString || !strings.HasPrefix(setting, "settings.") {
+			continue
+		}
+		src := program.Sources[setting]
+		binds = footBinding(binds, section, action, value, mode, src.File, src.Line)
+	}
+	return binds
+}
@@ -0,0 +1,48 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFoot(t *testing.T) {
+	src := `font=Iosevka Term:size=11
+include=~/.config/foot/theme.ini
+
+[colors]
+alpha=0.9
+foreground=cdd6f4
+
+[key-bindings]
+spawn-terminal=Control+Shift+n Mod4+Return
+scrollback-up-page=none
+pipe-scrollback=[sh -c "xurls | fuzzel -d | xargs -r xdg-open"] Control+Shift+u
+
+[search-bindings]
+find-prev=Control+r
+
+[text-bindings]
+\x1b=Mod1+Escape
+`
+
+	got := parseFoot(src, "foot.ini", 1)
+	want := []Keybind{
+		{Key: "Control+Shift+n", Command: "spawn-terminal", File: "foot.ini", Line: 9},
+		{Key: "Mod4+Return", Command: "spawn-terminal", File: "foot.ini", Line: 9},
+		{Key: "Control+Shift+u", Command: `pipe-scrollback sh -c "xurls | fuzzel -d | xargs -r xdg-open"`, File: "foot.ini", Line: 11},
+		{Key: "Control+r", Command: "find-prev", Mode: "search", File: "foot.ini", Line: 14},
+		{Key: "Mod1+Escape", Command: `\x1b`, Description: "Sends text", File: "foot.ini", Line: 17},
+	}
+	if !reflect.DeepEqual(got.binds, want) {
+		t.Errorf("parseFoot() binds =\n%+v\nwant\n%+v", got.binds, want)
+	}
+
+	wantSettings := []Setting{
+		{Key: "font", Value: "Iosevka Term:size=11", File: "foot.ini", Line: 1},
+		{Key: "include", Value: "~/.config/foot/theme.ini", File: "foot.ini", Line: 2},
+		{Key: "colors.alpha", Value: "0.9", File: "foot.ini", Line: 5},
+	}
+	if !reflect.DeepEqual(got.settings, wantSettings) {
+		t.Errorf("parseFoot() settings =\n%+v\nwant\n%+v", got.settings, wantSettings)
+	}
+}
@@ -0,0 +1,191 @@
+package dotfiles
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+func init() {
+	Register(Extractor{Tool: "hyprland", Match: isHyprlandConfig, Parse: parseHyprland, Nix: hyprlandNix, Resolve: resolveVariables})
+}
+
+// hyprlandModule is where home-manager configures Hyprland
+const hyprlandModule = "wayland.windowManager.hyprland."
+
+// hyprBindRe matches bind keywords and captures their flags, e.g. "el" in bindel
+var hyprBindRe = regexp.MustCompile(`^bind([a-z]*)$`)
+
+// isHyprlandConfig matches .conf files in a hypr directory
+func isHyprlandConfig(rel string) bool {
+	return path.Ext(rel) == ".conf" && inDir(rel, "hypr")
+}
+
+// hyprParser reads Hyprland config lines, keeping the variables and submap
+// set so far
+type hyprParser struct {
+	file   string
+	vars   map[string]string
+	submap string
+	binds  []Keybind
+}
+
+// parseHyprland reads the binds, submaps and variables of a Hyprland config
+func parseHyprland(src, file string, line int) []Keybind {
+	h := &hyprParser{file: file, vars: make(map[string]string)}
+	h.parse(src, line)
+	return h.binds
+}
+
+// parse reads config text whose first line is first
+func (h *hyprParser) parse(src string, first int) {
+	for i, text := range strings.Split(src, "\n") {
+		text = strings.TrimSpace(stripHyprComment(text))
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			continue
+		}
+		h.set(strings.TrimSpace(key), strings.TrimSpace(value), first+i)
+	}
+}
+
+// stripHyprComment removes a # comment; ## is a literal #
+func stripHyprComment(line string) string {
+	if !strings.Contains(line, "#") {
+		return line
+	}
+	var sb strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' {
+			if i+1 < len(line) && line[i+1] == '#' {
+				sb.WriteByte('#')
+				i++
+				continue
+			}
+			break
+		}
+		sb.WriteByte(line[i])
+	}
+	return sb.String()
+}
+
+// set applies one key = value line
+func (h *hyprParser) set(key, value string, line int) {
+	if name, ok := strings.CutPrefix(key, "$"); ok {
+		value = expandVariables(value, h.vars)
+		h.vars[name] = value
+		h.binds = append(h.binds, wmVariable(name, value, h.file, line))
+		return
+	}
+	value = expandVariables(value, h.vars)
+
+	switch {
+	case key == "submap":
+		h.submap = value
+		if value == "reset" {
+			h.submap = ""
+		}
+	case key == "unbind":
+		mods, k, _ := strings.Cut(value, ",")
+		combo := hyprKey(mods, k)
+		h.binds = removeBinds(h.binds, func(b Keybind) bool { return !b.variable && b.Key == combo && b.Mode == h.submap })
+	default:
+		m := hyprBindRe.FindStringSubmatch(key)
+		if m == nil {
+			return
+		}
+		flags := m[1]
+
+		// bind = MODS, key, dispatcher, params; bindd adds a description before the dispatcher
+		n := 4
+		if strings.Contains(flags, "d") {
+			n = 5
+		}
+		fields := strings.SplitN(value, ",", n)
+		if len(fields) < n-1 {
+			return
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		description := ""
+		if n == 5 {
+			description, fields = fields[2], append(fields[:2:2], fields[3:]...)
+		}
+		command := fields[2]
+		if len(fields) > 3 && fields[3] != "" {
+			command += " " + fields[3]
+		}
+		if command == "" {
+			return
+		}
+		if description == "" && strings.Contains(flags, "e") {
+			description = "Repeatable"
+		}
+
+		h.binds = replaceBind(h.binds, Keybind{
+			Key:         hyprKey(fields[0], fields[1]),
+			Command:     command,
+			Description: description,
+			Mode:        h.submap,
+			File:        h.file,
+			Line:        line,
+		})
+	}
+}
+
+// hyprKey joins modifiers and a key, e.g. "SUPER SHIFT" and "Q" into SUPER+SHIFT+Q
+func hyprKey(mods, key string) string {
+	return keyCombo(mods, " _+", key)
+}
+
+// hyprlandNix reads home-manager's wayland.windowManager.hyprland: variables
+// and bind lists in settings, then extraConfig
+func hyprlandNix(cfg *nix.Config) []Keybind {
+	h := &hyprParser{vars: make(map[string]string)}
+
+	var names []string
+	for option := range cfg.Options {
+		if name, ok := strings.CutPrefix(option, hyprlandModule+"settings."); ok {
+			names = append(names, name)
+		}
+	}
+	// Variables first, as home-manager writes them
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := strings.HasPrefix(names[i], "$"), strings.HasPrefix(names[j], "$")
+		if vi != vj {
+			return vi
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		option := hyprlandModule + "settings." + name
+		src := cfg.Sources[option]
+		h.file = src.File
+
+		switch value := cfg.Options[option].(type) {
+		case string:
+			if strings.HasPrefix(name, "$") {
+				h.set(name, value, src.Line)
+			}
+		case []any:
+			for _, item := range value {
+				if s, ok := item.(string); ok {
+					h.set(name, s, src.Line)
+				}
+			}
+		}
+	}
+
+	if extra, ok := cfg.Options[hyprlandModule+"extraConfig"].(string); ok {
+		src := cfg.Sources[hyprlandModule+"extraConfig"]
+		h.file = src.File
+		h.parse(extra, max(src.Line, 1))
+	}
+	return h.binds
+}
@@ -0,0 +1,42 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHyprland(t *testing.T) {
+	src := `$mainMod = SUPER # the logo key
+$browser = firefox
+
+bind = $mainMod, B, exec, $browser
+bind = $mainMod_SHIFT, 1, movetoworkspace, 1
+bindd = $mainMod, M, Open the mixer, exec, pavucontrol
+bindel = , XF86AudioLowerVolume, exec, wpctl set-volume @DEFAULT_AUDIO_SINK@ 5%-
+bindm = $mainMod, mouse:272, movewindow
+bind = $mainMod, C, exec, notify-send "##1"
+bind = $mainMod, X, killactive,
+unbind = $mainMod, X
+
+submap = launch
+bind = , f, exec, $browser
+bind = , escape, submap, reset
+submap = reset
+`
+
+	want := []Keybind{
+		{Key: "SUPER+B", Command: "exec firefox", File: "hyprland.conf", Line: 4},
+		{Key: "SUPER+SHIFT+1", Command: "movetoworkspace 1", File: "hyprland.conf", Line: 5},
+		{Key: "SUPER+M", Command: "exec pavucontrol", Description: "Open the mixer", File: "hyprland.conf", Line: 6},
+		{Key: "XF86AudioLowerVolume", Command: "exec wpctl set-volume @DEFAULT_AUDIO_SINK@ 5%-", Description: "Repeatable", File: "hyprland.conf", Line: 7},
+		{Key: "SUPER+mouse:272", Command: "movewindow", File: "hyprland.conf", Line: 8},
+		{Key: "SUPER+C", Command: `exec notify-send "#1"`, File: "hyprland.conf", Line: 9},
+		{Key: "f", Command: "exec firefox", Mode: "launch", File: "hyprland.conf", Line: 14},
+		{Key: "escape", Command: "submap reset", Mode: "launch", File: "hyprland.conf", Line: 15},
+	}
+
+	got := resolveVariables(parseHyprland(src, "hyprland.conf", 1))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHyprland() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,257 @@
+package dotfiles
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+func init() {
+	Register(Extractor{Tool: "i3", Match: isI3Config, Parse: parseI3, Nix: i3Nix, Resolve: resolveVariables})
+	Register(Extractor{Tool: "sway", Match: isSwayConfig, Parse: parseI3, Nix: swayNix, Resolve: resolveVariables})
+}
+
+// i3DefaultModifier is home-manager's default for config.modifier
+const i3DefaultModifier = "Mod1"
+
+// isI3Config matches i3/config, .i3/config and files in i3/config.d
+func isI3Config(rel string) bool {
+	return isI3StyleConfig(rel, "i3", ".i3")
+}
+
+// isSwayConfig matches sway/config and files in sway/config.d
+func isSwayConfig(rel string) bool {
+	return isI3StyleConfig(rel, "sway")
+}
+
+// isI3StyleConfig matches a config file directly in one of dirs, or any file
+// in its config.d
+func isI3StyleConfig(rel string, dirs ...string) bool {
+	dir := path.Dir(rel)
+	if path.Base(dir) == "config.d" {
+		dir = path.Dir(dir)
+	} else if path.Base(rel) != "config" {
+		return false
+	}
+	for _, want := range dirs {
+		if path.Base(dir) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// i3Block is a { } block open in an i3 config
+type i3Block struct {
+	mode string // Set for mode "name" { ... }
+	bind string // Set for bindsym { ... }, whose lines are key and command
+}
+
+// parseI3 reads bindsym and bindcode bindings of an i3 or sway config,
+// including those inside modes, and its variables. Variables are expanded as
+// they are set; ones set in other files are left for resolveVariables.
+func parseI3(src, file string, first int) []Keybind {
+	var binds []Keybind
+	vars := make(map[string]string)
+	var blocks []i3Block
+
+	mode := func() string {
+		for i := len(blocks) - 1; i >= 0; i-- {
+			if blocks[i].mode != "" {
+				return blocks[i].mode
+			}
+		}
+		return ""
+	}
+
+	lines := strings.Split(src, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := first + i
+		text := strings.TrimSpace(lines[i])
+		for strings.HasSuffix(text, `\`) && i+1 < len(lines) {
+			i++
+			text = strings.TrimSpace(strings.TrimSuffix(text, `\`)) + " " + strings.TrimSpace(lines[i])
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if text == "}" {
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		}
+
+		command, rest := cutField(text)
+		switch command {
+		case "set", "set_from_resource":
+			name, value := cutField(rest)
+			if command == "set_from_resource" {
+				_, value = cutField(value) // The resource; what follows is the fallback
+			}
+			if name, ok := strings.CutPrefix(name, "$"); ok {
+				value = expandVariables(value, vars)
+				vars[name] = value
+				binds = append(binds, wmVariable(name, value, file, line))
+			}
+			continue
+		}
+
+		text = expandVariables(text, vars)
+		if n := len(blocks); n > 0 && blocks[n-1].bind != "" {
+			key, command := cutField(text)
+			binds = i3Bind(binds, blocks[n-1].bind, key, command, mode(), file, line)
+			continue
+		}
+
+		command, rest = cutField(text)
+		switch command {
+		case "mode":
+			if name, ok := strings.CutSuffix(rest, "{"); ok {
+				name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "--pango_markup"))
+				blocks = append(blocks, i3Block{mode: strings.Trim(name, `"'`)})
+			}
+		case "bindsym", "bindcode":
+			rest = skipFlags(rest)
+			if rest == "{" {
+				blocks = append(blocks, i3Block{bind: command})
+				continue
+			}
+			key, cmd := cutField(rest)
+			binds = i3Bind(binds, command, key, cmd, mode(), file, line)
+		case "unbindsym", "unbindcode":
+			key, _ := cutField(skipFlags(rest))
+			current := mode()
+			binds = removeBinds(binds, func(b Keybind) bool { return !b.variable && b.Key == key && b.Mode == current })
+		default:
+			if strings.HasSuffix(text, "{") {
+				blocks = append(blocks, i3Block{})
+			}
+		}
+	}
+
+	return binds
+}
+
+// i3Bind adds a bindsym or bindcode binding
+func i3Bind(binds []Keybind, kind, key, command, mode, file string, line int) []Keybind {
+	if key == "" || command == "" {
+		return binds
+	}
+	b := Keybind{Key: key, Command: command, Mode: mode, File: file, Line: line}
+	if kind == "bindcode" {
+		b.Description = "Key code"
+	}
+	return replaceBind(binds, b)
+}
+
+// cutField splits the first whitespace-separated field from s
+func cutField(s string) (field, rest string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// skipFlags drops leading --flags such as --release
+func skipFlags(s string) string {
+	for strings.HasPrefix(s, "--") {
+		_, s = cutField(s)
+	}
+	return s
+}
+
+// nixInterpolationRe matches ${...} left in Nix strings
+var nixInterpolationRe = regexp.MustCompile(`\$\{[^}]*\}`)
+
+// i3Nix reads home-manager's xsession.windowManager.i3
+func i3Nix(cfg *nix.Config) []Keybind {
+	return i3StyleNix(cfg, "xsession.windowManager.i3")
+}
+
+// swayNix reads home-manager's wayland.windowManager.sway
+func swayNix(cfg *nix.Config) []Keybind {
+	return i3StyleNix(cfg, "wayland.windowManager.sway")
+}
+
+// i3StyleNix reads config.keybindings, config.modes and extraConfig of the
+// home-manager i3 or sway module at prefix. References to config options
+// such as ${modifier} or ${cfg.config.terminal} are expanded.
+func i3StyleNix(cfg *nix.Config, prefix string) []Keybind {
+	settings := prefix + ".config."
+	setting := func(name string) (string, bool) {
+		value, ok := cfg.Options[settings+name].(string)
+		return value, ok
+	}
+
+	modifier, ok := setting("modifier")
+	if !ok {
+		modifier = i3DefaultModifier
+	}
+	expand := func(s string) string {
+		return nixInterpolationRe.ReplaceAllStringFunc(s, func(ref string) string {
+			expr := ref[2 : len(ref)-1]
+			name := expr[strings.LastIndex(expr, ".")+1:]
+			if name == "modifier" {
+				return modifier
+			}
+			if value, ok := setting(name); ok {
+				return value
+			}
+			return ref
+		})
+	}
+
+	var binds []Keybind
+	for option, value := range cfg.Options {
+		rest, ok := strings.CutPrefix(option, settings)
+		if !ok {
+			continue
+		}
+		command, ok := value.(string)
+		if !ok {
+			continue // null removes one of the module's default bindings
+		}
+
+		mode, key := "", ""
+		if k, ok := strings.CutPrefix(rest, "keybindings."); ok {
+			key = k
+		} else if k, ok := strings.CutPrefix(rest, "modes."); ok {
+			mode, key, _ = strings.Cut(k, ".")
+		}
+		if key == "" {
+			continue
+		}
+
+		src := cfg.Sources[option]
+		binds = append(binds, Keybind{Key: expand(key), Command: expand(command), Mode: mode, File: src.File, Line: src.Line})
+	}
+	sortBinds(binds)
+
+	if extra, ok := cfg.Options[prefix+".extraConfig"].(string); ok {
+		src := cfg.Sources[prefix+".extraConfig"]
+		for _, b := range parseI3(extra, src.File, max(src.Line, 1)) {
+			binds = replaceBind(binds, b)
+		}
+	}
+	return binds
+}
+
+// sortBinds orders bindings read from a map by where they were set
+func sortBinds(binds []Keybind) {
+	sort.Slice(binds, func(i, j int) bool {
+		a, b := binds[i], binds[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Key < b.Key
+	})
+}
@@ -0,0 +1,90 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseI3(t *testing.T) {
+	src := `set $mod Mod4
+set $mode_system System (l) lock, (e) logout
+set_from_resource $term i3wm.terminal alacritty
+
+bindsym $mod+Return exec $term
+bindsym --release $mod+Print exec \
+    grim -g "$(slurp)"
+bindcode 133+36 exec rofi -show run
+bindsym $mod+q kill
+unbindsym $mod+q
+
+mode "$mode_system" {
+    bindsym l exec swaylock, mode "default"
+    bindsym Escape mode "default"
+}
+
+bar {
+    status_command i3status
+}
+
+bindsym {
+    $mod+1 workspace number 1
+    $mod+2 workspace number 2
+}
+`
+
+	want := []Keybind{
+		{Key: "Mod4+Return", Command: "exec alacritty", File: "config", Line: 5},
+		{Key: "Mod4+Print", Command: `exec grim -g "$(slurp)"`, File: "config", Line: 6},
+		{Key: "133+36", Command: "exec rofi -show run", Description: "Key code", File: "config", Line: 8},
+		{Key: "l", Command: `exec swaylock, mode "default"`, Mode: "System (l) lock, (e) logout", File: "config", Line: 13},
+		{Key: "Escape", Command: `mode "default"`, Mode: "System (l) lock, (e) logout", File: "config", Line: 14},
+		{Key: "Mod4+1", Command: "workspace number 1", File: "config", Line: 22},
+		{Key: "Mod4+2", Command: "workspace number 2", File: "config", Line: 23},
+	}
+
+	got := resolveVariables(parseI3(src, "config", 1))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseI3() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestI3ConfigMatch(t *testing.T) {
+	i3 := []string{".config/i3/config", ".i3/config", "i3/.config/i3/config.d/keys.conf"}
+	sway := []string{".config/sway/config", "sway/config.d/50-keys"}
+	neither := []string{".config/i3status/config", "i3/README.md", "sway/themes/config"}
+
+	for _, rel := range i3 {
+		if !isI3Config(rel) || isSwayConfig(rel) {
+			t.Errorf("Expected %s to be an i3 config only", rel)
+		}
+	}
+	for _, rel := range sway {
+		if !isSwayConfig(rel) || isI3Config(rel) {
+			t.Errorf("Expected %s to be a sway config only", rel)
+		}
+	}
+	for _, rel := range neither {
+		if isI3Config(rel) || isSwayConfig(rel) {
+			t.Errorf("Expected %s not to be read", rel)
+		}
+	}
+}
+
+func TestResolveVariables(t *testing.T) {
+	binds := []Keybind{
+		{Key: "$mod+Return", Command: "exec $term", File: "config.d/keys", Line: 1},
+		wmVariable("mod", "Mod4", "config", 1),
+		wmVariable("term", "foot", "config", 2),
+	}
+
+	// A binding with the variable's name as its key is not a definition
+	binds = replaceBind(binds, Keybind{Key: "mod", Command: "nop", File: "config", Line: 3})
+
+	want := []Keybind{
+		{Key: "Mod4+Return", Command: "exec foot", File: "config.d/keys", Line: 1},
+		{Key: "mod", Command: "nop", File: "config", Line: 3},
+	}
+	if got := resolveVariables(binds); !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveVariables() =\n%+v\nwant\n%+v", got, want)
+	}
+}
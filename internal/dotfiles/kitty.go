@@ -0,0 +1,157 @@
+package dotfiles
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+func init() {
+	Register(Extractor{
+		Tool:     "kitty",
+		Match:    isKittyConfig,
+		Parse:    terminalParser(parseKitty).keybinds,
+		Settings: terminalParser(parseKitty).settings,
+		Nix:      kittyNix,
+		Resolve:  resolveKittyMod,
+	})
+}
+
+// kittyDefaultMod is kitty_mod unless a config changes it
+const kittyDefaultMod = "ctrl+shift"
+
+// kittyThemeMarker precedes a comment naming the theme chosen with kitten themes
+const kittyThemeMarker = "# BEGIN_KITTY_THEME"
+
+// kittySettings are the font and theme settings kept
+var kittySettings = map[string]bool{
+	"font_family": true, "bold_font": true, "italic_font": true, "bold_italic_font": true,
+	"font_size": true, "include": true, "background_opacity": true,
+}
+
+// kittyMapOptions are the map options that take a value
+var kittyMapOptions = map[string]bool{"--when-focus-on": true, "--mode": true, "--new-mode": true, "--on-unknown": true, "--on-action": true}
+
+// isKittyConfig matches kitty.conf and .conf files in a kitty directory
+func isKittyConfig(rel string) bool {
+	return path.Base(rel) == "kitty.conf" || path.Ext(rel) == ".conf" && inDir(rel, "kitty")
+}
+
+// parseKitty reads kitty's map lines and its font and theme settings
+func parseKitty(src, file string, first int) *terminalConfig {
+	t := &terminalConfig{}
+	lines := strings.Split(src, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := first + i
+		text := strings.TrimSpace(lines[i])
+
+		if text == kittyThemeMarker && i+1 < len(lines) {
+			if name, ok := strings.CutPrefix(strings.TrimSpace(lines[i+1]), "#"); ok {
+				t.set("theme", strings.TrimSpace(name), file, line+1)
+			}
+			continue
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		// Lines starting with \ continue the one before
+		for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), `\`) {
+			i++
+			text += strings.TrimPrefix(strings.TrimSpace(lines[i]), `\`)
+		}
+
+		name, value := cutField(text)
+		switch {
+		case name == "map":
+			t.binds = kittyMap(t.binds, value, file, line)
+		case name == "kitty_mod":
+			t.binds = append(t.binds, wmVariable(name, value, file, line))
+		case kittySettings[name]:
+			t.set(name, value, file, line)
+		}
+	}
+	return t
+}
+
+// kittyMap reads the arguments of a map line: [options] key action. A map
+// without an action, or to no_op, removes the key's binding.
+func kittyMap(binds []Keybind, args, file string, line int) []Keybind {
+	mode, newMode := "", ""
+	for strings.HasPrefix(args, "--") {
+		var option, value string
+		option, args = cutField(args)
+		if name, v, ok := strings.Cut(option, "="); ok {
+			option, value = name, v
+		} else if kittyMapOptions[option] {
+			value, args = cutField(args)
+		}
+		switch option {
+		case "--mode":
+			mode = value
+		case "--new-mode":
+			newMode = value
+		}
+	}
+
+	key, action := cutField(args)
+	if key == "" {
+		return binds
+	}
+	if newMode != "" {
+		action = "push_keyboard_mode " + newMode
+	}
+	if action == "" || action == "no_op" {
+		return removeBinds(binds, func(b Keybind) bool { return !b.variable && b.Key == key && b.Mode == mode })
+	}
+	return replaceBind(binds, Keybind{Key: key, Command: action, Mode: mode, File: file, Line: line})
+}
+
+// resolveKittyMod expands kitty_mod in keys with its value from any file
+func resolveKittyMod(binds []Keybind) []Keybind {
+	mod := kittyDefaultMod
+	for _, b := range binds {
+		if b.variable && b.Key == "kitty_mod" {
+			mod = b.Command
+		}
+	}
+	binds = removeBinds(binds, func(b Keybind) bool { return b.variable })
+
+	for i, b := range binds {
+		binds[i].Key = strings.ReplaceAll(b.Key, "kitty_mod", mod)
+	}
+	return binds
+}
+
+// kittyNix reads home-manager's programs.kitty keybindings and extraConfig
+func kittyNix(cfg *nix.Config) []Keybind {
+	program := cfg.Programs()["kitty"]
+	if program == nil {
+		return nil
+	}
+
+	var binds []Keybind
+	if mod, ok := program.Settings["settings.kitty_mod"].(string); ok {
+		src := program.Sources["settings.kitty_mod"]
+		binds = append(binds, wmVariable("kitty_mod", mod, src.File, src.Line))
+	}
+	for setting, value := range program.Settings {
+		key, ok := strings.CutPrefix(setting, "keybindings.")
+		if !ok {
+			continue
+		}
+		if action, ok := value.(string); ok {
+			src := program.Sources[setting]
+			binds = append(binds, Keybind{Key: key, Command: action, File: src.File, Line: src.Line})
+		}
+	}
+	sort.Slice(binds, func(i, j int) bool {
+		if binds[i].Line != binds[j].Line {
+			return binds[i].Line < binds[j].Line
+		}
+		return binds[i].Key < binds[j].Key
+	})
+
+	return append(binds, parseSetting(program, "extraConfig", terminalParser(parseKitty).keybinds)...)
+}
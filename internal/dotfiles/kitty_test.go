@@ -0,0 +1,57 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKitty(t *testing.T) {
+	src := `font_family      JetBrains Mono
+font_size 11.5
+kitty_mod ctrl+alt
+
+map kitty_mod+t new_tab_with_cwd
+map ctrl+shift+enter launch --cwd=current
+    \ --type=window
+map --when-focus-on var:in_editor ctrl+s send_key ctrl+s
+map --new-mode mw kitty_mod+f7
+map --mode mw left neighboring_window left
+map kitty_mod+q
+map ctrl+w close_window
+map ctrl+w no_op
+
+# BEGIN_KITTY_THEME
+# Tokyo Night
+include current-theme.conf
+# END_KITTY_THEME
+`
+
+	got := parseKitty(src, "kitty.conf", 1)
+	want := []Keybind{
+		{Key: "ctrl+alt+t", Command: "new_tab_with_cwd", File: "kitty.conf", Line: 5},
+		{Key: "ctrl+shift+enter", Command: "launch --cwd=current --type=window", File: "kitty.conf", Line: 6},
+		{Key: "ctrl+s", Command: "send_key ctrl+s", File: "kitty.conf", Line: 8},
+		{Key: "ctrl+alt+f7", Command: "push_keyboard_mode mw", File: "kitty.conf", Line: 9},
+		{Key: "left", Command: "neighboring_window left", Mode: "mw", File: "kitty.conf", Line: 10},
+	}
+	if binds := resolveKittyMod(got.binds); !reflect.DeepEqual(binds, want) {
+		t.Errorf("parseKitty() binds =\n%+v\nwant\n%+v", binds, want)
+	}
+
+	wantSettings := []Setting{
+		{Key: "font_family", Value: "JetBrains Mono", File: "kitty.conf", Line: 1},
+		{Key: "font_size", Value: "11.5", File: "kitty.conf", Line: 2},
+		{Key: "theme", Value: "Tokyo Night", File: "kitty.conf", Line: 16},
+		{Key: "include", Value: "current-theme.conf", File: "kitty.conf", Line: 17},
+	}
+	if !reflect.DeepEqual(got.settings, wantSettings) {
+		t.Errorf("parseKitty() settings =\n%+v\nwant\n%+v", got.settings, wantSettings)
+	}
+}
+
+func TestResolveKittyModDefault(t *testing.T) {
+	got := resolveKittyMod([]Keybind{{Key: "kitty_mod+c", Command: "copy_to_clipboard"}})
+	if got[0].Key != "ctrl+shift+c" {
+		t.Errorf("Expected kitty_mod to default to ctrl+shift, got %q", got[0].Key)
+	}
+}
@@ -0,0 +1,711 @@
+package dotfiles
+
+import (
+	"strconv"
+	"strings"
+)
+
+// This file holds a small Lua lexer and expression parser. Lua configs
+// (neovim, wezterm) are not run; the extractors scan the tokens for calls and
+// table fields they understand and parse just those expressions.
+
+// luaTokenKind classifies a Lua token
+type luaTokenKind int
+
+const (
+	luaEOF luaTokenKind = iota
+	luaNameToken
+	luaStringToken
+	luaNumberToken
+	luaSymbol
+)
+
+// luaToken is one Lua token
+type luaToken struct {
+	kind       luaTokenKind
+	text       string // Name, symbol, number, or the decoded value of a string
+	start, end int    // Byte offsets in the source
+	line       int
+	valueLine  int // For strings, the line their content starts on
+}
+
+// lexLua splits Lua source into tokens, dropping comments. The first line of
+// src is numbered first. Malformed input never fails; an unterminated string
+// or comment runs to the end.
+func lexLua(src string, first int) []luaToken {
+	var toks []luaToken
+	line := first
+
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(src[i:], "--"):
+			i += 2
+			if level, ok := longBracket(src[i:]); ok {
+				_, n := readLongBracket(src[i:], level)
+				line += strings.Count(src[i:i+n], "\n")
+				i += n
+				continue
+			}
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		start, startLine := i, line
+		switch {
+		case isLuaNameStart(c):
+			for i < len(src) && isLuaNameChar(src[i]) {
+				i++
+			}
+			toks = append(toks, luaToken{kind: luaNameToken, text: src[start:i], start: start, end: i, line: line})
+
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			for i < len(src) {
+				d := src[i]
+				if (d == '+' || d == '-') && (src[i-1] == 'e' || src[i-1] == 'E') && !strings.HasPrefix(strings.ToLower(src[start:]), "0x") {
+					i++
+					continue
+				}
+				if !isLuaNameChar(d) && d != '.' {
+					break
+				}
+				i++
+			}
+			toks = append(toks, luaToken{kind: luaNumberToken, text: src[start:i], start: start, end: i, line: line})
+
+		case c == '"' || c == '\'':
+			value, n := readLuaString(src[i:])
+			line += strings.Count(src[i:i+n], "\n")
+			i += n
+			toks = append(toks, luaToken{kind: luaStringToken, text: value, start: start, end: i, line: startLine, valueLine: startLine})
+
+		case c == '[':
+			if level, ok := longBracket(src[i:]); ok {
+				body, n := readLongBracket(src[i:], level)
+				valueLine := line
+				if strings.HasPrefix(src[i+level+2:], "\n") {
+					valueLine++ // A newline right after the opening bracket is dropped
+				}
+				line += strings.Count(src[i:i+n], "\n")
+				i += n
+				toks = append(toks, luaToken{kind: luaStringToken, text: body, start: start, end: i, line: startLine, valueLine: valueLine})
+				continue
+			}
+			fallthrough
+
+		default:
+			n := 1
+			for _, op := range []string{"...", "..", "==", "~=", "<=", ">=", "//", "::", "<<", ">>"} {
+				if strings.HasPrefix(src[i:], op) {
+					n = len(op)
+					break
+				}
+			}
+			i += n
+			toks = append(toks, luaToken{kind: luaSymbol, text: src[start:i], start: start, end: i, line: line})
+		}
+	}
+
+	return append(toks, luaToken{kind: luaEOF, start: len(src), end: len(src), line: line})
+}
+
+// isLuaNameStart reports whether c can start a name
+func isLuaNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// isLuaNameChar reports whether c can continue a name
+func isLuaNameChar(c byte) bool {
+	return isLuaNameStart(c) || c >= '0' && c <= '9'
+}
+
+// longBracket reports whether s opens a long bracket such as [[ or [==[,
+// returning its level
+func longBracket(s string) (int, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return 0, false
+	}
+	level := 1
+	for level < len(s) && s[level] == '=' {
+		level++
+	}
+	if level < len(s) && s[level] == '[' {
+		return level - 1, true
+	}
+	return 0, false
+}
+
+// readLongBracket returns the contents of the long bracket s opens and the
+// number of bytes it spans
+func readLongBracket(s string, level int) (string, int) {
+	open := level + 2
+	closing := "]" + strings.Repeat("=", level) + "]"
+	end := strings.Index(s[open:], closing)
+	if end < 0 {
+		return strings.TrimPrefix(s[open:], "\n"), len(s)
+	}
+	return strings.TrimPrefix(s[open:open+end], "\n"), open + end + len(closing)
+}
+
+// readLuaString decodes the quoted string s starts with, returning its value
+// and the number of bytes it spans
+func readLuaString(s string) (string, int) {
+	quote := s[0]
+	var sb strings.Builder
+
+	i := 1
+	for i < len(s) && s[i] != quote && s[i] != '\n' {
+		if s[i] != '\\' || i+1 >= len(s) {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'z':
+			for i+1 < len(s) && strings.IndexByte(" \t\r\n", s[i+1]) >= 0 {
+				i++
+			}
+		case 'x':
+			if i+2 < len(s) {
+				if b, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+					sb.WriteByte(byte(b))
+					i += 2
+				}
+			}
+		default:
+			if c >= '0' && c <= '9' {
+				j := i
+				for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '9' {
+					j++
+				}
+				if b, err := strconv.ParseUint(s[i:j], 10, 8); err == nil {
+					sb.WriteByte(byte(b))
+				}
+				i = j - 1
+			} else {
+				sb.WriteByte(c) // \\, \", \', and a line break
+			}
+		}
+		i++
+	}
+
+	if i < len(s) && s[i] == quote {
+		i++
+	}
+	return sb.String(), i
+}
+
+// luaExpr is a parsed Lua expression
+type luaExpr interface {
+	span() luaSpan
+}
+
+// luaSpan is where an expression is in the source
+type luaSpan struct {
+	start, end int // Byte offsets
+	line       int
+}
+
+func (s luaSpan) span() luaSpan { return s }
+
+// luaString is a string literal
+type luaString struct {
+	luaSpan
+	value     string
+	valueLine int
+}
+
+// luaLiteral is a number, true, false, nil or ...
+type luaLiteral struct {
+	luaSpan
+	text string
+}
+
+// luaName is a variable reference
+type luaName struct {
+	luaSpan
+	name string
+}
+
+// luaIndex is obj.key or obj[key]; key is empty unless it is a string
+type luaIndex struct {
+	luaSpan
+	obj luaExpr
+	key string
+}
+
+// luaCall is a function or method call
+type luaCall struct {
+	luaSpan
+	fn     luaExpr
+	method string // Set for obj:method(...)
+	args   []luaExpr
+}
+
+// luaTable is a table constructor
+type luaTable struct {
+	luaSpan
+	items  []luaExpr  // Positional values
+	fields []luaField // Values with string keys, in order
+}
+
+// luaField is a keyed value of a table constructor
+type luaField struct {
+	key   string
+	value luaExpr
+}
+
+// luaFunction is a function expression; its body is the token range
+// [bodyStart, bodyEnd)
+type luaFunction struct {
+	luaSpan
+	params             []string
+	bodyStart, bodyEnd int
+}
+
+// luaBinary is a binary operation, including and, or and ..
+type luaBinary struct {
+	luaSpan
+	op          string
+	left, right luaExpr
+}
+
+// luaUnary is a unary operation
+type luaUnary struct {
+	luaSpan
+	op   string
+	expr luaExpr
+}
+
+// field returns the value of a keyed field, or nil
+func (t *luaTable) field(key string) luaExpr {
+	for _, f := range t.fields {
+		if f.key == key {
+			return f.value
+		}
+	}
+	return nil
+}
+
+// luaPriority is the left and right binding power of each binary operator
+var luaPriority = map[string][2]int{
+	"or": {1, 1}, "and": {2, 2},
+	"<": {3, 3}, ">": {3, 3}, "<=": {3, 3}, ">=": {3, 3}, "~=": {3, 3}, "==": {3, 3},
+	"|": {4, 4}, "~": {5, 5}, "&": {6, 6}, "<<": {7, 7}, ">>": {7, 7},
+	"..": {9, 8}, "+": {10, 10}, "-": {10, 10},
+	"*": {11, 11}, "/": {11, 11}, "//": {11, 11}, "%": {11, 11},
+	"^": {14, 13},
+}
+
+// luaUnaryPriority binds tighter than every binary operator except ^
+const luaUnaryPriority = 12
+
+// luaParser parses expressions from a token stream. Every method returns nil
+// instead of failing, leaving pos wherever parsing stopped.
+type luaParser struct {
+	toks []luaToken
+	pos  int
+}
+
+func (p *luaParser) peek() luaToken {
+	return p.toks[p.pos]
+}
+
+func (p *luaParser) next() luaToken {
+	tok := p.toks[p.pos]
+	if tok.kind != luaEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// is reports whether the current token is the symbol or keyword text
+func (p *luaParser) is(text string) bool {
+	tok := p.peek()
+	return (tok.kind == luaSymbol || tok.kind == luaNameToken) && tok.text == text
+}
+
+// spanFrom returns the span from token start to the last token consumed
+func (p *luaParser) spanFrom(start int) luaSpan {
+	end := start
+	if p.pos > start {
+		end = p.pos - 1
+	}
+	return luaSpan{start: p.toks[start].start, end: p.toks[end].end, line: p.toks[start].line}
+}
+
+// expr parses an expression
+func (p *luaParser) expr() luaExpr {
+	return p.subexpr(0)
+}
+
+// subexpr parses operators binding tighter than limit
+func (p *luaParser) subexpr(limit int) luaExpr {
+	start := p.pos
+
+	var left luaExpr
+	if p.is("not") || p.is("-") || p.is("#") || p.is("~") {
+		op := p.next().text
+		operand := p.subexpr(luaUnaryPriority)
+		if operand == nil {
+			return nil
+		}
+		left = &luaUnary{luaSpan: p.spanFrom(start), op: op, expr: operand}
+	} else if left = p.simple(); left == nil {
+		return nil
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != luaSymbol && tok.kind != luaNameToken {
+			return left
+		}
+		prio, ok := luaPriority[tok.text]
+		if !ok || prio[0] <= limit {
+			return left
+		}
+		p.next()
+		right := p.subexpr(prio[1])
+		if right == nil {
+			return nil
+		}
+		left = &luaBinary{luaSpan: p.spanFrom(start), op: tok.text, left: left, right: right}
+	}
+}
+
+// simple parses a literal, table, function or suffixed expression
+func (p *luaParser) simple() luaExpr {
+	start := p.pos
+	tok := p.peek()
+
+	switch {
+	case tok.kind == luaStringToken:
+		p.next()
+		return &luaString{luaSpan: p.spanFrom(start), value: tok.text, valueLine: tok.valueLine}
+	case tok.kind == luaNumberToken, p.is("nil"), p.is("true"), p.is("false"), p.is("..."):
+		p.next()
+		return &luaLiteral{luaSpan: p.spanFrom(start), text: tok.text}
+	case p.is("{"):
+		return p.table()
+	case p.is("function"):
+		p.next()
+		return p.function(start)
+	}
+	return p.suffixed()
+}
+
+// suffixed parses a name or parenthesized expression followed by field
+// accesses, indexing and calls
+func (p *luaParser) suffixed() luaExpr {
+	start := p.pos
+
+	var expr luaExpr
+	switch tok := p.peek(); {
+	case tok.kind == luaNameToken && !isLuaKeyword(tok.text):
+		p.next()
+		expr = &luaName{luaSpan: p.spanFrom(start), name: tok.text}
+	case p.is("("):
+		p.next()
+		expr = p.expr()
+		if expr == nil || !p.is(")") {
+			return nil
+		}
+		p.next()
+	default:
+		return nil
+	}
+
+	for {
+		switch tok := p.peek(); {
+		case p.is("."):
+			p.next()
+			name := p.next()
+			if name.kind != luaNameToken {
+				return nil
+			}
+			expr = &luaIndex{luaSpan: p.spanFrom(start), obj: expr, key: name.text}
+		case p.is("["):
+			p.next()
+			key := p.expr()
+			if key == nil || !p.is("]") {
+				return nil
+			}
+			p.next()
+			index := &luaIndex{luaSpan: p.spanFrom(start), obj: expr}
+			if s, ok := key.(*luaString); ok {
+				index.key = s.value
+			}
+			expr = index
+		case p.is(":"):
+			p.next()
+			name := p.next()
+			if name.kind != luaNameToken {
+				return nil
+			}
+			args, ok := p.args()
+			if !ok {
+				return nil
+			}
+			expr = &luaCall{luaSpan: p.spanFrom(start), fn: expr, method: name.text, args: args}
+		case p.is("("), p.is("{"), tok.kind == luaStringToken:
+			args, ok := p.args()
+			if !ok {
+				return nil
+			}
+			expr = &luaCall{luaSpan: p.spanFrom(start), fn: expr, args: args}
+		default:
+			return expr
+		}
+	}
+}
+
+// args parses call arguments: a parenthesized list, a table or a string
+func (p *luaParser) args() ([]luaExpr, bool) {
+	start := p.pos
+	switch tok := p.peek(); {
+	case tok.kind == luaStringToken:
+		p.next()
+		return []luaExpr{&luaString{luaSpan: p.spanFrom(start), value: tok.text, valueLine: tok.valueLine}}, true
+	case p.is("{"):
+		t := p.table()
+		if t == nil {
+			return nil, false
+		}
+		return []luaExpr{t}, true
+	case p.is("("):
+		p.next()
+		var args []luaExpr
+		for !p.is(")") {
+			arg := p.expr()
+			if arg == nil {
+				return nil, false
+			}
+			args = append(args, arg)
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		if !p.is(")") {
+			return nil, false
+		}
+		p.next()
+		return args, true
+	}
+	return nil, false
+}
+
+// table parses a table constructor
+func (p *luaParser) table() luaExpr {
+	start := p.pos
+	p.next() // {
+
+	t := &luaTable{}
+	for !p.is("}") {
+		switch next := p.toks[min(p.pos+1, len(p.toks)-1)]; {
+		case p.is("["):
+			p.next()
+			key := p.expr()
+			if key == nil || !p.is("]") {
+				return nil
+			}
+			p.next()
+			if !p.is("=") {
+				return nil
+			}
+			p.next()
+			value := p.expr()
+			if value == nil {
+				return nil
+			}
+			if s, ok := key.(*luaString); ok {
+				t.fields = append(t.fields, luaField{key: s.value, value: value})
+			}
+		case p.peek().kind == luaNameToken && next.kind == luaSymbol && next.text == "=":
+			key := p.next().text
+			p.next()
+			value := p.expr()
+			if value == nil {
+				return nil
+			}
+			t.fields = append(t.fields, luaField{key: key, value: value})
+		default:
+			value := p.expr()
+			if value == nil {
+				return nil
+			}
+			t.items = append(t.items, value)
+		}
+
+		if !p.is(",") && !p.is(";") {
+			break
+		}
+		p.next()
+	}
+	if !p.is("}") {
+		return nil
+	}
+	p.next()
+
+	t.luaSpan = p.spanFrom(start)
+	return t
+}
+
+// function parses a function's parameters and skips its body; the function
+// keyword at token start has been consumed
+func (p *luaParser) function(start int) luaExpr {
+	// function name(...) in a statement; the name is not part of the expression
+	for p.peek().kind == luaNameToken || p.is(".") || p.is(":") {
+		p.next()
+	}
+	if !p.is("(") {
+		return nil
+	}
+	p.next()
+
+	var params []string
+	for !p.is(")") {
+		tok := p.next()
+		if tok.kind == luaNameToken || tok.text == "..." {
+			params = append(params, tok.text)
+		} else if tok.text != "," {
+			return nil
+		}
+	}
+	p.next()
+
+	bodyStart := p.pos
+	for depth := 1; ; {
+		tok := p.peek()
+		if tok.kind == luaEOF {
+			return nil
+		}
+		if tok.kind == luaNameToken {
+			switch tok.text {
+			case "function", "do", "if", "repeat":
+				depth++
+			case "end", "until":
+				depth--
+			}
+		}
+		if depth == 0 {
+			break
+		}
+		p.next()
+	}
+	bodyEnd := p.pos
+	p.next() // end
+
+	return &luaFunction{luaSpan: p.spanFrom(start), params: params, bodyStart: bodyStart, bodyEnd: bodyEnd}
+}
+
+// luaKeywords cannot be used as names
+var luaKeywords = map[string]bool{
+	"and": true, "break": true, "do": true, "else": true, "elseif": true, "end": true,
+	"false": true, "for": true, "function": true, "goto": true, "if": true, "in": true,
+	"local": true, "nil": true, "not": true, "or": true, "repeat": true, "return": true,
+	"then": true, "true": true, "until": true, "while": true,
+}
+
+// isLuaKeyword reports whether name is reserved
+func isLuaKeyword(name string) bool {
+	return luaKeywords[name]
+}
+
+// luaPath returns the dotted path of a name or field access, e.g.
+// "vim.keymap.set", or "" for any other expression
+func luaPath(expr luaExpr) string {
+	switch e := expr.(type) {
+	case *luaName:
+		return e.name
+	case *luaIndex:
+		if e.key == "" {
+			return ""
+		}
+		if obj := luaPath(e.obj); obj != "" {
+			return obj + "." + e.key
+		}
+	}
+	return ""
+}
+
+// luaSource returns the source text of an expression on one line, shortened
+// to max bytes
+func luaSource(src string, expr luaExpr, max int) string {
+	s := expr.span()
+	return oneLine(src[s.start:s.end], max)
+}
+
+// luaStringValue evaluates an expression built from string literals, the
+// names bound in env, .. and or, reporting whether it could
+func luaStringValue(expr luaExpr, env map[string]luaExpr) (string, bool) {
+	switch e := expr.(type) {
+	case *luaString:
+		return e.value, true
+	case *luaName:
+		if value, ok := env[e.name]; ok {
+			return luaStringValue(value, nil)
+		}
+	case *luaBinary:
+		switch e.op {
+		case "..":
+			left, ok := luaStringValue(e.left, env)
+			if !ok {
+				return "", false
+			}
+			right, ok := luaStringValue(e.right, env)
+			if !ok {
+				return "", false
+			}
+			return left + right, true
+		case "or":
+			if value, ok := luaStringValue(e.left, env); ok {
+				return value, true
+			}
+			return luaStringValue(e.right, env)
+		}
+	}
+	return "", false
+}
+
+// luaStrings evaluates a string or a table of strings, such as a list of modes
+func luaStrings(expr luaExpr, env map[string]luaExpr) []string {
+	if value, ok := luaStringValue(expr, env); ok {
+		return []string{value}
+	}
+	if name, ok := expr.(*luaName); ok {
+		if value, ok := env[name.name]; ok {
+			return luaStrings(value, nil)
+		}
+	}
+
+	t, ok := expr.(*luaTable)
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, item := range t.items {
+		if value, ok := luaStringValue(item, env); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
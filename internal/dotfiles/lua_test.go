@@ -0,0 +1,73 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLexLua(t *testing.T) {
+	src := `-- comment
+local s = "a\tb" .. 'c\'d' --[[ block
+comment ]] x = [[
+long
+string]] y = 0x1F + 1e-3 ~= ...`
+
+	var texts []string
+	for _, tok := range lexLua(src, 1) {
+		if tok.kind != luaEOF {
+			texts = append(texts, tok.text)
+		}
+	}
+	want := []string{"local", "s", "=", "a\tb", "..", "c'd", "x", "=", "long\nstring", "y", "=", "0x1F", "+", "1e-3", "~=", "..."}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("lexLua() = %q\nwant %q", texts, want)
+	}
+
+	toks := lexLua(src, 1)
+	if long := toks[8]; long.line != 3 || long.valueLine != 4 {
+		t.Errorf("Expected the long string on line 3 with content from line 4, got %+v", long)
+	}
+	if y := toks[9]; y.line != 5 {
+		t.Errorf("Expected y on line 5, got %d", y.line)
+	}
+}
+
+func TestParseLuaExpr(t *testing.T) {
+	src := `vim.keymap.set({ "n", "v" }, "<leader>y", function() print("hi") end, { desc = "Yank " .. "it", silent = true })`
+	p := &luaParser{toks: lexLua(src, 1)}
+
+	call, ok := p.expr().(*luaCall)
+	if !ok {
+		t.Fatalf("Expected a call")
+	}
+	if luaPath(call.fn) != "vim.keymap.set" || len(call.args) != 4 {
+		t.Fatalf("Unexpected call %s with %d args", luaPath(call.fn), len(call.args))
+	}
+	if modes := luaStrings(call.args[0], nil); !reflect.DeepEqual(modes, []string{"n", "v"}) {
+		t.Errorf("Unexpected modes %q", modes)
+	}
+	if _, ok := call.args[2].(*luaFunction); !ok {
+		t.Errorf("Expected a function argument, got %T", call.args[2])
+	}
+	if got := luaSource(src, call.args[2], 80); got != `function() print("hi") end` {
+		t.Errorf("Unexpected function source %q", got)
+	}
+	desc, ok := luaStringValue(call.args[3].(*luaTable).field("desc"), nil)
+	if !ok || desc != "Yank it" {
+		t.Errorf("Expected desc to evaluate to %q, got %q", "Yank it", desc)
+	}
+	if p.peek().kind != luaEOF {
+		t.Errorf("Expected the whole call to be parsed, stopped at %+v", p.peek())
+	}
+}
+
+func TestParseLuaExprMalformed(t *testing.T) {
+	for _, src := range []string{`f(`, `{ a = }`, `function(a`, `x[`, `a.`, `("x"`} {
+		p := &luaParser{toks: lexLua(src, 1)}
+		if expr := p.expr(); expr != nil {
+			if _, ok := expr.(*luaName); !ok {
+				t.Errorf("Expected %q not to parse, got %T", src, expr)
+			}
+		}
+	}
+}
@@ -0,0 +1,587 @@
+package dotfiles
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+func init() {
+	registerShell("bash", shellExtractor{
+		match:    isBashConfig,
+		parse:    parseBash,
+		settings: []string{"bashrcExtra", "initExtra", "profileExtra"},
+	})
+	registerShell("zsh", shellExtractor{
+		match:    isZshConfig,
+		parse:    parseZsh,
+		settings: []string{"envExtra", "initExtraFirst", "initExtraBeforeCompInit", "initExtra", "initContent", "profileExtra", "loginExtra"},
+		nixPlugins: map[string]string{
+			"oh-my-zsh": "oh-my-zsh.plugins",
+			"antidote":  "antidote.plugins",
+			"zplug":     "zplug.plugins",
+			"prezto":    "prezto.pmodules",
+		},
+	})
+}
+
+// bashFiles and zshFiles are startup files read by name; files ending in
+// .bash or .zsh are read too
+var (
+	bashFiles = map[string]bool{".bashrc": true, "bashrc": true, ".bash_profile": true, "bash_profile": true, ".bash_login": true, ".bash_aliases": true, ".bash_functions": true, ".bash_exports": true}
+	zshFiles  = map[string]bool{".zshrc": true, "zshrc": true, ".zshenv": true, "zshenv": true, ".zprofile": true, "zprofile": true, ".zlogin": true, "zlogin": true, ".zsh_aliases": true}
+)
+
+// shPluginDirs hold frameworks and plugins checked out into the dotfiles,
+// not the user's own config
+var shPluginDirs = []string{".oh-my-zsh", "oh-my-zsh", "ohmyzsh", ".oh-my-bash", ".bash_it", ".zinit", "zinit", ".zplug", ".antidote", ".zprezto", "plugins", "themes"}
+
+// shPluginScripts are the scripts sourced to load a plugin manager
+var shPluginScripts = []struct{ script, manager string }{
+	{"oh-my-zsh.sh", "oh-my-zsh"},
+	{"oh-my-bash.sh", "oh-my-bash"},
+	{"bash_it.sh", "bash-it"},
+	{"zinit.zsh", "zinit"},
+	{"antigen.zsh", "antigen"},
+	{"antidote.zsh", "antidote"},
+	{"zplug/init.zsh", "zplug"},
+	{"zgenom.zsh", "zgenom"},
+	{"znap.zsh", "znap"},
+	{"zprezto/init.zsh", "prezto"},
+}
+
+// shPluginCommands are the subcommands of zsh plugin managers that load a
+// plugin. zplug takes the plugin as its first argument instead.
+var shPluginCommands = map[string][]string{
+	"zinit":    {"light", "load", "snippet"},
+	"zi":       {"light", "load", "snippet"},
+	"antigen":  {"bundle", "theme"},
+	"antidote": {"bundle"},
+	"zgenom":   {"load", "ohmyzsh", "oh-my-zsh"},
+	"znap":     {"source"},
+	"zplug":    nil,
+}
+
+// shKeywords may start a command without being the command
+var shKeywords = map[string]bool{"then": true, "do": true, "else": true, "{": true, "(": true, "!": true, "builtin": true, "command": true}
+
+var (
+	// shFunctionRe matches "name() ...", "function name ..." and "function name() ..."
+	shFunctionRe = regexp.MustCompile(`^(?:function\s+([^\s(){}]+)(?:\s*\(\s*\))?|([^\s(){}=$"']+)\s*\(\s*\))\s*(.*)$`)
+
+	// shHeredocRe matches the start of a here-document, but not a <<< here-string
+	shHeredocRe = regexp.MustCompile(`(?:^|[^<])<<-?[ \t]*['"\\]?([A-Za-z_][A-Za-z0-9_]*)`)
+
+	shNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// isBashConfig matches bash startup files and .bash files
+func isBashConfig(rel string) bool {
+	return !inDir(rel, shPluginDirs...) && (bashFiles[path.Base(rel)] || path.Ext(rel) == ".bash")
+}
+
+// isZshConfig matches zsh startup files and .zsh files
+func isZshConfig(rel string) bool {
+	return !inDir(rel, shPluginDirs...) && (zshFiles[path.Base(rel)] || path.Ext(rel) == ".zsh")
+}
+
+// parseBash reads a bash startup file
+func parseBash(src, file string, line int) *Shell {
+	return parseSh(src, file, line, "oh-my-bash")
+}
+
+// parseZsh reads a zsh startup file
+func parseZsh(src, file string, line int) *Shell {
+	return parseSh(src, file, line, "oh-my-zsh")
+}
+
+// shParser reads bash and zsh startup files
+type shParser struct {
+	shell     *Shell
+	file      string
+	framework string            // Whose plugins=(...) array the shell reads
+	vars      map[string]string // Variables assigned so far, for a later export NAME
+	fn        *shFunction       // The function whose body is being read
+}
+
+// shFunction is a function definition being read
+type shFunction struct {
+	def    Definition
+	body   strings.Builder
+	braces int  // { left open
+	opened bool // The body's { has been read
+}
+
+// parseSh reads the aliases, functions, exports, plugins and key bindings of
+// a bash or zsh script. Key bindings set inside functions are kept, since
+// functions such as zvm_after_init exist to bind keys.
+func parseSh(src, file string, first int, framework string) *Shell {
+	p := &shParser{shell: &Shell{}, file: file, framework: framework, vars: make(map[string]string)}
+	for _, l := range shLines(src, first) {
+		p.line(l.text, l.line)
+	}
+	if p.fn != nil {
+		p.endFunction()
+	}
+	return p.shell
+}
+
+// line reads one logical line
+func (p *shParser) line(text string, line int) {
+	if fn := p.fn; fn != nil && !fn.opened {
+		if strings.HasPrefix(text, "{") {
+			fn.opened = true
+		} else {
+			p.endFunction() // Not a { } body, e.g. a subshell
+		}
+	}
+
+	if fn := p.fn; fn != nil {
+		_, braces, _ := shScan(text)
+		fn.braces += braces
+		fn.body.WriteString(text + "\n")
+		p.commands(text, line, true)
+		if fn.braces <= 0 {
+			p.endFunction()
+		}
+		return
+	}
+
+	if m := shFunctionRe.FindStringSubmatch(text); m != nil {
+		p.fn = &shFunction{def: Definition{Name: m[1] + m[2], File: p.file, Line: line}}
+		if rest := m[3]; rest != "" {
+			p.fn.opened = true
+			p.line(rest, line)
+		}
+		return
+	}
+
+	p.commands(text, line, false)
+}
+
+// endFunction records the function being read
+func (p *shParser) endFunction() {
+	fn := p.fn
+	p.fn = nil
+
+	body := strings.TrimSpace(fn.body.String())
+	if inner, ok := strings.CutPrefix(body, "{"); ok {
+		if i := strings.LastIndex(inner, "}"); i >= 0 {
+			inner = inner[:i]
+		}
+		body = strings.TrimSuffix(strings.TrimSpace(inner), ";")
+	}
+	fn.def.Value = oneLine(body, maxDefinitionValue)
+	p.shell.Functions = setDefinition(p.shell.Functions, fn.def)
+}
+
+// commands reads each command on a logical line. Inside a function body only
+// key bindings are read; the rest only runs when the function is called.
+func (p *shParser) commands(text string, line int, bindsOnly bool) {
+	for _, args := range shCommands(text, false) {
+		for len(args) > 0 && shKeywords[args[0]] {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "bindkey":
+			p.bindkey(args[1:], line)
+		case "bind":
+			p.bind(args[1:], line)
+		}
+		if bindsOnly {
+			continue
+		}
+
+		switch args[0] {
+		case "alias":
+			for _, arg := range args[1:] {
+				if name, value, ok := strings.Cut(arg, "="); ok && name != "" && !strings.HasPrefix(arg, "-") {
+					p.shell.Aliases = setDefinition(p.shell.Aliases, Definition{Name: name, Value: oneLine(value, maxDefinitionValue), File: p.file, Line: line})
+				}
+			}
+		case "export", "declare", "typeset":
+			exported := args[0] == "export"
+			for _, arg := range args[1:] {
+				if strings.HasPrefix(arg, "-") {
+					exported = exported || strings.Contains(arg, "x")
+					continue
+				}
+				name, value, assigned := strings.Cut(arg, "=")
+				if !shNameRe.MatchString(name) {
+					continue
+				}
+				if assigned {
+					p.vars[name] = value
+				} else if value, assigned = p.vars[name]; !assigned {
+					continue
+				}
+				if exported {
+					p.shell.Exports = setDefinition(p.shell.Exports, Definition{Name: name, Value: oneLine(value, maxDefinitionValue), File: p.file, Line: line})
+				}
+			}
+		case "source", ".":
+			if len(args) > 1 {
+				for _, s := range shPluginScripts {
+					if strings.HasSuffix(args[1], s.script) {
+						p.shell.addPlugin(Plugin{Manager: s.manager, File: p.file, Line: line})
+					}
+				}
+			}
+		default:
+			if _, ok := shPluginCommands[args[0]]; ok {
+				p.pluginCommand(args, line)
+				continue
+			}
+			// NAME=value assignments, including plugins=(...)
+			for _, arg := range args {
+				name, value, ok := strings.Cut(arg, "=")
+				if !ok || !shNameRe.MatchString(name) {
+					break
+				}
+				p.vars[name] = value
+				if name == "plugins" && strings.HasPrefix(value, "(") {
+					for _, plugin := range strings.Fields(strings.Trim(value, "()")) {
+						p.shell.addPlugin(Plugin{Manager: p.framework, Name: plugin, File: p.file, Line: line})
+					}
+				}
+			}
+		}
+	}
+}
+
+// pluginCommand reads a plugin manager command such as "zinit light user/repo"
+func (p *shParser) pluginCommand(args []string, line int) {
+	manager, rest := args[0], args[1:]
+	if manager == "zi" {
+		manager = "zinit"
+	}
+	if subcommands := shPluginCommands[args[0]]; subcommands != nil {
+		if len(rest) == 0 || !slices.Contains(subcommands, rest[0]) {
+			return
+		}
+		rest = rest[1:]
+	} else if len(rest) == 0 || !strings.Contains(rest[0], "/") {
+		return // zplug load, zplug check, ...
+	}
+
+	for _, arg := range rest {
+		if strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") {
+			continue
+		}
+		p.shell.addPlugin(Plugin{Manager: manager, Name: strings.TrimSuffix(arg, ","), File: p.file, Line: line})
+		return
+	}
+}
+
+// bindkey reads a zsh bindkey command: bindkey [-M keymap] [-s] [-r] key [widget]
+func (p *shParser) bindkey(args []string, line int) {
+	mode, macro, remove := "", false, false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		flags := args[0]
+		args = args[1:]
+		if flags == "--" {
+			break
+		}
+		for _, flag := range flags[1:] {
+			switch flag {
+			case 'M':
+				if len(args) > 0 {
+					mode, args = args[0], args[1:]
+				}
+			case 's':
+				macro = true
+			case 'r':
+				remove = true
+			case 'R': // A range of keys, bound like one
+			default:
+				return // Selects, lists or copies keymaps
+			}
+		}
+	}
+
+	if remove {
+		p.shell.binds = removeBinds(p.shell.binds, func(b Keybind) bool { return slices.Contains(args, b.Key) && b.Mode == mode })
+		return
+	}
+	if len(args) < 2 {
+		return // Shows a binding
+	}
+
+	b := Keybind{Key: args[0], Command: args[1], Mode: mode, File: p.file, Line: line}
+	if macro {
+		b.Description = "Macro"
+	}
+	p.shell.binds = replaceBind(p.shell.binds, b)
+}
+
+// bind reads a bash bind command: bind [-m keymap] [-x] [-r key] '"key": command'
+func (p *shParser) bind(args []string, line int) {
+	mode, shellCommand := "", false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		flag := args[0]
+		args = args[1:]
+		switch flag {
+		case "-m":
+			if len(args) > 0 {
+				mode, args = args[0], args[1:]
+			}
+		case "-x":
+			shellCommand = true
+		case "-r":
+			if len(args) > 0 {
+				key := strings.Trim(args[0], `"`)
+				p.shell.binds = removeBinds(p.shell.binds, func(b Keybind) bool { return b.Key == key && b.Mode == mode })
+			}
+			return
+		default:
+			return // Lists, queries or reads bindings from a file
+		}
+	}
+
+	for _, arg := range args {
+		key, command, ok := readlineBinding(arg)
+		if !ok {
+			continue
+		}
+		b := Keybind{Key: key, Command: command, Mode: mode, File: p.file, Line: line}
+		if shellCommand {
+			b.Description = "Shell command"
+			b.Command = strings.Trim(command, `"`)
+		} else if unquoted, ok := strings.CutPrefix(command, `"`); ok {
+			b.Description = "Macro"
+			b.Command = strings.TrimSuffix(unquoted, `"`)
+		}
+		p.shell.binds = replaceBind(p.shell.binds, b)
+	}
+}
+
+// readlineBinding splits a readline binding such as "\C-r": reverse-search-history
+func readlineBinding(spec string) (key, command string, ok bool) {
+	spec = strings.TrimSpace(spec)
+	if rest, quoted := strings.CutPrefix(spec, `"`); quoted {
+		end := -1
+		for i := 0; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+			} else if rest[i] == '"' {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return "", "", false
+		}
+		key, command = rest[:end], strings.TrimPrefix(strings.TrimSpace(rest[end+1:]), ":")
+	} else if key, command, ok = strings.Cut(spec, ":"); !ok || strings.ContainsAny(key, " \t") {
+		return "", "", false // Not a binding, e.g. "set completion-ignore-case on"
+	}
+
+	command = strings.TrimSpace(command)
+	return key, command, key != "" && command != ""
+}
+
+// shLine is a logical line of shell and the line it starts on
+type shLine struct {
+	text string
+	line int
+}
+
+// shLines splits shell source into logical lines: continued lines, quotes
+// and ( ) spanning lines are joined, and comments and here-documents dropped
+func shLines(src string, first int) []shLine {
+	var lines []shLine
+	raw := strings.Split(src, "\n")
+	heredoc := ""
+
+	for i := 0; i < len(raw); i++ {
+		if heredoc != "" {
+			if strings.TrimSpace(raw[i]) == heredoc {
+				heredoc = ""
+			}
+			continue
+		}
+
+		start := i
+		text := raw[i]
+		for i+1 < len(raw) {
+			if continued, ok := strings.CutSuffix(text, `\`); ok {
+				i++
+				text = strings.TrimRight(continued, " \t") + " " + strings.TrimLeft(raw[i], " \t")
+				continue
+			}
+			if parens, _, quoted := shScan(text); parens <= 0 && !quoted {
+				break
+			}
+			i++
+			text += "\n" + raw[i]
+		}
+
+		if m := shHeredocRe.FindStringSubmatch(text); m != nil {
+			heredoc = m[1]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		lines = append(lines, shLine{text: text, line: first + start})
+	}
+	return lines
+}
+
+// shScan reports how many ( and { a line of shell leaves open, and whether
+// it ends inside a quote. Comments and ${...} expansions are skipped.
+func shScan(text string) (parens, braces int, quoted bool) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '#' && (i == 0 || isTmuxSpace(text[i-1])):
+			return parens, braces, false
+		case c == '$' && i+1 < len(text) && text[i+1] == '{':
+			if end := strings.IndexByte(text[i:], '}'); end >= 0 {
+				i += end
+			}
+		case c == '(':
+			parens++
+		case c == ')':
+			parens--
+		case c == '{':
+			braces++
+		case c == '}':
+			braces--
+		}
+	}
+	return parens, braces, quote != 0
+}
+
+// shCommands splits a logical line into commands and their words, removing
+// quotes and escapes. $(...), `...` and name=(...) stay one word. For fish,
+// escapes outside quotes are kept, as in key names like \cf, and any (...)
+// is a command substitution.
+func shCommands(text string, fish bool) [][]string {
+	var commands [][]string
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+			words = nil
+		}
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case isTmuxSpace(c):
+			endWord()
+			continue
+		case c == ';' || c == '&' || c == '|':
+			endCommand()
+			continue
+		case c == '#' && !inWord:
+			endCommand()
+			return commands
+		case c == '\\':
+			if fish {
+				word.WriteByte(c)
+			}
+			if i+1 < len(text) {
+				i++
+				word.WriteByte(text[i])
+			}
+		case c == '\'' && fish:
+			// fish allows \' and \\ inside single quotes
+			for i++; i < len(text) && text[i] != '\''; i++ {
+				if text[i] == '\\' && i+1 < len(text) && (text[i+1] == '\'' || text[i+1] == '\\') {
+					i++
+				}
+				word.WriteByte(text[i])
+			}
+		case c == '\'':
+			end := strings.IndexByte(text[i+1:], '\'')
+			if end < 0 {
+				end = len(text) - i - 1
+			}
+			word.WriteString(text[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			for i++; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' && i+1 < len(text) && strings.IndexByte("\"\\$`", text[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(text[i])
+			}
+		case c == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end < 0 {
+				end = len(text) - i - 1
+			}
+			word.WriteString(text[i:min(i+end+2, len(text))])
+			i += end + 1
+		case c == '(' && (fish || i > 0 && (text[i-1] == '$' || text[i-1] == '=')):
+			end := closingParen(text, i)
+			word.WriteString(text[i : end+1])
+			i = end
+		default:
+			word.WriteByte(c)
+		}
+		inWord = true
+	}
+	endCommand()
+	return commands
+}
+
+// closingParen returns the index of the ) closing the ( at open, or the end
+// of text if it is not closed
+func closingParen(text string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(text) - 1
+}
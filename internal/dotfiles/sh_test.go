@@ -0,0 +1,129 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseZsh(t *testing.T) {
+	src := `# Aliases
+alias gs='git status' gd="git diff"
+alias -g G='| grep'
+EDITOR=nvim
+export EDITOR PATH="$HOME/bin:$PATH"
+typeset -gx PAGER=less
+
+function gcb {
+  git checkout -b "$1"
+}
+zvm_after_init() {
+  bindkey -M viins '^F' forward-char
+}
+
+source ${ZINIT_HOME}/zinit.zsh
+zinit ice depth=1
+zinit light zsh-users/zsh-autosuggestions
+zplug "romkatv/powerlevel10k", as:theme
+zplug load
+
+bindkey -v
+bindkey -s '^o' 'lfcd\n'
+bindkey '^P' up-history
+bindkey -r '^P'
+cat <<EOF > /tmp/x
+alias nope=x
+EOF
+`
+
+	got := parseZsh(src, ".zshrc", 1)
+	want := &Shell{
+		Aliases: []Definition{
+			{Name: "gs", Value: "git status", File: ".zshrc", Line: 2},
+			{Name: "gd", Value: "git diff", File: ".zshrc", Line: 2},
+			{Name: "G", Value: "| grep", File: ".zshrc", Line: 3},
+		},
+		Functions: []Definition{
+			{Name: "gcb", Value: `git checkout -b "$1"`, File: ".zshrc", Line: 8},
+			{Name: "zvm_after_init", Value: "bindkey -M viins '^F' forward-char", File: ".zshrc", Line: 11},
+		},
+		Exports: []Definition{
+			{Name: "EDITOR", Value: "nvim", File: ".zshrc", Line: 5},
+			{Name: "PATH", Value: "$HOME/bin:$PATH", File: ".zshrc", Line: 5},
+			{Name: "PAGER", Value: "less", File: ".zshrc", Line: 6},
+		},
+		Plugins: []Plugin{
+			{Manager: "zinit", File: ".zshrc", Line: 15},
+			{Manager: "zinit", Name: "zsh-users/zsh-autosuggestions", File: ".zshrc", Line: 17},
+			{Manager: "zplug", Name: "romkatv/powerlevel10k", File: ".zshrc", Line: 18},
+		},
+		binds: []Keybind{
+			{Key: "^F", Command: "forward-char", Mode: "viins", File: ".zshrc", Line: 12},
+			{Key: "^o", Command: `lfcd\n`, Description: "Macro", File: ".zshrc", Line: 22},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseZsh() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseBash(t *testing.T) {
+	src := `alias ..='cd ..'
+bind '"\C-r": reverse-search-history'
+bind -m vi-insert '"\C-l": clear-screen'
+bind -x '"\C-g": "git status"'
+bind '"\C-x\C-e": "echo hi"'
+bind 'set completion-ignore-case on'
+source ~/.bash_it/bash_it.sh
+plugins=(git)
+`
+
+	got := parseBash(src, ".bashrc", 1)
+	wantBinds := []Keybind{
+		{Key: `\C-r`, Command: "reverse-search-history", File: ".bashrc", Line: 2},
+		{Key: `\C-l`, Command: "clear-screen", Mode: "vi-insert", File: ".bashrc", Line: 3},
+		{Key: `\C-g`, Command: "git status", Description: "Shell command", File: ".bashrc", Line: 4},
+		{Key: `\C-x\C-e`, Command: "echo hi", Description: "Macro", File: ".bashrc", Line: 5},
+	}
+	if !reflect.DeepEqual(got.binds, wantBinds) {
+		t.Errorf("bindings =\n%+v\nwant\n%+v", got.binds, wantBinds)
+	}
+
+	wantPlugins := []Plugin{
+		{Manager: "bash-it", File: ".bashrc", Line: 7},
+		{Manager: "oh-my-bash", Name: "git", File: ".bashrc", Line: 8},
+	}
+	if !reflect.DeepEqual(got.Plugins, wantPlugins) {
+		t.Errorf("plugins =\n%+v\nwant\n%+v", got.Plugins, wantPlugins)
+	}
+	if len(got.Aliases) != 1 || got.Aliases[0].Name != ".." {
+		t.Errorf("Expected the .. alias, got %+v", got.Aliases)
+	}
+}
+
+func TestShellConfigMatch(t *testing.T) {
+	bash := []string{".bashrc", "bash/.bash_aliases", "shell/git.bash"}
+	zsh := []string{".zshrc", "zsh/.zshenv", "zsh/aliases.zsh"}
+	fish := []string{".config/fish/config.fish", "fish/functions/gcm.fish", "fish/fish_plugins"}
+	none := []string{".oh-my-zsh/oh-my-zsh.sh", ".oh-my-zsh/plugins/git/git.plugin.zsh", "zsh/plugins/x/x.zsh", "scripts/setup.sh", "fish.md"}
+
+	for _, rel := range bash {
+		if !isBashConfig(rel) || isZshConfig(rel) || isFishConfig(rel) {
+			t.Errorf("Expected %s to be a bash config only", rel)
+		}
+	}
+	for _, rel := range zsh {
+		if !isZshConfig(rel) || isBashConfig(rel) || isFishConfig(rel) {
+			t.Errorf("Expected %s to be a zsh config only", rel)
+		}
+	}
+	for _, rel := range fish {
+		if !isFishConfig(rel) || isBashConfig(rel) || isZshConfig(rel) {
+			t.Errorf("Expected %s to be a fish config only", rel)
+		}
+	}
+	for _, rel := range none {
+		if isBashConfig(rel) || isZshConfig(rel) || isFishConfig(rel) {
+			t.Errorf("Expected %s not to be read", rel)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package dotfiles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+// Shell is what one shell's startup files define
+type Shell struct {
+	Aliases   []Definition // Aliases and fish abbreviations
+	Functions []Definition
+	Exports   []Definition // Exported environment variables
+	Plugins   []Plugin
+
+	binds []Keybind // Reported by ExtractAll with the other tools' bindings
+}
+
+// Definition is an alias, function or environment variable
+type Definition struct {
+	Name  string
+	Value string // Expansion, function body or variable value, on one line
+	File  string // Relative to the dotfiles root
+	Line  int
+}
+
+// Plugin is a shell plugin, or a plugin manager loaded by itself
+type Plugin struct {
+	Manager string // e.g. "oh-my-zsh", "zinit", "fisher" or "home-manager"
+	Name    string // Empty for the manager itself
+	File    string
+	Line    int
+}
+
+// maxDefinitionValue is the longest function body or value kept
+const maxDefinitionValue = 200
+
+// shellExtractor reads the startup files of one shell
+type shellExtractor struct {
+	match func(rel string) bool
+	parse func(src, file string, line int) *Shell
+
+	// settings are the programs.<shell> options holding shell code, e.g. "initExtra"
+	settings []string
+
+	// nixPlugins maps each home-manager plugin manager to the option listing its plugins
+	nixPlugins map[string]string
+}
+
+// shellExtractors holds every shell, keyed by name
+var shellExtractors = make(map[string]shellExtractor)
+
+// registerShell makes a shell's definitions and key bindings available to
+// ExtractAll
+func registerShell(name string, e shellExtractor) {
+	shellExtractors[name] = e
+	Register(Extractor{
+		Tool:  name,
+		Match: e.match,
+		Parse: func(src, file string, line int) []Keybind { return e.parse(src, file, line).binds },
+		Nix:   func(cfg *nix.Config) []Keybind { return shellNix(cfg, name, e).binds },
+	})
+}
+
+// merge adds what other defines; later definitions replace earlier ones of
+// the same name, as when the shell reads its files in turn
+func (s *Shell) merge(other *Shell) {
+	for _, d := range other.Aliases {
+		s.Aliases = setDefinition(s.Aliases, d)
+	}
+	for _, d := range other.Functions {
+		s.Functions = setDefinition(s.Functions, d)
+	}
+	for _, d := range other.Exports {
+		s.Exports = setDefinition(s.Exports, d)
+	}
+	for _, p := range other.Plugins {
+		s.addPlugin(p)
+	}
+	s.binds = append(s.binds, other.binds...)
+}
+
+// addPlugin adds a plugin unless it is already loaded by the same manager
+func (s *Shell) addPlugin(p Plugin) {
+	for _, existing := range s.Plugins {
+		if existing.Manager == p.Manager && existing.Name == p.Name {
+			return
+		}
+	}
+	s.Plugins = append(s.Plugins, p)
+}
+
+// setDefinition adds d, replacing an earlier definition of the same name
+func setDefinition(defs []Definition, d Definition) []Definition {
+	for i, existing := range defs {
+		if existing.Name == d.Name {
+			defs = append(defs[:i:i], defs[i+1:]...)
+			break
+		}
+	}
+	return append(defs, d)
+}
+
+// shellNix reads home-manager's programs.<shell> options for a shell, along
+// with home.shellAliases and home.sessionVariables, which every enabled shell
+// shares
+func shellNix(cfg *nix.Config, name string, e shellExtractor) *Shell {
+	shell := &Shell{}
+	program := cfg.Programs()[name]
+	if program == nil {
+		return shell
+	}
+
+	for _, d := range nixDefinitions(cfg.Options, cfg.Sources, "home.shellAliases.", "") {
+		shell.Aliases = setDefinition(shell.Aliases, d)
+	}
+	for _, prefix := range []string{"shellAliases.", "shellGlobalAliases."} {
+		for _, d := range nixDefinitions(program.Settings, program.Sources, prefix, "") {
+			shell.Aliases = setDefinition(shell.Aliases, d)
+		}
+	}
+	// fish abbreviations are an expansion, or a set with the expansion and options
+	for _, d := range nixDefinitions(program.Settings, program.Sources, "shellAbbrs.", ".expansion") {
+		shell.Aliases = setDefinition(shell.Aliases, d)
+	}
+
+	for _, d := range nixDefinitions(cfg.Options, cfg.Sources, "home.sessionVariables.", "") {
+		shell.Exports = setDefinition(shell.Exports, d)
+	}
+	for _, d := range nixDefinitions(program.Settings, program.Sources, "sessionVariables.", "") {
+		shell.Exports = setDefinition(shell.Exports, d)
+	}
+
+	// fish functions are a body, or a set with the body and options
+	for _, d := range nixDefinitions(program.Settings, program.Sources, "functions.", ".body") {
+		shell.Functions = setDefinition(shell.Functions, d)
+	}
+
+	managers := make([]string, 0, len(e.nixPlugins))
+	for manager := range e.nixPlugins {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+	for _, manager := range managers {
+		option := e.nixPlugins[manager]
+		enable, _, _ := strings.Cut(option, ".")
+		if program.Settings[enable+".enable"] != true {
+			continue
+		}
+		src := program.Sources[enable+".enable"]
+		shell.addPlugin(Plugin{Manager: manager, File: src.File, Line: src.Line})
+		shell.Plugins = append(shell.Plugins, nixPlugins(program, option, manager)...)
+	}
+	// Plugins sourced by home-manager itself
+	shell.Plugins = append(shell.Plugins, nixPlugins(program, "plugins", "home-manager")...)
+
+	for _, setting := range e.settings {
+		text, ok := program.Settings[setting].(string)
+		if !ok {
+			continue
+		}
+		src := program.Sources[setting]
+		shell.merge(e.parse(text, src.File, max(src.Line, 1)))
+	}
+	return shell
+}
+
+// nixDefinitions reads the settings below prefix as definitions named by the
+// rest of the key. A value may instead be set below the name, at suffix, as
+// with fish abbreviations { expansion = ...; }. Definitions are sorted by
+// where they were set.
+func nixDefinitions(settings map[string]any, sources map[string]nix.Source, prefix, suffix string) []Definition {
+	var defs []Definition
+	for key, value := range settings {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok && suffix != "" {
+			name = trimmed
+		} else if isNixSuboption(settings, prefix, name, suffix) {
+			continue // Another option of the set, e.g. a fish function's description
+		}
+
+		var text string
+		switch v := value.(type) {
+		case string:
+			text = v
+		case bool, int64, float64:
+			text = fmt.Sprint(v)
+		default:
+			continue
+		}
+
+		src := sources[key]
+		defs = append(defs, Definition{Name: name, Value: oneLine(text, maxDefinitionValue), File: src.File, Line: src.Line})
+	}
+
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].Line != defs[j].Line {
+			return defs[i].Line < defs[j].Line
+		}
+		return defs[i].Name < defs[j].Name
+	})
+	return defs
+}
+
+// isNixSuboption reports whether the key below prefix is an option of a set
+// whose value is at suffix, e.g. "fish_greeting.description" beside
+// "fish_greeting.body"
+func isNixSuboption(settings map[string]any, prefix, name, suffix string) bool {
+	if suffix == "" {
+		return false
+	}
+	for i := strings.LastIndex(name, "."); i > 0; i = strings.LastIndex(name[:i], ".") {
+		if _, ok := settings[prefix+name[:i]+suffix]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nixPlugins reads a list of plugins: names, or sets with a name
+func nixPlugins(program *nix.Program, option, manager string) []Plugin {
+	items, ok := program.Settings[option].([]any)
+	if !ok {
+		return nil
+	}
+
+	src := program.Sources[option]
+	var plugins []Plugin
+	for _, item := range items {
+		name, ok := item.(string)
+		if set, isSet := item.(map[string]any); isSet {
+			name, ok = set["name"].(string)
+		}
+		if ok && name != "" {
+			plugins = append(plugins, Plugin{Manager: manager, Name: name, File: src.File, Line: src.Line})
+		}
+	}
+	return plugins
+}
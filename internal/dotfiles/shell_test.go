@@ -0,0 +1,82 @@
+package dotfiles
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"codex/internal/nix"
+)
+
+func TestExtractShellsFiles(t *testing.T) {
+	got := ExtractAll(filepath.Join("testdata", "stow"), nil).Shells
+
+	zsh := got["zsh"]
+	if zsh == nil {
+		t.Fatalf("Expected zsh definitions, got %v", got)
+	}
+	wantAliases := []Definition{
+		{Name: "gl", Value: "git log --oneline --graph", File: "zsh/.zshrc", Line: 8},
+		{Name: "ll", Value: "ls -lah", File: "zsh/.zshrc", Line: 9},
+	}
+	if !reflect.DeepEqual(zsh.Aliases, wantAliases) {
+		t.Errorf("zsh aliases =\n%+v\nwant\n%+v", zsh.Aliases, wantAliases)
+	}
+	wantPlugins := []Plugin{
+		{Manager: "oh-my-zsh", Name: "git", File: "zsh/.zshrc", Line: 2},
+		{Manager: "oh-my-zsh", Name: "fzf", File: "zsh/.zshrc", Line: 2},
+		{Manager: "oh-my-zsh", File: "zsh/.zshrc", Line: 6},
+	}
+	if !reflect.DeepEqual(zsh.Plugins, wantPlugins) {
+		t.Errorf("zsh plugins =\n%+v\nwant\n%+v", zsh.Plugins, wantPlugins)
+	}
+
+	fish := got["fish"]
+	if fish == nil {
+		t.Fatalf("Expected fish definitions, got %v", got)
+	}
+	if len(fish.Plugins) != 2 || fish.Plugins[1].Name != "PatrickF1/fzf.fish" {
+		t.Errorf("Expected the plugins in fish_plugins, got %+v", fish.Plugins)
+	}
+
+	if len(got) != 2 {
+		t.Errorf("Expected only zsh and fish, got %v", got)
+	}
+}
+
+func TestExtractShellsHomeManager(t *testing.T) {
+	root := filepath.Join("testdata", "home")
+	cfg, err := nix.ParseConfig(root)
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	got := ExtractAll(root, cfg).Shells
+
+	// home.shellAliases and home.sessionVariables apply to every shell
+	want := &Shell{
+		Aliases: []Definition{
+			{Name: "g", Value: "git", File: "home.nix", Line: 50},
+			{Name: "gl", Value: "git log --oneline", File: "home.nix", Line: 56},
+		},
+		Functions: []Definition{{Name: "take", Value: `mkdir -p "$1" && cd "$1"`, File: "home.nix", Line: 64}},
+		Exports:   []Definition{{Name: "EDITOR", Value: "nvim", File: "home.nix", Line: 51}},
+		Plugins: []Plugin{
+			{Manager: "oh-my-zsh", File: "home.nix", Line: 59},
+			{Manager: "oh-my-zsh", Name: "git", File: "home.nix", Line: 60},
+			{Manager: "oh-my-zsh", Name: "sudo", File: "home.nix", Line: 60},
+		},
+		binds: []Keybind{{Key: "^ ", Command: "autosuggest-accept", File: "home.nix", Line: 63}},
+	}
+	if !reflect.DeepEqual(got["zsh"], want) {
+		t.Errorf("zsh =\n%+v\nwant\n%+v", got["zsh"], want)
+	}
+
+	// A function set with a body and a description is one function
+	wantFunctions := []Definition{
+		{Name: "gcm", Value: "git commit -m $argv", File: "home.nix", Line: 71},
+		{Name: "fish_greeting", File: "home.nix", Line: 74},
+	}
+	if fish := got["fish"]; fish == nil || !reflect.DeepEqual(fish.Functions, wantFunctions) {
+		t.Errorf("fish = %+v, want functions %+v", fish, wantFunctions)
+	}
+}
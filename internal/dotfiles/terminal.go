@@ -0,0 +1,32 @@
+package dotfiles
+
+// terminalConfig is what a terminal emulator's config text sets
+type terminalConfig struct {
+	binds    []Keybind
+	settings []Setting
+}
+
+// terminalParser reads a terminal emulator's config text, which holds both
+// key bindings and settings
+type terminalParser func(src, file string, line int) *terminalConfig
+
+// keybinds parses config text for its key bindings, as Extractor.Parse
+func (parse terminalParser) keybinds(src, file string, line int) []Keybind {
+	return parse(src, file, line).binds
+}
+
+// settings parses config text for its settings, as Extractor.Settings
+func (parse terminalParser) settings(src, file string, line int) []Setting {
+	return parse(src, file, line).settings
+}
+
+// set records a setting, replacing an earlier one of the same key
+func (t *terminalConfig) set(key string, value any, file string, line int) {
+	for i, s := range t.settings {
+		if s.Key == key {
+			t.settings = append(t.settings[:i:i], t.settings[i+1:]...)
+			break
+		}
+	}
+	t.settings = append(t.settings, Setting{Key: key, Value: value, File: file, Line: line})
+}
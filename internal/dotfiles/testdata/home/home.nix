@@ -0,0 +1,94 @@
+{ config, lib, pkgs, ... }:
+
+{
+  programs.tmux = {
+    enable = true;
+    shortcut = "a";
+    extraConfig = ''
+      unbind C-a
+      bind -r H resize-pane -L 5
+    '';
+  };
+
+  programs.neovim = {
+    enable = true;
+    extraLuaConfig = ''
+      vim.g.mapleader = ","
+      vim.keymap.set("n", "<leader>w", "<cmd>w<cr>", { desc = "Save" })
+    '';
+  };
+
+  wayland.windowManager.sway = {
+    enable = true;
+    config = {
+      modifier = "Mod4";
+      terminal = "foot";
+      keybindings = lib.mkOptionDefault {
+        "${modifier}+Return" = "exec ${config.wayland.windowManager.sway.config.terminal}";
+        "${modifier}+Shift+e" = null;
+      };
+      modes.resize = {
+        Escape = "mode default";
+      };
+    };
+    extraConfig = ''
+      bindsym XF86AudioMute exec wpctl set-mute @DEFAULT_AUDIO_SINK@ toggle
+    '';
+  };
+
+  wayland.windowManager.hyprland = {
+    enable = true;
+    settings = {
+      "$mod" = "SUPER";
+      bind = [
+        "$mod, F, exec, firefox"
+        "$mod SHIFT, Q, killactive,"
+      ];
+    };
+  };
+
+  home.shellAliases.g = "git";
+  home.sessionVariables.EDITOR = "nvim";
+
+  programs.zsh = {
+    enable = true;
+    shellAliases = {
+      gl = "git log --oneline";
+    };
+    oh-my-zsh = {
+      enable = true;
+      plugins = [ "git" "sudo" ];
+    };
+    initContent = ''
+      bindkey '^ ' autosuggest-accept
+      take() { mkdir -p "$1" && cd "$1"; }
+    '';
+  };
+
+  programs.fish = {
+    enable = true;
+    functions = {
+      gcm = "git commit -m $argv";
+      fish_greeting = {
+        description = "Greeting";
+        body = "";
+      };
+    };
+    shellAbbrs.gco = "git checkout";
+  };
+
+  programs.kitty = {
+    enable = true;
+    settings.kitty_mod = "ctrl+alt";
+    keybindings = {
+      "kitty_mod+enter" = "new_window_with_cwd";
+    };
+  };
+
+  programs.alacritty = {
+    enable = true;
+    settings.keyboard.bindings = [
+      { key = "N"; mods = "Control|Shift"; action = "SpawnNewInstance"; }
+    ];
+  };
+}
@@ -0,0 +1,11 @@
+set -gx EDITOR nvim
+
+if status is-interactive
+    abbr -a gco git checkout
+    alias ls 'eza --icons'
+end
+
+function fish_user_key_bindings
+    bind \cf forward-char
+    bind -M insert \ce end-of-line
+end
@@ -0,0 +1,3 @@
+vim.g.mapleader = " "
+
+require("lazy").setup("plugins")
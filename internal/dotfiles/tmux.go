@@ -0,0 +1,416 @@
+package dotfiles
+
+import (
+	"path"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+func init() {
+	Register(Extractor{Tool: "tmux", Match: isTmuxConfig, Parse: parseTmux, Nix: tmuxNix})
+}
+
+// tmuxPrefixCommand is what pressing a prefix key does
+const tmuxPrefixCommand = "switch-client -T prefix"
+
+// isTmuxConfig matches ~/.tmux.conf, ~/.config/tmux/tmux.conf and the local
+// overrides of oh-my-tmux, wherever they are kept in the repository
+func isTmuxConfig(rel string) bool {
+	switch path.Base(rel) {
+	case ".tmux.conf", "tmux.conf", ".tmux.conf.local":
+		return true
+	}
+	return false
+}
+
+// tmuxLine is one command line of a tmux config after joining continuations
+type tmuxLine struct {
+	text string
+	line int
+}
+
+// tmuxWord is one argument of a tmux command
+type tmuxWord struct {
+	text       string // Unquoted and unescaped
+	start, end int    // Offsets in the line
+	sep        bool   // An unescaped ";" ending a command
+}
+
+// parseTmux reads the prefix keys and bindings of a tmux config. Later
+// bindings of a key replace earlier ones, and unbind removes them.
+func parseTmux(src, file string, line int) []Keybind {
+	return applyTmux(nil, src, file, line)
+}
+
+// applyTmux applies the commands of a tmux config to binds
+func applyTmux(binds []Keybind, src, file string, line int) []Keybind {
+	for _, l := range tmuxLines(src, line) {
+		words := tmuxWords(l.text)
+		for len(words) > 0 {
+			n := 0
+			for n < len(words) && !words[n].sep {
+				n++
+			}
+			end := len(l.text)
+			if n < len(words) {
+				end = words[n].start
+			}
+			if n > 0 {
+				binds = tmuxCommand(binds, words[:n], l.text[:end], file, l.line)
+			}
+			if n == len(words) {
+				break
+			}
+			words = words[n+1:]
+		}
+	}
+
+	return binds
+}
+
+// tmuxCommand applies one command to the bindings read so far
+func tmuxCommand(binds []Keybind, words []tmuxWord, text, file string, line int) []Keybind {
+	switch words[0].text {
+	case "set", "set-option":
+		option, value := tmuxOption(words[1:])
+		description := ""
+		switch option {
+		case "prefix":
+			description = "Prefix key"
+		case "prefix2":
+			description = "Secondary prefix key"
+		default:
+			return binds
+		}
+		binds = removeBinds(binds, func(b Keybind) bool {
+			return b.Command == tmuxPrefixCommand && b.Description == description
+		})
+		if value == "" || value == "None" {
+			return binds
+		}
+		return append(binds, Keybind{
+			Key:         value,
+			Command:     tmuxPrefixCommand,
+			Description: description,
+			Mode:        "root",
+			File:        file,
+			Line:        line,
+		})
+
+	case "bind", "bind-key":
+		table, repeat, note := "prefix", false, ""
+		i := 1
+		for ; i < len(words) && isTmuxFlag(words[i].text); i++ {
+			for _, flag := range words[i].text[1:] {
+				switch flag {
+				case 'n':
+					table = "root"
+				case 'r':
+					repeat = true
+				case 'T', 'N':
+					if i+1 < len(words) {
+						i++
+						if flag == 'T' {
+							table = words[i].text
+						} else {
+							note = words[i].text
+						}
+					}
+				}
+			}
+		}
+		if i >= len(words) {
+			return binds
+		}
+		key := words[i]
+		command := tmuxCommandText(text[key.end:])
+		if command == "" {
+			return binds
+		}
+
+		description := note
+		if repeat {
+			if description == "" {
+				description = "Repeatable"
+			} else {
+				description += " (repeatable)"
+			}
+		}
+
+		return replaceBind(binds, Keybind{
+			Key:         key.text,
+			Command:     command,
+			Description: description,
+			Mode:        table,
+			File:        file,
+			Line:        line,
+		})
+
+	case "unbind", "unbind-key":
+		table, all := "prefix", false
+		i := 1
+		for ; i < len(words) && isTmuxFlag(words[i].text); i++ {
+			for _, flag := range words[i].text[1:] {
+				switch flag {
+				case 'a':
+					all = true
+				case 'n':
+					table = "root"
+				case 'T':
+					if i+1 < len(words) {
+						i++
+						table = words[i].text
+					}
+				}
+			}
+		}
+		if all {
+			return removeBinds(binds, func(b Keybind) bool { return b.Mode == table && b.Command != tmuxPrefixCommand })
+		}
+		if i < len(words) {
+			key := words[i].text
+			return removeBinds(binds, func(b Keybind) bool { return b.Key == key && b.Mode == table })
+		}
+	}
+
+	return binds
+}
+
+// tmuxOption returns the option name and value of set-option arguments, or
+// nothing when the option is being unset
+func tmuxOption(args []tmuxWord) (option, value string) {
+	i := 0
+	for ; i < len(args) && isTmuxFlag(args[i].text); i++ {
+		flags := args[i].text[1:]
+		if strings.Contains(flags, "u") {
+			return "", ""
+		}
+		if strings.Contains(flags, "t") {
+			i++ // Target session or window
+		}
+	}
+	if i < len(args) {
+		option = args[i].text
+	}
+	if i+1 < len(args) {
+		value = args[i+1].text
+	}
+	return option, value
+}
+
+// isTmuxFlag reports whether an argument is a flag rather than a key such as "-"
+func isTmuxFlag(word string) bool {
+	return len(word) > 1 && word[0] == '-'
+}
+
+// tmuxCommandText returns a bound command as written, with the lines of a
+// { } block joined into one
+func tmuxCommandText(text string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			prev := sb.String()
+			if strings.HasSuffix(prev, "{") || strings.HasPrefix(line, "}") {
+				sb.WriteString(" ")
+			} else {
+				sb.WriteString(" ; ")
+			}
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// tmuxLines splits a config into command lines, dropping comments and
+// %if directives and joining lines ended by a backslash or inside a { } block.
+// Each line records where it starts, counting the first line of src as first.
+func tmuxLines(src string, first int) []tmuxLine {
+	var lines []tmuxLine
+	var cur strings.Builder
+	start, depth, open := first, 0, false
+
+	flush := func() {
+		if text := strings.TrimSpace(cur.String()); text != "" && !strings.HasPrefix(text, "%") {
+			lines = append(lines, tmuxLine{text: text, line: start})
+		}
+		cur.Reset()
+		depth = 0
+	}
+
+	joiner := byte(' ')
+	for i, raw := range strings.Split(src, "\n") {
+		text := stripTmuxComment(strings.TrimRight(raw, "\r"))
+		if open {
+			cur.WriteByte(joiner)
+			if joiner == ' ' {
+				text = strings.TrimLeft(text, " \t")
+			}
+		} else {
+			start = first + i
+		}
+
+		continued := strings.HasSuffix(text, `\`) && !strings.HasSuffix(text, `\\`)
+		if continued {
+			text = strings.TrimRight(text[:len(text)-1], " \t")
+		}
+		cur.WriteString(text)
+
+		for _, field := range strings.Fields(text) {
+			switch {
+			case field == "{":
+				depth++
+			case field == "}" && depth > 0:
+				depth--
+			}
+		}
+
+		open = continued || depth > 0
+		if continued {
+			joiner = ' '
+		} else {
+			joiner = '\n'
+		}
+		if !open {
+			flush()
+		}
+	}
+	if open {
+		flush()
+	}
+
+	return lines
+}
+
+// stripTmuxComment removes a # comment, which starts a word outside quotes
+func stripTmuxComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\\':
+			i++
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tmuxWords splits a command line into arguments, removing quotes and
+// escapes. An unescaped ";" at the end of a word becomes a separator.
+func tmuxWords(text string) []tmuxWord {
+	var words []tmuxWord
+
+	i := 0
+	for i < len(text) {
+		if isTmuxSpace(text[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		var sb strings.Builder
+		for i < len(text) && !isTmuxSpace(text[i]) {
+			c := text[i]
+			if c == ';' && (i+1 == len(text) || isTmuxSpace(text[i+1])) {
+				break
+			}
+			switch c {
+			case '\\':
+				if i+1 < len(text) {
+					sb.WriteByte(text[i+1])
+				}
+				i += 2
+				continue
+			case '"', '\'':
+				i++
+				for i < len(text) && text[i] != c {
+					if c == '"' && text[i] == '\\' && i+1 < len(text) {
+						i++
+					}
+					sb.WriteByte(text[i])
+					i++
+				}
+				if i < len(text) {
+					i++ // Closing quote
+				}
+				continue
+			}
+			sb.WriteByte(c)
+			i++
+		}
+		if i > len(text) {
+			i = len(text)
+		}
+
+		if i > start {
+			words = append(words, tmuxWord{text: sb.String(), start: start, end: i})
+		}
+		if i < len(text) && text[i] == ';' {
+			words = append(words, tmuxWord{text: ";", start: i, end: i + 1, sep: true})
+			i++
+		}
+	}
+
+	return words
+}
+
+// isTmuxSpace reports whether c separates words
+func isTmuxSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// tmuxNix reads the tmux settings of a home-manager or NixOS configuration:
+// the prefix or shortcut options and the bindings in extraConfig, which
+// home-manager writes after the bindings it generates
+func tmuxNix(cfg *nix.Config) []Keybind {
+	program := cfg.Programs()["tmux"]
+	if program == nil {
+		return nil
+	}
+
+	var binds []Keybind
+	if prefix, ok := program.Settings["prefix"].(string); ok {
+		src := program.Sources["prefix"]
+		binds = append(binds, Keybind{
+			Key:         prefix,
+			Command:     tmuxPrefixCommand,
+			Description: "Prefix key",
+			Mode:        "root",
+			File:        src.File,
+			Line:        src.Line,
+		})
+	} else if shortcut, ok := program.Settings["shortcut"].(string); ok {
+		// The prefix becomes C-<shortcut>; pressing the shortcut again sends it
+		src := program.Sources["shortcut"]
+		binds = append(binds,
+			Keybind{Key: "C-" + shortcut, Command: tmuxPrefixCommand, Description: "Prefix key", Mode: "root", File: src.File, Line: src.Line},
+			Keybind{Key: shortcut, Command: "send-prefix", Mode: "prefix", File: src.File, Line: src.Line},
+			Keybind{Key: "C-" + shortcut, Command: "last-window", Mode: "prefix", File: src.File, Line: src.Line},
+		)
+	}
+
+	if extra, ok := program.Settings["extraConfig"].(string); ok {
+		src := program.Sources["extraConfig"]
+		line := src.Line
+		if line == 0 {
+			line = 1
+		}
+		binds = applyTmux(binds, extra, src.File, line)
+	}
+
+	return binds
+}
@@ -0,0 +1,74 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTmux(t *testing.T) {
+	src := `# ~/.tmux.conf
+set-option -g prefix C-a
+set -g prefix2 None
+unbind C-b
+bind-key C-a send-prefix
+
+bind '"' split-window -v -c "#{pane_current_path}"  # keep the cwd
+bind - split-window -v
+bind -n M-Left select-pane -L
+bind -r -N "Grow the pane" J resize-pane -D 5
+bind -T copy-mode-vi v send-keys -X begin-selection
+bind r source-file ~/.tmux.conf \; \
+    display "Reloaded"
+bind x {
+  kill-pane
+}
+bind k kill-window
+unbind k
+bind q kill-session; set -g mouse on
+`
+
+	want := []Keybind{
+		{Key: "C-a", Command: tmuxPrefixCommand, Description: "Prefix key", Mode: "root", Line: 2},
+		{Key: "C-a", Command: "send-prefix", Mode: "prefix", Line: 5},
+		{Key: `"`, Command: `split-window -v -c "#{pane_current_path}"`, Mode: "prefix", Line: 7},
+		{Key: "-", Command: "split-window -v", Mode: "prefix", Line: 8},
+		{Key: "M-Left", Command: "select-pane -L", Mode: "root", Line: 9},
+		{Key: "J", Command: "resize-pane -D 5", Description: "Grow the pane (repeatable)", Mode: "prefix", Line: 10},
+		{Key: "v", Command: "send-keys -X begin-selection", Mode: "copy-mode-vi", Line: 11},
+		{Key: "r", Command: `source-file ~/.tmux.conf \; display "Reloaded"`, Mode: "prefix", Line: 12},
+		{Key: "x", Command: "{ kill-pane }", Mode: "prefix", Line: 14},
+		{Key: "q", Command: "kill-session", Mode: "prefix", Line: 19},
+	}
+	got := parseTmux(src, "", 1)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTmux() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseTmuxRebind(t *testing.T) {
+	src := "bind c new-window\nset -g prefix C-b\nbind c new-window -c '#{pane_current_path}'\nset -g prefix C-Space\nunbind -a\n"
+
+	got := parseTmux(src, "tmux.conf", 10)
+	want := []Keybind{
+		{Key: "C-Space", Command: tmuxPrefixCommand, Description: "Prefix key", Mode: "root", File: "tmux.conf", Line: 13},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTmux() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTmuxWords(t *testing.T) {
+	words := tmuxWords(`bind \; command-prompt "a b" 'c\d'; next`)
+
+	var texts []string
+	for _, w := range words {
+		texts = append(texts, w.text)
+	}
+	want := []string{"bind", ";", "command-prompt", "a b", `c\d`, ";", "next"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Fatalf("tmuxWords() = %q, want %q", texts, want)
+	}
+	if words[1].sep || !words[5].sep {
+		t.Errorf("Expected only the unescaped ; to be a separator: %+v", words)
+	}
+}
@@ -0,0 +1,410 @@
+package dotfiles
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file holds a small TOML reader, enough for terminal configs such as
+// alacritty.toml. It reads tables, arrays of tables, dotted keys, and string,
+// number, boolean, array and inline table values; dates are kept as strings.
+// Malformed lines are skipped rather than failing the file.
+
+// docValue is a value read from a TOML or YAML config, with the line it
+// starts on. value is a string, int64, float64, bool, []docValue or
+// map[string]docValue.
+type docValue struct {
+	value any
+	line  int
+}
+
+// get returns the value at a path of table keys
+func (v docValue) get(path ...string) (docValue, bool) {
+	for _, key := range path {
+		table, ok := v.value.(map[string]docValue)
+		if !ok {
+			return docValue{}, false
+		}
+		if v, ok = table[key]; !ok {
+			return docValue{}, false
+		}
+	}
+	return v, true
+}
+
+// str returns the string at key of a table, or ""
+func (v docValue) str(key string) string {
+	field, _ := v.get(key)
+	s, _ := field.value.(string)
+	return s
+}
+
+// list returns the items of an array, or nil
+func (v docValue) list() []docValue {
+	items, _ := v.value.([]docValue)
+	return items
+}
+
+// plain returns the value without lines, with arrays as []any and tables as
+// map[string]any
+func (v docValue) plain() any {
+	switch value := v.value.(type) {
+	case []docValue:
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = item.plain()
+		}
+		return items
+	case map[string]docValue:
+		table := make(map[string]any, len(value))
+		for key, item := range value {
+			table[key] = item.plain()
+		}
+		return table
+	}
+	return v.value
+}
+
+// tomlReader reads TOML source from pos, counting lines
+type tomlReader struct {
+	src  string
+	pos  int
+	line int
+}
+
+// parseTOML reads TOML source whose first line is numbered first into a table
+func parseTOML(src string, first int) docValue {
+	r := &tomlReader{src: src, line: first}
+	root := docValue{value: make(map[string]docValue), line: first}
+	table := root.value.(map[string]docValue)
+
+	for {
+		r.skipBlank(true)
+		if r.pos >= len(r.src) {
+			return root
+		}
+
+		line := r.line
+		switch {
+		case strings.HasPrefix(r.src[r.pos:], "[["):
+			r.pos += 2
+			path := r.keys()
+			if !r.consume("]]") || len(path) == 0 {
+				r.skipLine()
+				continue
+			}
+			parent := tomlTable(root.value.(map[string]docValue), path[:len(path)-1], line)
+			last := path[len(path)-1]
+			array, ok := parent[last]
+			if !ok {
+				array.line = line
+			}
+			items, _ := array.value.([]docValue)
+			table = make(map[string]docValue)
+			parent[last] = docValue{value: append(items, docValue{value: table, line: line}), line: array.line}
+
+		case r.src[r.pos] == '[':
+			r.pos++
+			path := r.keys()
+			if !r.consume("]") || len(path) == 0 {
+				r.skipLine()
+				continue
+			}
+			table = tomlTable(root.value.(map[string]docValue), path, line)
+
+		default:
+			path := r.keys()
+			r.skipBlank(false)
+			if len(path) == 0 || !r.consume("=") {
+				r.skipLine()
+				continue
+			}
+			value, ok := r.value()
+			if !ok {
+				r.skipLine()
+				continue
+			}
+			tomlTable(table, path[:len(path)-1], line)[path[len(path)-1]] = value
+		}
+		r.skipLine()
+	}
+}
+
+// tomlTable returns the table at path below table, creating missing tables
+// at line. A key holding an array of tables refers to its last table.
+func tomlTable(table map[string]docValue, path []string, line int) map[string]docValue {
+	for _, key := range path {
+		v, ok := table[key]
+		if items, isArray := v.value.([]docValue); isArray && len(items) > 0 {
+			v = items[len(items)-1]
+		}
+		next, isTable := v.value.(map[string]docValue)
+		if !ok || !isTable {
+			next = make(map[string]docValue)
+			table[key] = docValue{value: next, line: line}
+		}
+		table = next
+	}
+	return table
+}
+
+// skipBlank skips spaces and comments, and newlines too if newlines is set
+func (r *tomlReader) skipBlank(newlines bool) {
+	for r.pos < len(r.src) {
+		switch c := r.src[r.pos]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			r.pos++
+		case c == '\n' && newlines:
+			r.pos++
+			r.line++
+		case c == '#':
+			for r.pos < len(r.src) && r.src[r.pos] != '\n' {
+				r.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipLine skips the rest of the current line
+func (r *tomlReader) skipLine() {
+	for r.pos < len(r.src) && r.src[r.pos] != '\n' {
+		r.pos++
+	}
+}
+
+// consume skips s if the source continues with it
+func (r *tomlReader) consume(s string) bool {
+	r.skipBlank(false)
+	if strings.HasPrefix(r.src[r.pos:], s) {
+		r.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// keys reads a dotted key of bare and quoted parts
+func (r *tomlReader) keys() []string {
+	var path []string
+	for {
+		r.skipBlank(false)
+		if r.pos >= len(r.src) {
+			return nil
+		}
+
+		var key string
+		switch c := r.src[r.pos]; {
+		case c == '"' || c == '\'':
+			s, ok := r.string()
+			if !ok {
+				return nil
+			}
+			key = s
+		default:
+			start := r.pos
+			for r.pos < len(r.src) && isTOMLBareChar(r.src[r.pos]) {
+				r.pos++
+			}
+			if r.pos == start {
+				return nil
+			}
+			key = r.src[start:r.pos]
+		}
+		path = append(path, key)
+
+		if !r.consume(".") {
+			return path
+		}
+	}
+}
+
+// isTOMLBareChar reports whether c can be part of a bare key
+func isTOMLBareChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value reads a value, reporting whether there was one
+func (r *tomlReader) value() (docValue, bool) {
+	r.skipBlank(false)
+	if r.pos >= len(r.src) {
+		return docValue{}, false
+	}
+	line := r.line
+
+	switch r.src[r.pos] {
+	case '"', '\'':
+		s, ok := r.string()
+		return docValue{value: s, line: line}, ok
+
+	case '[':
+		r.pos++
+		var items []docValue
+		for {
+			r.skipBlank(true)
+			if r.pos >= len(r.src) {
+				return docValue{}, false
+			}
+			if r.src[r.pos] == ']' {
+				r.pos++
+				return docValue{value: items, line: line}, true
+			}
+			item, ok := r.value()
+			if !ok {
+				return docValue{}, false
+			}
+			items = append(items, item)
+			r.skipBlank(true)
+			r.consume(",")
+		}
+
+	case '{':
+		r.pos++
+		table := make(map[string]docValue)
+		for {
+			r.skipBlank(true)
+			if r.consume("}") {
+				return docValue{value: table, line: line}, true
+			}
+			itemLine := r.line
+			path := r.keys()
+			if len(path) == 0 || !r.consume("=") {
+				return docValue{}, false
+			}
+			item, ok := r.value()
+			if !ok {
+				return docValue{}, false
+			}
+			tomlTable(table, path[:len(path)-1], itemLine)[path[len(path)-1]] = item
+			r.skipBlank(true)
+			r.consume(",")
+		}
+	}
+
+	start := r.pos
+	for r.pos < len(r.src) && !strings.ContainsRune(",]}#\n\r", rune(r.src[r.pos])) {
+		r.pos++
+	}
+	text := strings.TrimSpace(r.src[start:r.pos])
+	if text == "" {
+		return docValue{}, false
+	}
+	return docValue{value: tomlScalar(text), line: line}, true
+}
+
+// tomlScalar converts a bare value: a boolean, an integer, a float, or
+// anything else, such as a date, as its text
+func tomlScalar(text string) any {
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	digits := strings.ReplaceAll(text, "_", "")
+	if n, err := strconv.ParseInt(digits, 0, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(digits, 64); err == nil {
+		return f
+	}
+	return text
+}
+
+// string reads a basic, literal or multi-line string
+func (r *tomlReader) string() (string, bool) {
+	quote := r.src[r.pos : r.pos+1]
+	multi := strings.HasPrefix(r.src[r.pos:], strings.Repeat(quote, 3))
+	if multi {
+		r.pos += 3
+		// A newline right after the opening quotes is not part of the string
+		if strings.HasPrefix(r.src[r.pos:], "\r\n") {
+			r.pos += 2
+			r.line++
+		} else if strings.HasPrefix(r.src[r.pos:], "\n") {
+			r.pos++
+			r.line++
+		}
+	} else {
+		r.pos++
+	}
+
+	var b strings.Builder
+	for r.pos < len(r.src) {
+		c := r.src[r.pos]
+		switch {
+		case multi && strings.HasPrefix(r.src[r.pos:], strings.Repeat(quote, 3)):
+			r.pos += 3
+			return b.String(), true
+		case !multi && c == quote[0]:
+			r.pos++
+			return b.String(), true
+		case c == '\n' && !multi:
+			return "", false
+		case c == '\\' && quote == `"`:
+			r.escape(&b, multi)
+			continue
+		case c == '\n':
+			r.line++
+		}
+		b.WriteByte(c)
+		r.pos++
+	}
+	return "", false
+}
+
+// escape decodes the escape sequence at pos in a basic string. In a
+// multi-line string a backslash at the end of a line trims the whitespace
+// that follows.
+func (r *tomlReader) escape(b *strings.Builder, multi bool) {
+	r.pos++
+	if r.pos >= len(r.src) {
+		return
+	}
+
+	c := r.src[r.pos]
+	r.pos++
+	switch c {
+	case 'n':
+		b.WriteByte('\n')
+	case 't':
+		b.WriteByte('\t')
+	case 'r':
+		b.WriteByte('\r')
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'e':
+		b.WriteByte('\x1b')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if r.pos+size <= len(r.src) {
+			if n, err := strconv.ParseUint(r.src[r.pos:r.pos+size], 16, 32); err == nil && utf8.ValidRune(rune(n)) {
+				b.WriteRune(rune(n))
+				r.pos += size
+				return
+			}
+		}
+		b.WriteByte('\\')
+		b.WriteByte(c)
+	case ' ', '\t', '\r', '\n':
+		if !multi {
+			b.WriteByte(c)
+			return
+		}
+		r.pos--
+		for r.pos < len(r.src) && strings.ContainsRune(" \t\r\n", rune(r.src[r.pos])) {
+			if r.src[r.pos] == '\n' {
+				r.line++
+			}
+			r.pos++
+		}
+	default:
+		b.WriteByte(c) // \" and \\
+	}
+}
@@ -0,0 +1,80 @@
+package dotfiles
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	src := `# comment
+title = 'C:\path' # trailing comment
+"quoted key".x = 0x10
+window.dimensions = { columns = 80, lines = 24 }
+list = [
+  "a", # first
+  'b',
+]
+text = """
+one \
+    two"""
+raw = '''
+x\n'''
+
+[server]
+enabled = true
+ratio = 1_000.5
+when = 1979-05-27
+
+[[server.hosts]]
+name = "a\tb"
+
+[[server.hosts]]
+name = "\u00e9"
+
+[server.hosts.extra]
+port = 22
+`
+
+	got := parseTOML(src, 1)
+	want := map[string]any{
+		"title":      `C:\path`,
+		"quoted key": map[string]any{"x": int64(16)},
+		"window":     map[string]any{"dimensions": map[string]any{"columns": int64(80), "lines": int64(24)}},
+		"list":       []any{"a", "b"},
+		"text":       "one two",
+		"raw":        `x\n`,
+		"server": map[string]any{
+			"enabled": true,
+			"ratio":   1000.5,
+			"when":    "1979-05-27",
+			"hosts": []any{
+				map[string]any{"name": "a\tb"},
+				map[string]any{"name": "é", "extra": map[string]any{"port": int64(22)}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got.plain(), want) {
+		t.Errorf("parseTOML() =\n%#v\nwant\n%#v", got.plain(), want)
+	}
+
+	for path, line := range map[string]int{"list": 5, "raw": 12, "server": 15, "server.enabled": 16} {
+		if v, _ := got.get(strings.Split(path, ".")...); v.line != line {
+			t.Errorf("Expected %s on line %d, got %d", path, line, v.line)
+		}
+	}
+	hosts, _ := got.get("server", "hosts")
+	if items := hosts.list(); len(items) != 2 || items[1].line != 23 {
+		t.Errorf("Expected the second host table on line 23, got %+v", items)
+	}
+}
+
+func TestParseTOMLSkipsMalformedLines(t *testing.T) {
+	got := parseTOML("a = \nb = [1, 2\nc = 3", 1)
+	if _, ok := got.get("a"); ok {
+		t.Errorf("Expected a key without a value to be skipped, got %+v", got)
+	}
+	if _, ok := got.get("b"); ok {
+		t.Errorf("Expected an unterminated array to be skipped, got %+v", got)
+	}
+}
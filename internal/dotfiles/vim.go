@@ -0,0 +1,733 @@
+package dotfiles
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+func init() {
+	Register(Extractor{Tool: "neovim", Match: isNeovimConfig, Parse: parseVimConfig, Nix: neovimNix, Resolve: resolveLeader})
+	Register(Extractor{Tool: "vim", Match: isVimConfig, Parse: parseVimConfig, Nix: vimNix, Resolve: resolveLeader})
+}
+
+// The leader keys are recorded as bindings with these commands, so they can
+// be resolved once every file is read
+const (
+	vimLeaderCommand      = "<leader>"
+	vimLocalLeaderCommand = "<localleader>"
+)
+
+// vimDefaultLeader is the leader when mapleader is not set
+const vimDefaultLeader = `\`
+
+// vimMaxCommand is the longest Lua expression kept as a bound command
+const vimMaxCommand = 80
+
+// vimPluginDirs hold installed plugins rather than the user's own mappings
+var vimPluginDirs = map[string]bool{"pack": true, "bundle": true, "plugged": true}
+
+// isNeovimConfig matches Lua and Vimscript files in an nvim directory
+func isNeovimConfig(rel string) bool {
+	if ext := path.Ext(rel); ext != ".lua" && ext != ".vim" {
+		return false
+	}
+	nvim := false
+	for _, dir := range strings.Split(path.Dir(rel), "/") {
+		if vimPluginDirs[dir] {
+			return false
+		}
+		nvim = nvim || dir == "nvim"
+	}
+	return nvim
+}
+
+// isVimConfig matches vimrc files and Vimscript in a .vim directory
+func isVimConfig(rel string) bool {
+	switch path.Base(rel) {
+	case ".vimrc", "vimrc", "_vimrc", ".gvimrc", "gvimrc":
+		return true
+	}
+	if path.Ext(rel) != ".vim" {
+		return false
+	}
+	vim := false
+	for _, dir := range strings.Split(path.Dir(rel), "/") {
+		if vimPluginDirs[dir] {
+			return false
+		}
+		vim = vim || dir == ".vim" || dir == "vimfiles"
+	}
+	return vim
+}
+
+// parseVimConfig reads the mappings of a Lua or Vimscript config file
+func parseVimConfig(src, file string, line int) []Keybind {
+	if path.Ext(file) == ".lua" {
+		return parseVimLua(src, file, line)
+	}
+	return parseVimscript(src, file, line)
+}
+
+// vimModes returns the modes a map command applies to, e.g. "n" for
+// nnoremap or "n,v,o" for map; bang is set for map!
+func vimModes(mode string, bang bool) string {
+	switch {
+	case mode == "" && bang, mode == "!":
+		return "i,c"
+	case mode == "":
+		return "n,v,o"
+	}
+	return mode
+}
+
+// vimLeaderKey returns a leader in key notation, e.g. "<Space>" for " "
+func vimLeaderKey(value string) string {
+	if value == " " || strings.EqualFold(value, "<space>") {
+		return "<Space>"
+	}
+	return value
+}
+
+// vimLeaderBind records a mapleader or maplocalleader setting
+func vimLeaderBind(variable, value, file string, line int) Keybind {
+	b := Keybind{Key: vimLeaderKey(value), Command: vimLeaderCommand, Description: "Leader key", File: file, Line: line}
+	if variable == "maplocalleader" {
+		b.Command, b.Description = vimLocalLeaderCommand, "Local leader key"
+	}
+	return b
+}
+
+var (
+	vimLeaderRe      = regexp.MustCompile(`(?i)<leader>`)
+	vimLocalLeaderRe = regexp.MustCompile(`(?i)<localleader>`)
+)
+
+// resolveLeader replaces <leader> and <localleader> in mapped keys with the
+// leader keys set in any file, or the default backslash
+func resolveLeader(binds []Keybind) []Keybind {
+	leader, localLeader := vimDefaultLeader, vimDefaultLeader
+	for _, b := range binds {
+		switch b.Command {
+		case vimLeaderCommand:
+			leader = b.Key
+		case vimLocalLeaderCommand:
+			localLeader = b.Key
+		}
+	}
+
+	for i, b := range binds {
+		if b.Command == vimLeaderCommand || b.Command == vimLocalLeaderCommand {
+			continue
+		}
+		key := vimLeaderRe.ReplaceAllLiteralString(b.Key, leader)
+		binds[i].Key = vimLocalLeaderRe.ReplaceAllLiteralString(key, localLeader)
+	}
+	return binds
+}
+
+// Vimscript
+
+// vimMapRe matches the map command family: map, nmap, nnoremap, vmap, map!, ...
+var vimMapRe = regexp.MustCompile(`^([nvxsoilct]?)(?:nore)?map(!?)$`)
+
+// vimUnmapRe matches the unmap command family
+var vimUnmapRe = regexp.MustCompile(`^([nvxsoilct]?)unmap(!?)$`)
+
+// vimMapAbbreviations are the common short forms of map commands
+var vimMapAbbreviations = map[string]string{
+	"no": "noremap", "nor": "noremap",
+	"nn": "nnoremap", "nno": "nnoremap", "nnor": "nnoremap", "nnore": "nnoremap",
+	"vn": "vnoremap", "vno": "vnoremap", "xn": "xnoremap", "xno": "xnoremap",
+	"ino": "inoremap", "inor": "inoremap", "cno": "cnoremap", "ono": "onoremap", "tno": "tnoremap",
+	"nm": "nmap", "vm": "vmap", "xm": "xmap", "im": "imap", "cm": "cmap", "om": "omap", "tma": "tmap",
+}
+
+// vimMapArguments may precede the keys of a mapping
+var vimMapArguments = []string{"<buffer>", "<nowait>", "<silent>", "<special>", "<script>", "<expr>", "<unique>"}
+
+// parseVimscript reads mappings and leader settings from Vimscript, including
+// Lua given to :lua and lua << EOF blocks
+func parseVimscript(src, file string, line int) []Keybind {
+	var binds []Keybind
+
+	lines := strings.Split(src, "\n")
+	for i := 0; i < len(lines); i++ {
+		start := line + i
+		text := strings.TrimSpace(lines[i])
+		for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), `\`) {
+			i++
+			text += strings.TrimPrefix(strings.TrimSpace(lines[i]), `\`)
+		}
+		text = strings.TrimLeft(text, ": \t")
+		if text == "" || strings.HasPrefix(text, `"`) {
+			continue
+		}
+
+		if marker, ok := vimLuaHeredoc(text); ok {
+			j := i + 1
+			for j < len(lines) && strings.TrimSpace(lines[j]) != marker {
+				j++
+			}
+			body := strings.Join(lines[i+1:min(j, len(lines))], "\n")
+			binds = append(binds, parseVimLua(body, file, line+i+1)...)
+			i = j
+			continue
+		}
+		if rest, ok := strings.CutPrefix(text, "lua "); ok {
+			binds = append(binds, parseVimLua(rest, file, start)...)
+			continue
+		}
+
+		binds = vimCommand(binds, text, file, start)
+	}
+
+	return binds
+}
+
+// vimLuaHeredoc returns the end marker of a lua << [trim] EOF line
+func vimLuaHeredoc(text string) (string, bool) {
+	rest, ok := strings.CutPrefix(text, "lua")
+	if !ok {
+		return "", false
+	}
+	rest, ok = strings.CutPrefix(strings.TrimSpace(rest), "<<")
+	if !ok {
+		return "", false
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) > 0 && fields[0] == "trim" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return ".", true
+	}
+	return fields[0], true
+}
+
+// vimCommand applies one Vimscript command to the mappings read so far
+func vimCommand(binds []Keybind, text, file string, line int) []Keybind {
+	name, args := text, ""
+	if i := strings.IndexAny(text, " \t"); i >= 0 {
+		name, args = text[:i], strings.TrimSpace(text[i:])
+	}
+
+	if name == "let" {
+		variable, value, ok := strings.Cut(args, "=")
+		variable = strings.TrimPrefix(strings.TrimSpace(variable), "g:")
+		if ok && (variable == "mapleader" || variable == "maplocalleader") {
+			binds = append(binds, vimLeaderBind(variable, vimStringValue(value), file, line))
+		}
+		return binds
+	}
+
+	if full, ok := vimMapAbbreviations[name]; ok {
+		name = full
+	}
+	if m := vimUnmapRe.FindStringSubmatch(name); m != nil {
+		key := strings.TrimSpace(vimMapArgs(args))
+		mode := vimModes(m[1], m[2] == "!")
+		return removeBinds(binds, func(b Keybind) bool { return b.Key == key && b.Mode == mode })
+	}
+	m := vimMapRe.FindStringSubmatch(name)
+	if m == nil {
+		return binds
+	}
+
+	args = vimMapArgs(args)
+	lhs, rhs := args, ""
+	if i := strings.IndexAny(args, " \t"); i >= 0 {
+		lhs, rhs = args[:i], strings.TrimSpace(args[i:])
+	}
+	rhs = vimCommandEnd(rhs)
+	if lhs == "" || rhs == "" {
+		return binds // Lists mappings rather than defining one
+	}
+
+	return replaceBind(binds, Keybind{Key: lhs, Command: rhs, Mode: vimModes(m[1], m[2] == "!"), File: file, Line: line})
+}
+
+// vimMapArgs skips the special arguments before a mapping's keys
+func vimMapArgs(args string) string {
+	for {
+		skipped := false
+		for _, arg := range vimMapArguments {
+			if len(args) >= len(arg) && strings.EqualFold(args[:len(arg)], arg) {
+				args = strings.TrimSpace(args[len(arg):])
+				skipped = true
+			}
+		}
+		if !skipped {
+			return args
+		}
+	}
+}
+
+// vimCommandEnd cuts a mapped command at an unescaped |, which starts the
+// next Vimscript command
+func vimCommandEnd(rhs string) string {
+	for i := 0; i < len(rhs); i++ {
+		switch rhs[i] {
+		case '\\', 0x16: // Backslash or CTRL-V escape the next character
+			i++
+		case '|':
+			return strings.TrimSpace(rhs[:i])
+		}
+	}
+	return rhs
+}
+
+// vimStringValue evaluates a quoted Vimscript string such as "\<Space>"
+func vimStringValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return value
+	}
+	switch {
+	case value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	case value[0] == '"' && value[len(value)-1] == '"':
+		inner := value[1 : len(value)-1]
+		if strings.EqualFold(inner, `\<space>`) {
+			return " "
+		}
+		return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(inner)
+	}
+	return value
+}
+
+// Lua
+
+// vimKeymapFuncs are the Lua functions that define mappings, with the
+// position of their mode argument; keys, command and options follow it
+var vimKeymapFuncs = map[string]int{
+	"vim.keymap.set":              0,
+	"vim.api.nvim_set_keymap":     0,
+	"vim.api.nvim_buf_set_keymap": 1,
+}
+
+// vimCommandFuncs run Vimscript given as a string
+var vimCommandFuncs = map[string]bool{
+	"vim.cmd":              true,
+	"vim.api.nvim_command": true,
+	"vim.api.nvim_exec":    true,
+	"vim.api.nvim_exec2":   true,
+}
+
+// vimWrapper is a function that passes its parameters on to a keymap
+// function, such as local function map(mode, lhs, rhs) vim.keymap.set(...) end
+type vimWrapper struct {
+	params   []string
+	defaults map[string]luaExpr // Parameters given a default in the body: mode = mode or "n"
+	call     *luaCall
+	offset   int
+}
+
+// vimLua reads the mappings of one Lua config
+type vimLua struct {
+	src      string
+	file     string
+	toks     []luaToken
+	aliases  map[string]string // local map = vim.keymap.set
+	wrappers map[string]*vimWrapper
+	binds    []Keybind
+}
+
+// parseVimLua reads mappings and leader settings from a Lua config: keymap
+// calls, lazy.nvim keys specs and Vimscript run through vim.cmd
+func parseVimLua(src, file string, line int) []Keybind {
+	l := &vimLua{
+		src:      src,
+		file:     file,
+		toks:     lexLua(src, line),
+		aliases:  make(map[string]string),
+		wrappers: make(map[string]*vimWrapper),
+	}
+	for i := range l.toks {
+		l.scan(i)
+	}
+	return l.binds
+}
+
+// startsExpr reports whether the name at token i starts an expression
+// rather than continuing one or naming a function being defined
+func (l *vimLua) startsExpr(i int) bool {
+	if l.toks[i].kind != luaNameToken || isLuaKeyword(l.toks[i].text) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	prev := l.toks[i-1]
+	return !(prev.kind == luaSymbol && (prev.text == "." || prev.text == ":")) && prev.text != "function"
+}
+
+// scan looks for a definition, assignment or call starting at token i
+func (l *vimLua) scan(i int) {
+	p := &luaParser{toks: l.toks, pos: i}
+
+	switch tok := l.toks[i]; {
+	case tok.kind == luaNameToken && tok.text == "local":
+		p.next()
+		if p.is("function") {
+			return // Scanned from the function keyword
+		}
+		name := p.next()
+		if name.kind != luaNameToken || !p.is("=") {
+			return
+		}
+		p.next()
+		if value := p.expr(); value != nil {
+			l.assign(name.text, value)
+		}
+
+	case tok.kind == luaNameToken && tok.text == "function":
+		p.next()
+		var name strings.Builder
+		for p.peek().kind == luaNameToken || p.is(".") {
+			name.WriteString(p.next().text)
+		}
+		p.pos = i + 1
+		if fn, ok := p.function(i).(*luaFunction); ok && name.Len() > 0 {
+			l.defineWrapper(name.String(), fn)
+		}
+
+	case l.startsExpr(i):
+		expr := p.suffixed()
+		if expr == nil {
+			return
+		}
+		if p.is("=") {
+			p.next()
+			if value := p.expr(); value != nil {
+				l.assign(luaPath(expr), value)
+			}
+			return
+		}
+		if call, ok := expr.(*luaCall); ok {
+			l.call(call)
+		}
+	}
+}
+
+// resolve expands an alias at the start of a dotted path
+func (l *vimLua) resolve(name string) string {
+	first, rest, _ := strings.Cut(name, ".")
+	if alias, ok := l.aliases[first]; ok {
+		if rest == "" {
+			return alias
+		}
+		return alias + "." + rest
+	}
+	return name
+}
+
+// assign handles an assignment or table field: leader settings, lazy.nvim
+// keys specs, aliases and wrapper functions
+func (l *vimLua) assign(target string, value luaExpr) {
+	switch target {
+	case "":
+		return
+	case "vim.g.mapleader", "vim.g.maplocalleader":
+		if s, ok := luaStringValue(value, nil); ok {
+			l.binds = append(l.binds, vimLeaderBind(strings.TrimPrefix(target, "vim.g."), s, l.file, value.span().line))
+		}
+		return
+	case "keys":
+		if t, ok := value.(*luaTable); ok {
+			l.lazyKeys(t)
+		}
+		return
+	}
+
+	switch v := value.(type) {
+	case *luaFunction:
+		l.defineWrapper(target, v)
+	case *luaName, *luaIndex:
+		if path := luaPath(v); path != "" && !strings.Contains(target, ".") {
+			l.aliases[target] = l.resolve(path)
+		}
+	}
+}
+
+// call handles a call to a keymap function, a wrapper or vim.cmd
+func (l *vimLua) call(call *luaCall) {
+	if call.method != "" {
+		return
+	}
+	name := l.resolve(luaPath(call.fn))
+
+	if offset, ok := vimKeymapFuncs[name]; ok {
+		l.keymap(call.args, offset, nil, call.line)
+		return
+	}
+
+	if vimCommandFuncs[name] && len(call.args) > 0 {
+		if s, ok := call.args[0].(*luaString); ok {
+			for _, b := range parseVimscript(s.value, l.file, s.valueLine) {
+				l.binds = replaceBind(l.binds, b)
+			}
+		}
+		return
+	}
+
+	if w := l.wrappers[name]; w != nil {
+		env := make(map[string]luaExpr, len(w.params))
+		for i, param := range w.params {
+			if i < len(call.args) {
+				env[param] = call.args[i]
+			} else if def, ok := w.defaults[param]; ok {
+				env[param] = def
+			}
+		}
+		l.keymap(w.call.args, w.offset, env, call.line)
+	}
+}
+
+// defineWrapper records fn as a wrapper if it passes a parameter on as the
+// mode or keys of a keymap call
+func (l *vimLua) defineWrapper(name string, fn *luaFunction) {
+	w := &vimWrapper{params: fn.params, defaults: make(map[string]luaExpr)}
+	isParam := func(expr luaExpr) bool {
+		n, ok := expr.(*luaName)
+		if !ok {
+			return false
+		}
+		for _, param := range fn.params {
+			if n.name == param {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := fn.bodyStart; i < fn.bodyEnd; i++ {
+		if !l.startsExpr(i) {
+			continue
+		}
+		p := &luaParser{toks: l.toks, pos: i}
+		expr := p.suffixed()
+
+		// mode = mode or "n"
+		if isParam(expr) && p.is("=") {
+			p.next()
+			if or, ok := p.expr().(*luaBinary); ok && or.op == "or" && isParam(or.left) && or.left.(*luaName).name == expr.(*luaName).name {
+				w.defaults[expr.(*luaName).name] = or.right
+			}
+			continue
+		}
+
+		call, ok := expr.(*luaCall)
+		if !ok || call.method != "" {
+			continue
+		}
+		offset, ok := vimKeymapFuncs[l.resolve(luaPath(call.fn))]
+		if !ok || len(call.args) < offset+2 {
+			continue
+		}
+		if isParam(call.args[offset]) || isParam(call.args[offset+1]) {
+			w.call, w.offset = call, offset
+			break
+		}
+	}
+
+	if w.call != nil {
+		l.wrappers[name] = w
+	}
+}
+
+// arg returns argument i of a keymap call and the names it is evaluated
+// with: a wrapper's parameter is replaced by what its caller passed
+func (l *vimLua) arg(args []luaExpr, i int, env map[string]luaExpr) (luaExpr, map[string]luaExpr) {
+	if i >= len(args) {
+		return nil, nil
+	}
+	if n, ok := args[i].(*luaName); ok && env != nil {
+		if value, ok := env[n.name]; ok {
+			return value, nil
+		}
+		return nil, nil
+	}
+	return args[i], env
+}
+
+// keymap records the mapping defined by keymap call arguments
+func (l *vimLua) keymap(args []luaExpr, offset int, env map[string]luaExpr, line int) {
+	modeExpr, modeEnv := l.arg(args, offset, env)
+	lhsExpr, lhsEnv := l.arg(args, offset+1, env)
+	rhsExpr, rhsEnv := l.arg(args, offset+2, env)
+	optsExpr, optsEnv := l.arg(args, offset+3, env)
+	if modeExpr == nil || lhsExpr == nil {
+		return
+	}
+
+	modes := luaStrings(modeExpr, modeEnv)
+	lhs, ok := luaStringValue(lhsExpr, lhsEnv)
+	if len(modes) == 0 || !ok {
+		return
+	}
+
+	opts, _ := optsExpr.(*luaTable)
+	command := ""
+	if rhsExpr != nil {
+		command = l.command(rhsExpr, rhsEnv)
+	}
+	if command == "" && opts != nil {
+		if callback := opts.field("callback"); callback != nil {
+			command = l.command(callback, optsEnv)
+		}
+	}
+	if command == "" {
+		return
+	}
+
+	description := ""
+	if opts != nil {
+		description, _ = luaStringValue(opts.field("desc"), optsEnv)
+	}
+
+	l.add(Keybind{Key: lhs, Command: command, Description: description, Mode: vimLuaModes(modes), File: l.file, Line: line})
+}
+
+// command returns a mapped command: a string as is, anything else, such as
+// a function, as its source
+func (l *vimLua) command(expr luaExpr, env map[string]luaExpr) string {
+	if s, ok := luaStringValue(expr, env); ok {
+		return s
+	}
+	return luaSource(l.src, expr, vimMaxCommand)
+}
+
+// lazyKeys reads a lazy.nvim keys spec: { { "<leader>ff", rhs, desc = ..., mode = ... }, ... }
+func (l *vimLua) lazyKeys(t *luaTable) {
+	for _, item := range t.items {
+		spec, ok := item.(*luaTable)
+		if !ok || len(spec.items) < 2 {
+			continue // Keys without a command only load the plugin
+		}
+		lhs, ok := luaStringValue(spec.items[0], nil)
+		if !ok {
+			continue
+		}
+
+		modes := []string{"n"}
+		if mode := spec.field("mode"); mode != nil {
+			modes = luaStrings(mode, nil)
+		}
+		description, _ := luaStringValue(spec.field("desc"), nil)
+
+		l.add(Keybind{
+			Key:         lhs,
+			Command:     l.command(spec.items[1], nil),
+			Description: description,
+			Mode:        vimLuaModes(modes),
+			File:        l.file,
+			Line:        spec.line,
+		})
+	}
+}
+
+// add records a mapping, replacing an earlier one of the same keys and modes
+func (l *vimLua) add(b Keybind) {
+	l.binds = replaceBind(l.binds, b)
+}
+
+// vimLuaModes joins the modes of a Lua keymap, where "" means map and "!" map!
+func vimLuaModes(modes []string) string {
+	for i, mode := range modes {
+		modes[i] = vimModes(mode, false)
+	}
+	return strings.Join(modes, ",")
+}
+
+// Nix
+
+// neovimNix reads mappings from home-manager's programs.neovim and nixvim's
+// programs.nixvim
+func neovimNix(cfg *nix.Config) []Keybind {
+	programs := cfg.Programs()
+
+	var binds []Keybind
+	if neovim := programs["neovim"]; neovim != nil {
+		binds = append(binds, parseSetting(neovim, "extraConfig", parseVimscript)...)
+		binds = append(binds, parseSetting(neovim, "extraLuaConfig", parseVimLua)...)
+	}
+	if nixvim := programs["nixvim"]; nixvim != nil {
+		for _, variable := range []string{"mapleader", "maplocalleader"} {
+			if value, ok := nixvim.Settings["globals."+variable].(string); ok {
+				src := nixvim.Sources["globals."+variable]
+				binds = append(binds, vimLeaderBind(variable, value, src.File, src.Line))
+			}
+		}
+		binds = append(binds, nixvimKeymaps(nixvim)...)
+		binds = append(binds, parseSetting(nixvim, "extraConfigVim", parseVimscript)...)
+		binds = append(binds, parseSetting(nixvim, "extraConfigLua", parseVimLua)...)
+	}
+	return binds
+}
+
+// vimNix reads mappings from home-manager's programs.vim
+func vimNix(cfg *nix.Config) []Keybind {
+	if vim := cfg.Programs()["vim"]; vim != nil {
+		return parseSetting(vim, "extraConfig", parseVimscript)
+	}
+	return nil
+}
+
+// nixvimKeymaps reads nixvim's keymaps list:
+// [ { mode = "n"; key = "<leader>ff"; action = "..."; options.desc = "..."; } ]
+func nixvimKeymaps(program *nix.Program) []Keybind {
+	keymaps, ok := program.Settings["keymaps"].([]any)
+	if !ok {
+		return nil
+	}
+	src := program.Sources["keymaps"]
+
+	var binds []Keybind
+	for _, item := range keymaps {
+		keymap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		key, _ := keymap["key"].(string)
+		command, _ := keymap["action"].(string)
+		if raw, ok := keymap["action"].(map[string]any); ok {
+			command, _ = raw["__raw"].(string)
+		}
+		if key == "" || command == "" {
+			continue
+		}
+
+		var modes []string
+		switch mode := keymap["mode"].(type) {
+		case string:
+			modes = []string{mode}
+		case []any:
+			for _, m := range mode {
+				if s, ok := m.(string); ok {
+					modes = append(modes, s)
+				}
+			}
+		default:
+			modes = []string{""}
+		}
+
+		description, _ := keymap["options.desc"].(string)
+		if options, ok := keymap["options"].(map[string]any); ok {
+			description, _ = options["desc"].(string)
+		}
+
+		binds = replaceBind(binds, Keybind{
+			Key:         key,
+			Command:     oneLine(command, vimMaxCommand),
+			Description: description,
+			Mode:        vimLuaModes(modes),
+			File:        src.File,
+			Line:        src.Line,
+		})
+	}
+	return binds
+}
@@ -0,0 +1,169 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+
+	"codex/internal/nix"
+)
+
+func TestParseVimLua(t *testing.T) {
+	src := `vim.g.mapleader = " "
+local map = vim.keymap.set
+local builtin = require("telescope.builtin")
+
+map("n", "<leader>ff", builtin.find_files, { desc = "Find files" })
+vim.keymap.set({ "n", "v" }, "<leader>y", '"+y', { desc = "Yank to clipboard" })
+vim.api.nvim_set_keymap("i", "jk", "<Esc>", { noremap = true })
+vim.api.nvim_buf_set_keymap(0, "n", "K", "", { callback = vim.lsp.buf.hover })
+vim.keymap.set("n", "<leader>e", function()
+  vim.cmd("Explore")
+end)
+
+vim.cmd [[
+  nnoremap <silent> <leader>w :write<CR>
+]]
+
+local function nmap(keys, func, desc)
+  vim.keymap.set("n", keys, func, { desc = "LSP: " .. desc })
+end
+nmap("gd", vim.lsp.buf.definition, "Goto definition")
+
+local lmap = function(keys, func, mode)
+  mode = mode or "n"
+  vim.keymap.set(mode, keys, func)
+end
+lmap("<C-s>", "<cmd>w<cr>")
+`
+
+	want := []Keybind{
+		{Key: "<Space>", Command: vimLeaderCommand, Description: "Leader key", File: "init.lua", Line: 1},
+		{Key: "<leader>ff", Command: "builtin.find_files", Description: "Find files", Mode: "n", File: "init.lua", Line: 5},
+		{Key: "<leader>y", Command: `"+y`, Description: "Yank to clipboard", Mode: "n,v", File: "init.lua", Line: 6},
+		{Key: "jk", Command: "<Esc>", Mode: "i", File: "init.lua", Line: 7},
+		{Key: "K", Command: "vim.lsp.buf.hover", Mode: "n", File: "init.lua", Line: 8},
+		{Key: "<leader>e", Command: `function() vim.cmd("Explore") end`, Mode: "n", File: "init.lua", Line: 9},
+		{Key: "<leader>w", Command: ":write<CR>", Mode: "n", File: "init.lua", Line: 14},
+		{Key: "gd", Command: "vim.lsp.buf.definition", Description: "LSP: Goto definition", Mode: "n", File: "init.lua", Line: 20},
+		{Key: "<C-s>", Command: "<cmd>w<cr>", Mode: "n", File: "init.lua", Line: 26},
+	}
+
+	got := parseVimLua(src, "init.lua", 1)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVimLua() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseVimLuaLazyKeys(t *testing.T) {
+	src := `return {
+  "nvim-telescope/telescope.nvim",
+  keys = {
+    { "<leader>ff", "<cmd>Telescope find_files<cr>", desc = "Find files" },
+    { "<leader>/", function() require("telescope.builtin").live_grep() end, mode = { "n", "x" }, desc = "Grep" },
+    { "<leader>fb" },
+  },
+}
+`
+	want := []Keybind{
+		{Key: "<leader>ff", Command: "<cmd>Telescope find_files<cr>", Description: "Find files", Mode: "n", File: "plugins/telescope.lua", Line: 4},
+		{Key: "<leader>/", Command: `function() require("telescope.builtin").live_grep() end`, Description: "Grep", Mode: "n,x", File: "plugins/telescope.lua", Line: 5},
+	}
+	got := parseVimLua(src, "plugins/telescope.lua", 1)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVimLua() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseVimscript(t *testing.T) {
+	src := `" Leader
+let mapleader = ","
+let g:maplocalleader = "\<Space>"
+
+nnoremap <silent> <leader>ff :Files<CR>
+nmap <Leader>g :Rg<Space>| echo "searching"
+inoremap jk <Esc>
+map! <C-a> <Home>
+noremap Y y$
+nnoremap <leader>x :bdelete<CR>
+nunmap <leader>x
+vnoremap <leader>s
+      \ :sort<CR>
+lua << EOF
+vim.keymap.set("n", "<localleader>t", "<cmd>TestNearest<cr>", { desc = "Test" })
+EOF
+`
+	want := []Keybind{
+		{Key: ",", Command: vimLeaderCommand, Description: "Leader key", File: "vimrc", Line: 2},
+		{Key: "<Space>", Command: vimLocalLeaderCommand, Description: "Local leader key", File: "vimrc", Line: 3},
+		{Key: "<leader>ff", Command: ":Files<CR>", Mode: "n", File: "vimrc", Line: 5},
+		{Key: "<Leader>g", Command: ":Rg<Space>", Mode: "n", File: "vimrc", Line: 6},
+		{Key: "jk", Command: "<Esc>", Mode: "i", File: "vimrc", Line: 7},
+		{Key: "<C-a>", Command: "<Home>", Mode: "i,c", File: "vimrc", Line: 8},
+		{Key: "Y", Command: "y$", Mode: "n,v,o", File: "vimrc", Line: 9},
+		{Key: "<leader>s", Command: ":sort<CR>", Mode: "v", File: "vimrc", Line: 12},
+		{Key: "<localleader>t", Command: "<cmd>TestNearest<cr>", Description: "Test", Mode: "n", File: "vimrc", Line: 15},
+	}
+	got := parseVimscript(src, "vimrc", 1)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseVimscript() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestResolveLeader(t *testing.T) {
+	binds := resolveLeader([]Keybind{
+		{Key: "<leader>ff", Command: ":Files<CR>"},
+		{Key: "<Space>", Command: vimLeaderCommand},
+		{Key: "<LocalLeader>t", Command: ":Test<CR>"},
+	})
+	if binds[0].Key != "<Space>ff" {
+		t.Errorf("Expected <leader> to resolve to the leader set later, got %q", binds[0].Key)
+	}
+	if binds[2].Key != `\t` {
+		t.Errorf("Expected <localleader> to default to backslash, got %q", binds[2].Key)
+	}
+}
+
+func TestVimConfigMatch(t *testing.T) {
+	neovim := []string{".config/nvim/init.lua", "nvim/lua/plugins/telescope.lua", "nvim/after/ftplugin/go.vim"}
+	vim := []string{".vimrc", "vim/.vimrc", ".vim/plugin/maps.vim"}
+	neither := []string{"wezterm/wezterm.lua", "nvim/pack/vendor/start/x/plugin/x.vim", ".vim/plugged/fzf/plugin/fzf.vim", "nvim/README.md"}
+
+	for _, rel := range neovim {
+		if !isNeovimConfig(rel) || isVimConfig(rel) {
+			t.Errorf("Expected %s to be a neovim config only", rel)
+		}
+	}
+	for _, rel := range vim {
+		if !isVimConfig(rel) || isNeovimConfig(rel) {
+			t.Errorf("Expected %s to be a vim config only", rel)
+		}
+	}
+	for _, rel := range neither {
+		if isVimConfig(rel) || isNeovimConfig(rel) {
+			t.Errorf("Expected %s not to be read", rel)
+		}
+	}
+}
+
+func TestNixvimKeymaps(t *testing.T) {
+	program := &nix.Program{
+		Name: "nixvim",
+		Settings: map[string]any{
+			"keymaps": []any{
+				map[string]any{"mode": "n", "key": "<leader>ff", "action": "<cmd>Telescope find_files<CR>", "options.desc": "Find files"},
+				map[string]any{"mode": []any{"n", "v"}, "key": "<leader>y", "action": `"+y`, "options": map[string]any{"desc": "Yank"}},
+				map[string]any{"key": "<C-n>", "action": map[string]any{"__raw": "function()\n  require('oil').open()\nend"}},
+			},
+		},
+		Sources: map[string]nix.Source{"keymaps": {File: "nvim.nix", Line: 12}},
+	}
+
+	want := []Keybind{
+		{Key: "<leader>ff", Command: "<cmd>Telescope find_files<CR>", Description: "Find files", Mode: "n", File: "nvim.nix", Line: 12},
+		{Key: "<leader>y", Command: `"+y`, Description: "Yank", Mode: "n,v", File: "nvim.nix", Line: 12},
+		{Key: "<C-n>", Command: "function() require('oil').open() end", Mode: "n,v,o", File: "nvim.nix", Line: 12},
+	}
+	if got := nixvimKeymaps(program); !reflect.DeepEqual(got, want) {
+		t.Errorf("nixvimKeymaps() =\n%+v\nwant\n%+v", got, want)
+	}
+}
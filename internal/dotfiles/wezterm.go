@@ -0,0 +1,226 @@
+package dotfiles
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	"codex/internal/nix"
+)
+
+func init() {
+	Register(Extractor{
+		Tool:     "wezterm",
+		Match:    isWeztermConfig,
+		Parse:    terminalParser(parseWezterm).keybinds,
+		Settings: terminalParser(parseWezterm).settings,
+		Nix:      weztermNix,
+	})
+}
+
+// weztermMaxCommand is the longest action expression kept as a bound command
+const weztermMaxCommand = 80
+
+// weztermActions is the module whose actions bindings perform
+const weztermActions = "wezterm.action"
+
+// weztermSettings are the font and theme settings kept
+var weztermSettings = map[string]bool{
+	"font": true, "font_size": true, "color_scheme": true, "window_background_opacity": true,
+}
+
+// isWeztermConfig matches wezterm.lua, .wezterm.lua and Lua files in a wezterm directory
+func isWeztermConfig(rel string) bool {
+	base := path.Base(rel)
+	return base == "wezterm.lua" || base == ".wezterm.lua" || path.Ext(rel) == ".lua" && inDir(rel, "wezterm")
+}
+
+// wezterm reads one wezterm config
+type wezterm struct {
+	src     string
+	file    string
+	toks    []luaToken
+	actions []string // Names bound to wezterm.action, e.g. local act = wezterm.action
+	config  *terminalConfig
+}
+
+// parseWezterm reads the keys, key_tables and leader a wezterm config sets,
+// by assignment to a config table, as fields of the table it returns, or with
+// table.insert, and its font and color scheme
+func parseWezterm(src, file string, line int) *terminalConfig {
+	w := &wezterm{src: src, file: file, toks: lexLua(src, line), actions: []string{weztermActions}, config: &terminalConfig{}}
+	for i := range w.toks {
+		w.scan(i)
+	}
+	return w.config
+}
+
+// scan looks for an assignment, returned table or table.insert call
+// starting at token i
+func (w *wezterm) scan(i int) {
+	p := &luaParser{toks: w.toks, pos: i}
+	tok := w.toks[i]
+	if tok.kind != luaNameToken {
+		return
+	}
+
+	switch {
+	case tok.text == "local":
+		p.next()
+		name := p.next()
+		if name.kind != luaNameToken || !p.is("=") {
+			return
+		}
+		p.next()
+		if value := p.expr(); value != nil {
+			w.assign(name.text, value)
+		}
+
+	case tok.text == "return":
+		p.next()
+		if t, ok := p.expr().(*luaTable); ok {
+			w.assign("", t)
+		}
+
+	case !isLuaKeyword(tok.text) && (i == 0 || w.toks[i-1].text != "." && w.toks[i-1].text != ":"):
+		expr := p.suffixed()
+		if expr == nil {
+			return
+		}
+		if p.is("=") {
+			p.next()
+			if value := p.expr(); value != nil {
+				w.assign(luaPath(expr), value)
+			}
+			return
+		}
+		// table.insert(config.keys, { ... })
+		if call, ok := expr.(*luaCall); ok && luaPath(call.fn) == "table.insert" && len(call.args) == 2 {
+			if target := luaPath(call.args[0]); target == "keys" || strings.HasSuffix(target, ".keys") {
+				w.bind(call.args[1], "")
+			}
+		}
+	}
+}
+
+// assign handles a value given to target, a variable or field path. The
+// fields of a table, such as the config returned, are assigned in turn.
+func (w *wezterm) assign(target string, value luaExpr) {
+	name := target[strings.LastIndex(target, ".")+1:]
+	parent := strings.TrimSuffix(target, "."+name)
+
+	switch {
+	case luaPath(value) == weztermActions:
+		w.actions = append(w.actions, target)
+		return
+	case parent != target && strings.HasSuffix(parent, "key_tables"):
+		if t, ok := value.(*luaTable); ok {
+			for _, item := range t.items {
+				w.bind(item, name)
+			}
+		}
+		return
+	case name == "keys":
+		if t, ok := value.(*luaTable); ok {
+			for _, item := range t.items {
+				w.bind(item, "")
+			}
+		}
+		return
+	case name == "key_tables":
+		if t, ok := value.(*luaTable); ok {
+			for _, f := range t.fields {
+				w.assign(target+"."+f.key, f.value)
+			}
+		}
+		return
+	case name == "leader":
+		if t, ok := value.(*luaTable); ok {
+			key, _ := luaStringValue(t.field("key"), nil)
+			mods, _ := luaStringValue(t.field("mods"), nil)
+			if key != "" {
+				w.config.binds = replaceBind(w.config.binds, Keybind{Key: keyCombo(mods, " |", key), Command: "LEADER", Description: "Leader key", File: w.file, Line: t.line})
+			}
+		}
+		return
+	case weztermSettings[name]:
+		if s, ok := w.setting(name, value); ok {
+			w.config.set(name, s, w.file, value.span().line)
+		}
+		return
+	}
+
+	if t, ok := value.(*luaTable); ok {
+		for _, f := range t.fields {
+			w.assign(strings.TrimPrefix(target+"."+f.key, "."), f.value)
+		}
+	}
+}
+
+// bind reads a key table entry: { key = "t", mods = "CTRL|SHIFT", action = ... }
+func (w *wezterm) bind(expr luaExpr, mode string) {
+	t, ok := expr.(*luaTable)
+	if !ok {
+		return
+	}
+	key, _ := luaStringValue(t.field("key"), nil)
+	action := t.field("action")
+	if key == "" || action == nil {
+		return
+	}
+	mods, _ := luaStringValue(t.field("mods"), nil)
+
+	// Actions are values of wezterm.action, or their names as strings
+	command := luaSource(w.src, action, weztermMaxCommand)
+	for _, prefix := range w.actions {
+		command = strings.TrimPrefix(command, prefix+".")
+	}
+	if s, ok := action.(*luaString); ok {
+		command = s.value
+	}
+	w.config.binds = replaceBind(w.config.binds, Keybind{Key: keyCombo(mods, " |", key), Command: command, Mode: mode, File: w.file, Line: t.line})
+}
+
+// setting evaluates a font or theme setting: a string, a number, or the
+// first family given to wezterm.font or wezterm.font_with_fallback
+func (w *wezterm) setting(name string, value luaExpr) (any, bool) {
+	if call, ok := value.(*luaCall); ok && name == "font" && len(call.args) > 0 {
+		if families := luaStrings(call.args[0], nil); len(families) > 0 {
+			return families[0], true
+		}
+		// wezterm.font { family = "..." } or font_with_fallback { { family = "..." }, ... }
+		if t, ok := call.args[0].(*luaTable); ok {
+			if len(t.items) > 0 {
+				if first, ok := t.items[0].(*luaTable); ok {
+					t = first
+				}
+			}
+			if family, ok := luaStringValue(t.field("family"), nil); ok {
+				return family, true
+			}
+		}
+		return nil, false
+	}
+
+	if s, ok := luaStringValue(value, nil); ok {
+		return s, true
+	}
+	if lit, ok := value.(*luaLiteral); ok {
+		if n, err := strconv.ParseInt(lit.text, 10, 64); err == nil {
+			return n, true
+		}
+		if f, err := strconv.ParseFloat(lit.text, 64); err == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// weztermNix reads home-manager's programs.wezterm extraConfig
+func weztermNix(cfg *nix.Config) []Keybind {
+	program := cfg.Programs()["wezterm"]
+	if program == nil {
+		return nil
+	}
+	return parseSetting(program, "extraConfig", terminalParser(parseWezterm).keybinds)
+}
@@ -0,0 +1,76 @@
+package dotfiles
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWezterm(t *testing.T) {
+	src := `local wezterm = require 'wezterm'
+local act = wezterm.action
+local config = wezterm.config_builder()
+
+config.font = wezterm.font_with_fallback { 'Fira Code', 'Noto Color Emoji' }
+config.font_size = 13
+config.color_scheme = 'Catppuccin Mocha'
+
+config.leader = { key = 'a', mods = 'CTRL', timeout_milliseconds = 1000 }
+config.keys = {
+  { key = '|', mods = 'LEADER|SHIFT', action = act.SplitHorizontal { domain = 'CurrentPaneDomain' } },
+  { key = 'r', mods = 'LEADER', action = act.ActivateKeyTable { name = 'resize_pane', one_shot = false } },
+  { key = 't', mods = 'CTRL|SHIFT', action = wezterm.action.SpawnTab 'CurrentPaneDomain' },
+}
+table.insert(config.keys, { key = 'Enter', mods = 'ALT', action = act.ToggleFullScreen })
+
+config.key_tables = {
+  resize_pane = {
+    { key = 'h', action = act.AdjustPaneSize { 'Left', 1 } },
+    { key = 'Escape', action = 'PopKeyTable' },
+  },
+}
+
+return config
+`
+
+	got := parseWezterm(src, "wezterm.lua", 1)
+	want := []Keybind{
+		{Key: "CTRL+a", Command: "LEADER", Description: "Leader key", File: "wezterm.lua", Line: 9},
+		{Key: "LEADER+SHIFT+|", Command: "SplitHorizontal { domain = 'CurrentPaneDomain' }", File: "wezterm.lua", Line: 11},
+		{Key: "LEADER+r", Command: "ActivateKeyTable { name = 'resize_pane', one_shot = false }", File: "wezterm.lua", Line: 12},
+		{Key: "CTRL+SHIFT+t", Command: "SpawnTab 'CurrentPaneDomain'", File: "wezterm.lua", Line: 13},
+		{Key: "ALT+Enter", Command: "ToggleFullScreen", File: "wezterm.lua", Line: 15},
+		{Key: "h", Command: "AdjustPaneSize { 'Left', 1 }", Mode: "resize_pane", File: "wezterm.lua", Line: 19},
+		{Key: "Escape", Command: "PopKeyTable", Mode: "resize_pane", File: "wezterm.lua", Line: 20},
+	}
+	if !reflect.DeepEqual(got.binds, want) {
+		t.Errorf("parseWezterm() binds =\n%+v\nwant\n%+v", got.binds, want)
+	}
+
+	wantSettings := []Setting{
+		{Key: "font", Value: "Fira Code", File: "wezterm.lua", Line: 5},
+		{Key: "font_size", Value: int64(13), File: "wezterm.lua", Line: 6},
+		{Key: "color_scheme", Value: "Catppuccin Mocha", File: "wezterm.lua", Line: 7},
+	}
+	if !reflect.DeepEqual(got.settings, wantSettings) {
+		t.Errorf("parseWezterm() settings =\n%+v\nwant\n%+v", got.settings, wantSettings)
+	}
+}
+
+func TestParseWeztermReturnedTable(t *testing.T) {
+	src := `return {
+  font = wezterm.font({ family = 'JetBrains Mono', weight = 'Bold' }),
+  keys = {
+    { key = 'w', mods = 'CMD', action = wezterm.action.CloseCurrentTab { confirm = true } },
+  },
+}`
+
+	got := parseWezterm(src, ".wezterm.lua", 1)
+	want := []Keybind{{Key: "CMD+w", Command: "CloseCurrentTab { confirm = true }", File: ".wezterm.lua", Line: 4}}
+	if !reflect.DeepEqual(got.binds, want) {
+		t.Errorf("parseWezterm() binds =\n%+v\nwant\n%+v", got.binds, want)
+	}
+	wantSettings := []Setting{{Key: "font", Value: "JetBrains Mono", File: ".wezterm.lua", Line: 2}}
+	if !reflect.DeepEqual(got.settings, wantSettings) {
+		t.Errorf("parseWezterm() settings =\n%+v\nwant\n%+v", got.settings, wantSettings)
+	}
+}
@@ -0,0 +1,69 @@
+package dotfiles
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// wmVariable records the definition of $name, so that variables set in one
+// file can be expanded in the files it includes. resolveVariables drops
+// these before bindings are returned.
+func wmVariable(name, value, file string, line int) Keybind {
+	return Keybind{Key: name, Command: value, File: file, Line: line, variable: true}
+}
+
+// expandVariables replaces each $name in text with its value. Longer names
+// are replaced first so $mod does not match the start of $mode_system.
+func expandVariables(text string, vars map[string]string) string {
+	if !strings.Contains(text, "$") {
+		return text
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		text = strings.ReplaceAll(text, "$"+name, vars[name])
+	}
+	return text
+}
+
+// resolveVariables expands the variables still left in bindings with the
+// definitions read from every file, then drops the definitions
+func resolveVariables(binds []Keybind) []Keybind {
+	vars := make(map[string]string)
+	for _, b := range binds {
+		if b.variable {
+			vars[b.Key] = b.Command
+		}
+	}
+	binds = removeBinds(binds, func(b Keybind) bool { return b.variable })
+
+	for i, b := range binds {
+		binds[i].Key = expandVariables(b.Key, vars)
+		binds[i].Command = expandVariables(b.Command, vars)
+		binds[i].Mode = expandVariables(b.Mode, vars)
+	}
+	return binds
+}
+
+// inDir reports whether rel is somewhere below a directory named one of dirs
+func inDir(rel string, dirs ...string) bool {
+	for _, dir := range strings.Split(path.Dir(rel), "/") {
+		for _, want := range dirs {
+			if dir == want {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,393 @@
+// Package ignore decides which repository paths to leave out of context,
+// following .gitignore semantics.
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitignoreFile is the per-directory ignore file name
+const GitignoreFile = ".gitignore"
+
+// CodexignoreFile holds patterns that keep tracked files out of context only.
+// It uses gitignore syntax and overrides .gitignore in the same directory.
+const CodexignoreFile = ".codexignore"
+
+// DefaultPatterns are ignored in every repository: version control metadata,
+// dependencies, build output, caches and editor state. They have the lowest
+// precedence, so a repository's own ignore files can re-include them.
+var DefaultPatterns = []string{
+	// Dependencies
+	"node_modules/",
+	"vendor/",
+
+	// Build/Dist
+	"dist/",
+	"build/",
+	"target/",
+	".next/",
+	".nuxt/",
+	"out/",
+
+	// Caches
+	".cache/",
+	".npm/",
+	".yarn/",
+	".gradle/",
+	".m2/",
+	".turbo/",
+	"tmp/",
+	"temp/",
+	".tmp/",
+	"Cache/",          // Electron/browser cache
+	"GPUCache/",       // GPU cache
+	"Code Cache/",     // Code cache
+	"DawnCache/",      // WebGPU cache
+	"IndexedDB/",      // Browser IndexedDB
+	"LocalStorage/",   // Browser local storage
+	"SessionStorage/", // Browser session storage
+	"Service Worker/", // Service worker cache
+	"adblock/",        // Adblock lists
+
+	// Python
+	"__pycache__/",
+	".pytest_cache/",
+	".mypy_cache/",
+	".venv/",
+	"venv/",
+	".tox/",
+
+	// Coverage/Testing
+	"coverage/",
+	".coverage/",
+	".nyc_output/",
+
+	// IDE/Editor
+	".idea/",
+	".vscode/",
+	".DS_Store",
+
+	// Browser/Electron specific
+	"Dictionaries/", // Spell check dictionaries
+	"WebStorage/",   // Web storage
+	"Partitions/",   // Browser partitions
+	"DawnGraphiteCache/",
+	"DawnWebGPUCache/",
+
+	// Nix
+	"result", // Nix result symlinks
+	"result-*",
+}
+
+// globalExcludesFile returns the user's global excludes file; a variable so tests can replace it
+var globalExcludesFile = defaultGlobalExcludesFile
+
+// pattern is one parsed line of an ignore file
+type pattern struct {
+	base     string   // Slash-separated directory the pattern is relative to ("" = root)
+	segments []string // Glob per path element; "**" matches any number of elements
+	negate   bool     // "!pattern" re-includes
+	dirOnly  bool     // "pattern/" only matches directories
+}
+
+// Matcher reports whether paths inside a repository are ignored. Ignore files
+// in subdirectories are loaded the first time a path beneath them is matched.
+type Matcher struct {
+	root      string
+	files     []string        // Per-directory ignore file names, in increasing precedence
+	patterns  []pattern       // In increasing precedence: the last match wins
+	overrides []pattern       // Applied after every ignore file, however deep
+	includes  []pattern       // If set, files must match one of these
+	loaded    map[string]bool // Directories whose ignore files have been read
+}
+
+// New creates a matcher for the repository at root. In increasing
+// precedence it applies DefaultPatterns, the global excludes file
+// (core.excludesFile), .git/info/exclude and every .gitignore in the tree.
+func New(root string) *Matcher {
+	m := &Matcher{
+		root:   root,
+		files:  []string{GitignoreFile},
+		loaded: make(map[string]bool),
+	}
+
+	m.AddPatterns("", DefaultPatterns)
+	if global := globalExcludesFile(root); global != "" {
+		m.AddFile("", global)
+	}
+	m.AddFile("", filepath.Join(root, ".git", "info", "exclude"))
+
+	return m
+}
+
+// AddPatterns adds gitignore-style pattern lines relative to base, a
+// slash-separated directory inside the repository ("" for the root). They
+// take precedence over everything added before.
+func (m *Matcher) AddPatterns(base string, lines []string) {
+	for _, line := range lines {
+		if p, ok := parsePattern(base, line); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// AddFile adds the patterns of an ignore file relative to base. A missing
+// file is not an error.
+func (m *Matcher) AddFile(base, path string) {
+	lines, err := readLines(path)
+	if err != nil {
+		return
+	}
+	m.AddPatterns(base, lines)
+}
+
+// AddOverrides adds gitignore-style patterns, relative to the root, that take
+// precedence over every ignore file in the tree
+func (m *Matcher) AddOverrides(lines []string) {
+	for _, line := range lines {
+		if p, ok := parsePattern("", line); ok {
+			m.overrides = append(m.overrides, p)
+		}
+	}
+}
+
+// SetIncludes restricts files to those matching at least one of the globs,
+// written like root-relative gitignore patterns. Directories are unaffected.
+func (m *Matcher) SetIncludes(globs []string) {
+	m.includes = nil
+	for _, glob := range globs {
+		if p, ok := parsePattern("", glob); ok {
+			// "dir/" includes everything beneath dir
+			if p.dirOnly {
+				p.segments = append(p.segments, "**")
+				p.dirOnly = false
+			}
+			m.includes = append(m.includes, p)
+		}
+	}
+}
+
+// AddIgnoreFileName makes the matcher read name in every directory as well,
+// with higher precedence than .gitignore in the same directory
+func (m *Matcher) AddIgnoreFileName(name string) {
+	m.files = append(m.files, name)
+}
+
+// Match reports whether relPath, slash- or OS-separated and relative to the
+// repository root, is ignored. As in git, a path inside an ignored directory
+// is ignored even if a later pattern would re-include it.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == "." || relPath == "" {
+		return false
+	}
+
+	// Version control metadata is never context
+	elems := strings.Split(relPath, "/")
+	for _, elem := range elems {
+		if elem == ".git" {
+			return true
+		}
+	}
+
+	// Check each enclosing directory first, loading ignore files on the way down
+	m.load("")
+	for i := 1; i < len(elems); i++ {
+		dir := strings.Join(elems[:i], "/")
+		if m.matchOne(dir, true) {
+			return true
+		}
+		m.load(dir)
+	}
+
+	if !isDir && len(m.includes) > 0 && !m.included(relPath) {
+		return true
+	}
+
+	return m.matchOne(relPath, isDir)
+}
+
+// included reports whether a file matches any include glob
+func (m *Matcher) included(relPath string) bool {
+	for _, p := range m.includes {
+		if p.matches(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOne applies the patterns to a single path without checking its parents
+func (m *Matcher) matchOne(relPath string, isDir bool) bool {
+	ignored := false
+	for _, patterns := range [][]pattern{m.patterns, m.overrides} {
+		for _, p := range patterns {
+			if p.dirOnly && !isDir {
+				continue
+			}
+			if p.matches(relPath) {
+				ignored = !p.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// load reads the ignore files of dir (slash-separated, "" for the root) once
+func (m *Matcher) load(dir string) {
+	if m.loaded[dir] {
+		return
+	}
+	m.loaded[dir] = true
+
+	for _, name := range m.files {
+		m.AddFile(dir, filepath.Join(m.root, filepath.FromSlash(dir), name))
+	}
+}
+
+// matches reports whether relPath (relative to the repository root) matches the pattern
+func (p pattern) matches(relPath string) bool {
+	if p.base != "" {
+		if !strings.HasPrefix(relPath, p.base+"/") {
+			return false
+		}
+		relPath = relPath[len(p.base)+1:]
+	}
+	return matchSegments(p.segments, strings.Split(relPath, "/"))
+}
+
+// matchSegments matches path elements against glob segments, where "**"
+// stands for zero or more whole elements
+func matchSegments(segments, elems []string) bool {
+	for len(segments) > 0 {
+		if segments[0] == "**" {
+			rest := segments[1:]
+			if len(rest) == 0 {
+				// A trailing "**" matches everything inside, not the directory itself
+				return len(elems) > 0
+			}
+			for i := 0; i <= len(elems); i++ {
+				if matchSegments(rest, elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, err := path.Match(segments[0], elems[0]); err != nil || !ok {
+			return false
+		}
+		segments, elems = segments[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
+
+// ValidatePattern checks that a gitignore-style pattern is well formed
+func ValidatePattern(line string) error {
+	p, ok := parsePattern("", line)
+	if !ok {
+		return fmt.Errorf("empty pattern %q", line)
+	}
+	for _, segment := range p.segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", line, err)
+		}
+	}
+	return nil
+}
+
+// parsePattern parses one ignore file line; ok is false for blanks and comments
+func parsePattern(base, line string) (pattern, bool) {
+	line = strings.TrimRight(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	p := pattern{base: strings.Trim(filepath.ToSlash(base), "/")}
+
+	switch {
+	case strings.HasPrefix(line, "!"):
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to its base directory;
+	// otherwise it matches at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	// Git negates bracket expressions with "[!", path.Match with "[^"
+	line = strings.ReplaceAll(line, "[!", "[^")
+
+	p.segments = strings.Split(line, "/")
+	if !anchored {
+		p.segments = append([]string{"**"}, p.segments...)
+	}
+
+	return p, true
+}
+
+// trimTrailingSpaces removes unescaped trailing spaces, as git does. An
+// escaped space is left for path.Match to unescape.
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// readLines reads a file's lines
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// defaultGlobalExcludesFile finds the global excludes file the way git does:
+// core.excludesFile if set, else $XDG_CONFIG_HOME/git/ignore or ~/.config/git/ignore
+func defaultGlobalExcludesFile(root string) string {
+	cmd := exec.Command("git", "config", "--path", "core.excludesFile")
+	cmd.Dir = root
+	if output, err := cmd.Output(); err == nil {
+		if file := strings.TrimSpace(string(output)); file != "" {
+			return file
+		}
+	}
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "git", "ignore")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "git", "ignore")
+	}
+	return ""
+}
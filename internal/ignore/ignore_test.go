@@ -0,0 +1,236 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files under root from a map of relative path to content
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestMatcher builds a matcher over a fixture tree with globalFile as the global excludes
+func newTestMatcher(t *testing.T, files map[string]string, globalFile string) *Matcher {
+	t.Helper()
+
+	root := t.TempDir()
+	writeTree(t, root, files)
+
+	saved := globalExcludesFile
+	globalExcludesFile = func(string) string { return globalFile }
+	t.Cleanup(func() { globalExcludesFile = saved })
+
+	return New(root)
+}
+
+type matchCase struct {
+	path  string
+	isDir bool
+	want  bool
+}
+
+func checkMatches(t *testing.T, m *Matcher, cases []matchCase) {
+	t.Helper()
+	for _, c := range cases {
+		if got := m.Match(c.path, c.isDir); got != c.want {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", c.path, c.isDir, got, c.want)
+		}
+	}
+}
+
+func TestDefaultPatterns(t *testing.T) {
+	m := newTestMatcher(t, nil, "")
+	checkMatches(t, m, []matchCase{
+		{".git", true, true},
+		{".git/config", false, true},
+		{"node_modules", true, true},
+		{"web/node_modules/react/index.js", false, true},
+		{"target", true, true},
+		{"result", false, true},
+		{"result-2", false, true},
+		{"src/main.go", false, false},
+		{"build.sh", false, false},
+	})
+}
+
+func TestGitignoreSemantics(t *testing.T) {
+	m := newTestMatcher(t, map[string]string{
+		".gitignore": `# comment
+*.log
+!keep.log
+/secrets.nix
+docs/generated/
+logs/**
+!logs/README
+**/fixtures/*.json
+a/**/z.txt
+\#literal
+` + "trailing   \n" + "[!a]bc\n",
+	}, "")
+
+	checkMatches(t, m, []matchCase{
+		// Unanchored patterns match at any depth
+		{"debug.log", false, true},
+		{"deep/dir/app.log", false, true},
+
+		// Negation re-includes
+		{"keep.log", false, false},
+		{"sub/keep.log", false, false},
+
+		// A leading slash anchors to the ignore file's directory
+		{"secrets.nix", false, true},
+		{"hosts/secrets.nix", false, false},
+
+		// A middle slash anchors too; a trailing slash only matches directories
+		{"docs/generated", true, true},
+		{"docs/generated/api.go", false, true},
+		{"other/docs/generated", true, false},
+
+		// Trailing ** ignores the contents, so entries can be re-included
+		{"logs", true, false},
+		{"logs/app", false, true},
+		{"logs/README", false, false},
+
+		// Leading and middle **
+		{"fixtures/a.json", false, true},
+		{"test/data/fixtures/a.json", false, true},
+		{"test/data/fixtures/a.yaml", false, false},
+		{"a/z.txt", false, true},
+		{"a/b/c/z.txt", false, true},
+		{"b/z.txt", false, false},
+
+		// Escapes, trailing spaces and bracket negation
+		{"#literal", false, true},
+		{"trailing", false, true},
+		{"xbc", false, true},
+		{"abc", false, false},
+	})
+}
+
+func TestParentDirectoryCannotBeReincluded(t *testing.T) {
+	m := newTestMatcher(t, map[string]string{
+		".gitignore": "private/\n!private/public.txt\n",
+	}, "")
+
+	checkMatches(t, m, []matchCase{
+		{"private", true, true},
+		{"private/public.txt", false, true},
+	})
+}
+
+func TestNestedIgnoreFiles(t *testing.T) {
+	m := newTestMatcher(t, map[string]string{
+		".gitignore":          "*.tmp\n",
+		"pkg/.gitignore":      "/local.go\n!important.tmp\n",
+		"pkg/sub/.gitignore":  "*.go\n",
+		"other/.gitignore":    "",
+		"pkg/sub/deep/x.go":   "",
+		"pkg/local.go":        "",
+		"local.go":            "",
+		"pkg/important.tmp":   "",
+		"other/important.tmp": "",
+	}, "")
+
+	checkMatches(t, m, []matchCase{
+		// Nested patterns are anchored to their own directory
+		{"pkg/local.go", false, true},
+		{"local.go", false, false},
+		{"pkg/sub/local.go", false, true},
+
+		// Deeper files take precedence over shallower ones
+		{"pkg/important.tmp", false, false},
+		{"other/important.tmp", false, true},
+
+		// Patterns in a nested file only apply beneath it
+		{"pkg/sub/deep/x.go", false, true},
+		{"pkg/main.go", false, false},
+	})
+}
+
+func TestExcludeSources(t *testing.T) {
+	global := filepath.Join(t.TempDir(), "ignore")
+	writeTree(t, filepath.Dir(global), map[string]string{"ignore": "*.swp\n*.orig\n"})
+
+	m := newTestMatcher(t, map[string]string{
+		".git/info/exclude": "scratch/\n",
+		".gitignore":        "!wanted.orig\n",
+	}, global)
+
+	checkMatches(t, m, []matchCase{
+		{"main.go.swp", false, true},
+		{"merge.orig", false, true},
+		{"scratch", true, true},
+
+		// .gitignore takes precedence over the global excludes
+		{"wanted.orig", false, false},
+	})
+}
+
+func TestDefaultsCanBeReincluded(t *testing.T) {
+	m := newTestMatcher(t, map[string]string{
+		".gitignore": "!vendor/\n",
+	}, "")
+
+	checkMatches(t, m, []matchCase{
+		{"vendor", true, false},
+		{"node_modules", true, true},
+	})
+}
+
+func TestCodexignoreOverridesGitignore(t *testing.T) {
+	m := newTestMatcher(t, map[string]string{
+		".gitignore":   "*.gen.go\n",
+		".codexignore": "secrets/\n!api.gen.go\n",
+	}, "")
+	m.AddIgnoreFileName(CodexignoreFile)
+
+	checkMatches(t, m, []matchCase{
+		{"secrets", true, true},
+		{"db.gen.go", false, true},
+		{"api.gen.go", false, false},
+	})
+}
+
+func TestOverridesAndIncludes(t *testing.T) {
+	m := newTestMatcher(t, map[string]string{
+		"modules/.gitignore": "!*.lock\n",
+	}, "")
+	m.AddOverrides([]string{"*.lock"})
+	m.SetIncludes([]string{"modules/**/*.nix", "docs/"})
+
+	checkMatches(t, m, []matchCase{
+		// Overrides beat even nested ignore files
+		{"modules/flake.lock", false, true},
+
+		// Only included files are read, but directories are still walked
+		{"modules/desktop/sway.nix", false, false},
+		{"modules/desktop/sway.conf", false, true},
+		{"hosts/laptop.nix", false, true},
+		{"hosts", true, false},
+		{"docs/setup/install.sh", false, false},
+	})
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, valid := range []string{"*.nix", "modules/**/*.nix", "!keep", "[a-z]*"} {
+		if err := ValidatePattern(valid); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"", "# comment", "[a-"} {
+		if err := ValidatePattern(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package nix parses the subset of the Nix language used by NixOS and
+// home-manager configurations, without evaluating it or needing a nix binary.
+package nix
+
+import "fmt"
+
+// Pos is a 1-based position in a source file
+type Pos struct {
+	Line int
+	Col  int
+}
+
+// Position returns the position itself, so every node embedding Pos satisfies Expr
+func (p Pos) Position() Pos { return p }
+
+// Expr is a node of the syntax tree
+type Expr interface {
+	Position() Pos
+}
+
+// Ident is a variable reference; true, false and null are identifiers too
+type Ident struct {
+	Pos
+	Name string
+}
+
+// Int is an integer literal
+type Int struct {
+	Pos
+	Value int64
+}
+
+// Float is a floating point literal
+type Float struct {
+	Pos
+	Value float64
+}
+
+// String is a "string" or ”indented string”. Interpolations are kept as
+// their ${...} source text.
+type String struct {
+	Pos
+	Value        string
+	Interpolated bool
+	Indented     bool
+	ValueLine    int // Line of the first line of Value in the source
+}
+
+// Path is a path literal: ./foo.nix, /etc/nixos, ~/x or <nixpkgs>
+type Path struct {
+	Pos
+	Value string
+}
+
+// URI is an unquoted URI literal
+type URI struct {
+	Pos
+	Value string
+}
+
+// List is [ a b c ]
+type List struct {
+	Pos
+	Items []Expr
+}
+
+// AttrSet is { a = 1; b.c = 2; }. Inherited attributes are stored as
+// bindings to the identifier or selection they come from.
+type AttrSet struct {
+	Pos
+	Rec      bool
+	Bindings []*Binding
+}
+
+// Binding assigns a value to an attribute path. Dynamic attribute names are
+// kept as their ${...} source text.
+type Binding struct {
+	Pos
+	Path  []string
+	Value Expr
+}
+
+// Select is e.a.b, optionally with a default: e.a.b or d
+type Select struct {
+	Pos
+	Expr    Expr
+	Path    []string
+	Default Expr
+}
+
+// HasAttr is e ? a.b
+type HasAttr struct {
+	Pos
+	Expr Expr
+	Path []string
+}
+
+// Apply is a function application: f x
+type Apply struct {
+	Pos
+	Func Expr
+	Arg  Expr
+}
+
+// Formal is one argument of a { a, b ? default, ... }: lambda
+type Formal struct {
+	Name    string
+	Default Expr
+}
+
+// Lambda is x: body or { formals }: body, optionally with args @ binding
+type Lambda struct {
+	Pos
+	Arg      string   // Name bound to the whole argument, if any
+	Formals  []Formal // Set when the argument is destructured
+	Ellipsis bool
+	HasSet   bool // Whether the argument is a { ... } pattern
+	Body     Expr
+}
+
+// Let is let bindings in body
+type Let struct {
+	Pos
+	Bindings []*Binding
+	Body     Expr
+}
+
+// With is with env; body
+type With struct {
+	Pos
+	Env  Expr
+	Body Expr
+}
+
+// If is if cond then a else b
+type If struct {
+	Pos
+	Cond Expr
+	Then Expr
+	Else Expr
+}
+
+// Assert is assert cond; body
+type Assert struct {
+	Pos
+	Cond Expr
+	Body Expr
+}
+
+// BinaryOp is a binary operator such as ++, // or ==
+type BinaryOp struct {
+	Pos
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+// UnaryOp is ! or unary -
+type UnaryOp struct {
+	Pos
+	Op   string
+	Expr Expr
+}
+
+// SyntaxError reports where a file could not be parsed
+type SyntaxError struct {
+	File string
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Col, e.Msg)
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// Walk calls fn for expr and, while fn returns true, for each of its children
+func Walk(expr Expr, fn func(Expr) bool) {
+	if expr == nil || !fn(expr) {
+		return
+	}
+
+	switch e := expr.(type) {
+	case *List:
+		for _, item := range e.Items {
+			Walk(item, fn)
+		}
+	case *AttrSet:
+		for _, b := range e.Bindings {
+			Walk(b.Value, fn)
+		}
+	case *Select:
+		Walk(e.Expr, fn)
+		Walk(e.Default, fn)
+	case *HasAttr:
+		Walk(e.Expr, fn)
+	case *Apply:
+		Walk(e.Func, fn)
+		Walk(e.Arg, fn)
+	case *Lambda:
+		for _, f := range e.Formals {
+			Walk(f.Default, fn)
+		}
+		Walk(e.Body, fn)
+	case *Let:
+		for _, b := range e.Bindings {
+			Walk(b.Value, fn)
+		}
+		Walk(e.Body, fn)
+	case *With:
+		Walk(e.Env, fn)
+		Walk(e.Body, fn)
+	case *If:
+		Walk(e.Cond, fn)
+		Walk(e.Then, fn)
+		Walk(e.Else, fn)
+	case *Assert:
+		Walk(e.Cond, fn)
+		Walk(e.Body, fn)
+	case *BinaryOp:
+		Walk(e.Left, fn)
+		Walk(e.Right, fn)
+	case *UnaryOp:
+		Walk(e.Expr, fn)
+	}
+}
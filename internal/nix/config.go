@@ -0,0 +1,599 @@
+package nix
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Entry point file names, in the order they are looked for
+const (
+	FlakeFile         = "flake.nix"
+	ConfigurationFile = "configuration.nix"
+	HomeFile          = "home.nix"
+)
+
+// Config is what codex understands of a NixOS or home-manager configuration
+type Config struct {
+	Root     string            // Directory holding the configuration
+	IsFlake  bool              // Whether Root has a flake.nix
+	Files    []string          // Files read, relative to Root, in the order they were read
+	Packages []string          // Installed packages, sorted and deduplicated
+	Options  map[string]any    // Option assignments keyed by dotted path, e.g. "services.openssh.enable"
+	Sources  map[string]Source // Where each option in Options was set
+	Inputs   []FlakeInput      // Flake inputs, sorted by name
+
+	HomeConfigurations []string // Names of the flake's homeConfigurations
+	Warnings           []string // Files that could not be read or parsed
+	Hash               string   // SHA-256 of every file read, for caching
+
+	visited map[string]bool
+	digest  hash.Hash
+}
+
+// Source is where an option was set. For multi-line strings, Line is the
+// line of the string's first line of content.
+type Source struct {
+	File string `json:"file"` // Relative to the configuration root
+	Line int    `json:"line"`
+}
+
+// ErrNoEntryPoint is returned by ParseConfig for a directory with no Nix
+// configuration, such as plain dotfiles
+var ErrNoEntryPoint = errors.New("no Nix entry point")
+
+// packageOptions hold package lists rather than settings
+var packageOptions = []string{"environment.systemPackages", "home.packages"}
+
+// ParseConfig reads the configuration at path: a flake, a directory with a
+// configuration.nix or home.nix, or a single module file. It follows
+// imports and collects packages and option assignments.
+func ParseConfig(path string) (*Config, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Nix configuration: %w", err)
+	}
+
+	c := &Config{
+		Root:    path,
+		Options: make(map[string]any),
+		Sources: make(map[string]Source),
+		visited: make(map[string]bool),
+		digest:  sha256.New(),
+	}
+
+	var entries []string
+	if info.IsDir() {
+		if _, err := os.Stat(filepath.Join(path, FlakeFile)); err == nil {
+			c.IsFlake = true
+			c.loadFlake(filepath.Join(path, FlakeFile))
+		}
+		// Without a flake, or one whose modules could not be followed, use the usual entry points
+		if len(c.Files) <= 1 {
+			for _, name := range []string{ConfigurationFile, HomeFile} {
+				if entry := filepath.Join(path, name); fileExists(entry) {
+					entries = append(entries, entry)
+					break
+				}
+			}
+		}
+		if !c.IsFlake && len(entries) == 0 {
+			return nil, fmt.Errorf("%w: no %s, %s or %s in %s", ErrNoEntryPoint, FlakeFile, ConfigurationFile, HomeFile, path)
+		}
+	} else {
+		c.Root = filepath.Dir(path)
+		if filepath.Base(path) == FlakeFile {
+			c.IsFlake = true
+			c.loadFlake(path)
+		} else {
+			entries = append(entries, path)
+		}
+	}
+
+	for _, entry := range entries {
+		c.loadModule(entry, nil)
+	}
+
+	sort.Strings(c.Packages)
+	c.Packages = dedupe(c.Packages)
+	c.Hash = hex.EncodeToString(c.digest.Sum(nil))
+
+	return c, nil
+}
+
+// read parses a file once, recording it; ok is false if it was already read or failed
+func (c *Config) read(file string) (Expr, bool) {
+	if c.visited[file] {
+		return nil, false
+	}
+	c.visited[file] = true
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		c.Warnings = append(c.Warnings, err.Error())
+		return nil, false
+	}
+	c.digest.Write([]byte(file))
+	c.digest.Write(data)
+	c.Files = append(c.Files, c.rel(file))
+
+	expr, err := Parse(string(data))
+	if err != nil {
+		if syntaxErr, ok := err.(*SyntaxError); ok {
+			syntaxErr.File = c.rel(file)
+		}
+		c.Warnings = append(c.Warnings, err.Error())
+		return nil, false
+	}
+	return expr, true
+}
+
+// loadFlake reads the modules listed in a flake's system and home configurations
+func (c *Config) loadFlake(file string) {
+	expr, ok := c.read(file)
+	if !ok {
+		return
+	}
+	c.loadFlakeInputs(file, expr)
+
+	m := &module{file: file}
+	Walk(expr, func(e Expr) bool {
+		set, ok := e.(*AttrSet)
+		if !ok {
+			return true
+		}
+		for _, b := range set.Bindings {
+			c.HomeConfigurations = append(c.HomeConfigurations, homeConfigurationNames(b)...)
+			if b.Path[len(b.Path)-1] == "modules" {
+				for _, item := range listItems(b.Value) {
+					c.loadImport(m, item, nil)
+				}
+			}
+		}
+		return true
+	})
+	sort.Strings(c.HomeConfigurations)
+}
+
+// loadModule reads a module file and collects its options under prefix
+func (c *Config) loadModule(file string, prefix []string) {
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		file = filepath.Join(file, "default.nix")
+	}
+
+	expr, ok := c.read(file)
+	if !ok {
+		return
+	}
+	c.collectModule(&module{file: file}, expr, prefix)
+}
+
+// module is the file being collected and the simple let-bound strings in scope
+type module struct {
+	file  string
+	scope map[string]string
+}
+
+// withScope returns a copy of m with the string bindings of a let added
+func (m *module) withScope(bindings []*Binding) *module {
+	scope := make(map[string]string, len(m.scope))
+	for name, value := range m.scope {
+		scope[name] = value
+	}
+	for _, b := range bindings {
+		if s, ok := b.Value.(*String); ok && !s.Interpolated && len(b.Path) == 1 {
+			scope[b.Path[0]] = s.Value
+		}
+	}
+	return &module{file: m.file, scope: scope}
+}
+
+// collectModule collects a module: a set of options, possibly wrapped in a
+// function, let or with, with imports, options and config handled specially
+func (c *Config) collectModule(m *module, expr Expr, prefix []string) {
+	for {
+		switch e := unwrapMk(expr).(type) {
+		case *Lambda:
+			expr = e.Body
+			continue
+		case *Let:
+			m = m.withScope(e.Bindings)
+			expr = e.Body
+			continue
+		case *With:
+			expr = e.Body
+			continue
+		case *AttrSet:
+			for _, b := range e.Bindings {
+				path := m.resolve(b.Path)
+				switch path[0] {
+				case "imports":
+					for _, item := range listItems(b.Value) {
+						c.loadImport(m, item, prefix)
+					}
+					continue
+				case "options":
+					// Option declarations, not settings
+					continue
+				case "config":
+					path = path[1:]
+				}
+				c.collect(m, join(prefix, path), b.Value, b.Line)
+			}
+		default:
+			if items, ok := mkMergeItems(e); ok {
+				for _, item := range items {
+					c.collectModule(m, item, prefix)
+				}
+			}
+		}
+		return
+	}
+}
+
+// loadImport follows one entry of an imports or modules list
+func (c *Config) loadImport(m *module, item Expr, prefix []string) {
+	if file, ok := importedFile(m, item); ok {
+		c.loadModule(file, prefix)
+		return
+	}
+
+	// Inline modules
+	switch unwrapMk(item).(type) {
+	case *AttrSet, *Lambda, *Let:
+		c.collectModule(m, item, prefix)
+	}
+}
+
+// collect records the value assigned at path, descending into nested sets
+func (c *Config) collect(m *module, path []string, value Expr, line int) {
+	value = unwrapMk(value)
+	if let, ok := value.(*Let); ok {
+		m = m.withScope(let.Bindings)
+		value = unwrapMk(let.Body)
+	}
+
+	switch v := value.(type) {
+	case *AttrSet:
+		for _, b := range v.Bindings {
+			c.collect(m, join(path, m.resolve(b.Path)), b.Value, b.Line)
+		}
+		return
+	case *With:
+		if _, ok := unwrapMk(v.Body).(*AttrSet); ok {
+			c.collect(m, path, v.Body, line)
+			return
+		}
+	case *Lambda:
+		// home-manager.users.<name> = { pkgs, ... }: { ... }
+		if v.HasSet {
+			c.collectModule(m, v, path)
+			return
+		}
+	}
+
+	if items, ok := mkMergeItems(value); ok {
+		for _, item := range items {
+			c.collect(m, path, item, item.Position().Line)
+		}
+		return
+	}
+	if file, ok := importedFile(m, value); ok {
+		c.loadModule(file, path)
+		return
+	}
+
+	c.set(m, path, value, line)
+}
+
+// set stores one option value
+func (c *Config) set(m *module, path []string, value Expr, line int) {
+	if len(path) == 0 {
+		return
+	}
+	key := strings.Join(path, ".")
+
+	if isPackageOption(key) {
+		c.Packages = append(c.Packages, packageNames(value)...)
+		return
+	}
+
+	converted := Value(value)
+	if existing, ok := c.Options[key].([]any); ok {
+		if list, ok := converted.([]any); ok {
+			converted = append(existing, list...)
+		}
+	}
+	c.Options[key] = converted
+
+	if s, ok := value.(*String); ok && s.Indented {
+		line = s.ValueLine
+	}
+	c.Sources[key] = Source{File: c.rel(m.file), Line: line}
+}
+
+// rel returns file relative to the configuration root
+func (c *Config) rel(file string) string {
+	if rel, err := filepath.Rel(c.Root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+// resolve substitutes let-bound strings into dynamic attribute names
+func (m *module) resolve(path []string) []string {
+	resolved := make([]string, len(path))
+	for i, name := range path {
+		resolved[i] = name
+		if strings.HasPrefix(name, "${") && strings.HasSuffix(name, "}") {
+			if value, ok := m.scope[strings.TrimSpace(name[2:len(name)-1])]; ok {
+				resolved[i] = value
+			}
+		}
+	}
+	return resolved
+}
+
+// isPackageOption reports whether key is a package list: system or home
+// packages, or a user's packages
+func isPackageOption(key string) bool {
+	for _, option := range packageOptions {
+		if key == option || strings.HasSuffix(key, "."+option) {
+			return true
+		}
+	}
+	return strings.HasPrefix(key, "users.users.") && strings.HasSuffix(key, ".packages")
+}
+
+// importedFile returns the file an import list entry or import call refers to
+func importedFile(m *module, expr Expr) (string, bool) {
+	if apply, ok := expr.(*Apply); ok && funcName(apply.Func) == "import" {
+		expr = apply.Arg
+	}
+	path, ok := expr.(*Path)
+	if !ok {
+		return "", false
+	}
+	return resolvePath(filepath.Dir(m.file), path.Value)
+}
+
+// resolvePath resolves a path literal relative to dir; search paths like
+// <nixpkgs> cannot be resolved
+func resolvePath(dir, path string) (string, bool) {
+	switch {
+	case strings.HasPrefix(path, "<"):
+		return "", false
+	case strings.HasPrefix(path, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		return filepath.Join(home, path[2:]), true
+	case filepath.IsAbs(path):
+		return filepath.Clean(path), true
+	}
+	return filepath.Join(dir, path), true
+}
+
+// mkWrappers maps lib functions that wrap a value to their number of arguments
+var mkWrappers = map[string]int{
+	"mkDefault":       1,
+	"mkForce":         1,
+	"mkBefore":        1,
+	"mkAfter":         1,
+	"mkOptionDefault": 1,
+	"mkIf":            2,
+	"mkOverride":      2,
+	"mkOrder":         2,
+}
+
+// unwrapMk strips mkIf, mkDefault, mkForce and similar wrappers from a value
+func unwrapMk(expr Expr) Expr {
+	for {
+		head, args := flattenApply(expr)
+		n, ok := mkWrappers[funcName(head)]
+		if !ok || len(args) != n {
+			return expr
+		}
+		expr = args[n-1]
+	}
+}
+
+// mkMergeItems returns the values merged by mkMerge [ ... ]
+func mkMergeItems(expr Expr) ([]Expr, bool) {
+	head, args := flattenApply(expr)
+	if funcName(head) != "mkMerge" || len(args) != 1 {
+		return nil, false
+	}
+	return listItems(args[0]), true
+}
+
+// flattenApply splits f a b into f and [a b]
+func flattenApply(expr Expr) (Expr, []Expr) {
+	var args []Expr
+	for {
+		apply, ok := expr.(*Apply)
+		if !ok {
+			break
+		}
+		args = append([]Expr{apply.Arg}, args...)
+		expr = apply.Func
+	}
+	return expr, args
+}
+
+// funcName returns the name a function is called by: mkIf for both mkIf and lib.mkIf
+func funcName(expr Expr) string {
+	switch e := expr.(type) {
+	case *Ident:
+		return e.Name
+	case *Select:
+		return e.Path[len(e.Path)-1]
+	}
+	return ""
+}
+
+// listItems returns the items of a list expression, looking through with,
+// let, ++ and lib.optionals
+func listItems(expr Expr) []Expr {
+	switch e := unwrapMk(expr).(type) {
+	case *List:
+		return e.Items
+	case *With:
+		return listItems(e.Body)
+	case *Let:
+		return listItems(e.Body)
+	case *BinaryOp:
+		if e.Op == "++" {
+			return append(listItems(e.Left), listItems(e.Right)...)
+		}
+	case *Apply:
+		head, args := flattenApply(e)
+		switch funcName(head) {
+		case "optionals":
+			return listItems(args[len(args)-1])
+		case "optional":
+			return args[len(args)-1:]
+		}
+	}
+	return nil
+}
+
+// packageMethods are derivation helpers stripped from package names:
+// pkgs.python3.withPackages (...) is python3
+var packageMethods = map[string]bool{
+	"override":            true,
+	"overrideAttrs":       true,
+	"overridePythonAttrs": true,
+	"withPackages":        true,
+	"withPlugins":         true,
+}
+
+// isPackageSet reports whether name is likely a nixpkgs instance, like pkgs
+// or unstable, rather than a package
+func isPackageSet(name string) bool {
+	switch name {
+	case "unstable", "stable", "master", "nur":
+		return true
+	}
+	return strings.Contains(strings.ToLower(name), "pkgs")
+}
+
+// packageNames returns the names of the packages in a package list
+func packageNames(expr Expr) []string {
+	var names []string
+	for _, item := range listItems(expr) {
+		if name := packageName(item); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// packageName returns a package's attribute name: ripgrep for both ripgrep
+// (under with pkgs) and pkgs.ripgrep
+func packageName(expr Expr) string {
+	switch e := expr.(type) {
+	case *Ident:
+		return e.Name
+	case *Select:
+		path := e.Path
+		if base, ok := e.Expr.(*Ident); ok {
+			path = append([]string{base.Name}, path...)
+		} else if base := packageName(e.Expr); base != "" {
+			path = append([]string{base}, path...)
+		}
+		for len(path) > 1 && packageMethods[path[len(path)-1]] {
+			path = path[:len(path)-1]
+		}
+		if len(path) > 1 && isPackageSet(path[0]) {
+			path = path[1:]
+		}
+		return strings.Join(path, ".")
+	case *Apply:
+		head, args := flattenApply(e)
+		// pkgs.callPackage ./tool.nix { } is named after its file
+		if funcName(head) == "callPackage" && len(args) > 0 {
+			if path, ok := args[0].(*Path); ok {
+				return strings.TrimSuffix(filepath.Base(path.Value), ".nix")
+			}
+		}
+		return packageName(head)
+	}
+	return ""
+}
+
+// Value converts a literal expression to a Go value: string, bool, int64,
+// float64, nil, []any or map[string]any. Anything else, such as a function
+// call or a reference, is returned as its Nix source text.
+func Value(expr Expr) any {
+	switch e := unwrapMk(expr).(type) {
+	case *String:
+		return e.Value
+	case *Int:
+		return e.Value
+	case *Float:
+		return e.Value
+	case *Path:
+		return e.Value
+	case *Ident:
+		switch e.Name {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+	case *UnaryOp:
+		if e.Op == "-" {
+			switch v := Value(e.Expr).(type) {
+			case int64:
+				return -v
+			case float64:
+				return -v
+			}
+		}
+	case *List:
+		items := make([]any, 0, len(e.Items))
+		for _, item := range e.Items {
+			items = append(items, Value(item))
+		}
+		return items
+	case *AttrSet:
+		set := make(map[string]any, len(e.Bindings))
+		for _, b := range e.Bindings {
+			set[strings.Join(b.Path, ".")] = Value(b.Value)
+		}
+		return set
+	}
+	return Format(expr)
+}
+
+// join returns a new slice of prefix followed by path
+func join(prefix, path []string) []string {
+	joined := make([]string, 0, len(prefix)+len(path))
+	return append(append(joined, prefix...), path...)
+}
+
+// dedupe removes adjacent duplicates from a sorted slice
+func dedupe(sorted []string) []string {
+	var out []string
+	for i, s := range sorted {
+		if i == 0 || s != sorted[i-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
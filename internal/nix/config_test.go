@@ -0,0 +1,146 @@
+package nix
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigFlake(t *testing.T) {
+	cfg, err := ParseConfig(filepath.Join("testdata", "flake"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	if !cfg.IsFlake {
+		t.Error("Expected a flake")
+	}
+
+	wantFiles := []string{
+		"flake.nix",
+		"hosts/laptop/configuration.nix",
+		"hosts/laptop/hardware-configuration.nix",
+		"modules/default.nix",
+		"modules/desktop.nix",
+		"home.nix",
+	}
+	if !reflect.DeepEqual(cfg.Files, wantFiles) {
+		t.Errorf("Files = %v, want %v", cfg.Files, wantFiles)
+	}
+
+	wantPackages := []string{"fd", "firefox", "git", "python3", "ripgrep", "vim", "xclip"}
+	if !reflect.DeepEqual(cfg.Packages, wantPackages) {
+		t.Errorf("Packages = %v, want %v", cfg.Packages, wantPackages)
+	}
+
+	wantOptions := map[string]any{
+		"boot.loader.systemd-boot.enable":                   true,
+		"networking.hostName":                               "laptop",
+		"services.openssh.enable":                           true,
+		"services.openssh.settings.PasswordAuthentication":  false,
+		"users.users.jeff.isNormalUser":                     true,
+		"users.users.jeff.extraGroups":                      []any{"wheel", "networkmanager"},
+		"nix.settings.max-jobs":                             int64(8),
+		`fileSystems./.fsType`:                              "ext4",
+		"services.xserver.enable":                           true,
+		"programs.sway.enable":                              true,
+		"fonts.packages":                                    []any{"pkgs.fira-code"},
+		"home-manager.useGlobalPkgs":                        true,
+		"home-manager.users.jeff.programs.git.userName":     "Jeff",
+		"home-manager.users.jeff.programs.tmux.extraConfig": "set -g prefix C-a\nbind | split-window -h\n",
+	}
+	for key, want := range wantOptions {
+		if got, ok := cfg.Options[key]; !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("Options[%q] = %#v, want %#v", key, got, want)
+		}
+	}
+
+	// Declarations and imports are not settings; packages are listed separately
+	for _, key := range []string{"imports", "options.my.desktop.enable", "my.desktop.enable", "environment.systemPackages", "home-manager.users.jeff.home.packages"} {
+		if _, ok := cfg.Options[key]; ok {
+			t.Errorf("Did not expect option %q", key)
+		}
+	}
+
+	if src := cfg.Sources["networking.hostName"]; src != (Source{File: "hosts/laptop/configuration.nix", Line: 13}) {
+		t.Errorf("Unexpected source for networking.hostName: %+v", src)
+	}
+	if src := cfg.Sources["home-manager.users.jeff.programs.tmux.extraConfig"]; src != (Source{File: "home.nix", Line: 14}) {
+		t.Errorf("Unexpected source for tmux extraConfig: %+v", src)
+	}
+	if src := cfg.Sources["home-manager.useGlobalPkgs"]; src.File != "flake.nix" {
+		t.Errorf("Expected inline flake module option to come from flake.nix, got %+v", src)
+	}
+
+	if len(cfg.Warnings) != 0 {
+		t.Errorf("Unexpected warnings: %v", cfg.Warnings)
+	}
+	if len(cfg.Hash) != 64 {
+		t.Errorf("Expected a SHA-256 hash, got %q", cfg.Hash)
+	}
+}
+
+func TestParseConfigClassic(t *testing.T) {
+	cfg, err := ParseConfig(filepath.Join("testdata", "classic"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	if cfg.IsFlake {
+		t.Error("Did not expect a flake")
+	}
+
+	// Packages from every module are merged, as are lists
+	wantPackages := []string{"git", "htop", "jq"}
+	if !reflect.DeepEqual(cfg.Packages, wantPackages) {
+		t.Errorf("Packages = %v, want %v", cfg.Packages, wantPackages)
+	}
+	if got := cfg.Options["boot.kernelModules"]; !reflect.DeepEqual(got, []any{"kvm-intel", "wl"}) {
+		t.Errorf("Expected kernel modules to be merged, got %#v", got)
+	}
+	if cfg.Options["services.printing.enable"] != false {
+		t.Errorf("Expected option from import call, got %#v", cfg.Options["services.printing.enable"])
+	}
+
+	// A broken import is reported but does not stop parsing
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "broken.nix:3:") {
+		t.Errorf("Expected a syntax warning for broken.nix, got %v", cfg.Warnings)
+	}
+}
+
+func TestParseConfigSingleFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "host.nix")
+	src := `{ lib, ... }: {
+  services = lib.mkMerge [
+    { nginx.enable = true; }
+    { postgresql.enable = lib.mkForce false; }
+  ];
+  programs.zsh.shellAliases = { ll = "ls -l"; };
+}
+`
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ParseConfig(file)
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	want := map[string]any{
+		"services.nginx.enable":        true,
+		"services.postgresql.enable":   false,
+		"programs.zsh.shellAliases.ll": "ls -l",
+	}
+	if !reflect.DeepEqual(cfg.Options, want) {
+		t.Errorf("Options = %#v, want %#v", cfg.Options, want)
+	}
+}
+
+func TestParseConfigMissingEntry(t *testing.T) {
+	if _, err := ParseConfig(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without a configuration")
+	}
+}
@@ -0,0 +1,228 @@
+package nix
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LockFile is the flake lock file name
+const LockFile = "flake.lock"
+
+// FlakeInput is one input of a flake, as declared in flake.nix and locked in flake.lock
+type FlakeInput struct {
+	Name         string
+	URL          string            // As declared, or rebuilt from the lock's original reference
+	Follows      string            // Set when this input follows another instead of being locked itself
+	InputFollows map[string]string // This input's own inputs redirected to ours, e.g. nixpkgs -> nixpkgs
+	NonFlake     bool              // Declared with flake = false
+
+	// From flake.lock; Rev is empty when the input is not locked
+	Type         string    // github, git, path, tarball, ...
+	Ref          string    // Branch or tag the input tracks
+	Rev          string    // Locked commit
+	NarHash      string    // Content hash of the locked source
+	LastModified time.Time // Time of the locked commit
+}
+
+// flakeLock is the JSON structure of flake.lock
+type flakeLock struct {
+	Nodes   map[string]lockNode `json:"nodes"`
+	Root    string              `json:"root"`
+	Version int                 `json:"version"`
+}
+
+// lockNode is one node of the lock graph. An input reference is either a
+// node name or, for follows, a path of input names from the root.
+type lockNode struct {
+	Inputs   map[string]json.RawMessage `json:"inputs"`
+	Locked   *lockRef                   `json:"locked"`
+	Original *lockRef                   `json:"original"`
+	Flake    *bool                      `json:"flake"`
+}
+
+// lockRef is a locked or original flake reference
+type lockRef struct {
+	Type         string `json:"type"`
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	Repo         string `json:"repo"`
+	Ref          string `json:"ref"`
+	Rev          string `json:"rev"`
+	URL          string `json:"url"`
+	Path         string `json:"path"`
+	NarHash      string `json:"narHash"`
+	LastModified int64  `json:"lastModified"`
+}
+
+// flakeInputs reads the inputs declared in a flake.nix expression
+func flakeInputs(expr Expr) map[string]*FlakeInput {
+	inputs := make(map[string]*FlakeInput)
+
+	set, ok := expr.(*AttrSet)
+	if !ok {
+		return inputs
+	}
+
+	input := func(name string) *FlakeInput {
+		if inputs[name] == nil {
+			inputs[name] = &FlakeInput{Name: name}
+		}
+		return inputs[name]
+	}
+
+	flatten(nil, set, func(path []string, value Expr) {
+		if len(path) < 2 || path[0] != "inputs" {
+			return
+		}
+		in := input(path[1])
+		s, isString := value.(*String)
+
+		switch rest := path[2:]; {
+		case len(rest) == 0 && isString:
+			// inputs.foo = "github:owner/repo";
+			in.URL = s.Value
+		case len(rest) == 1 && rest[0] == "url" && isString:
+			in.URL = s.Value
+		case len(rest) == 1 && rest[0] == "follows" && isString:
+			in.Follows = s.Value
+		case len(rest) == 1 && rest[0] == "flake":
+			in.NonFlake = Value(value) == false
+		case len(rest) == 3 && rest[0] == "inputs" && rest[2] == "follows" && isString:
+			if in.InputFollows == nil {
+				in.InputFollows = make(map[string]string)
+			}
+			in.InputFollows[rest[1]] = s.Value
+		}
+	})
+
+	return inputs
+}
+
+// flatten calls fn with the full attribute path of every non-set value in set
+func flatten(prefix []string, set *AttrSet, fn func(path []string, value Expr)) {
+	for _, b := range set.Bindings {
+		path := join(prefix, b.Path)
+		if nested, ok := b.Value.(*AttrSet); ok {
+			flatten(path, nested, fn)
+			continue
+		}
+		fn(path, b.Value)
+	}
+}
+
+// applyLock adds locked revisions from a flake.lock to the declared inputs
+func applyLock(inputs map[string]*FlakeInput, data []byte) error {
+	var lock flakeLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return fmt.Errorf("invalid %s: %w", LockFile, err)
+	}
+
+	root, ok := lock.Nodes[lock.Root]
+	if !ok {
+		return fmt.Errorf("invalid %s: missing root node %q", LockFile, lock.Root)
+	}
+
+	for name, raw := range root.Inputs {
+		in := inputs[name]
+		if in == nil {
+			in = &FlakeInput{Name: name}
+			inputs[name] = in
+		}
+
+		nodeName, follows := lockInputRef(raw)
+		if follows != "" {
+			in.Follows = follows
+			continue
+		}
+		node, ok := lock.Nodes[nodeName]
+		if !ok {
+			continue
+		}
+
+		for sub, subRaw := range node.Inputs {
+			if _, target := lockInputRef(subRaw); target != "" {
+				if in.InputFollows == nil {
+					in.InputFollows = make(map[string]string)
+				}
+				in.InputFollows[sub] = target
+			}
+		}
+		if node.Flake != nil && !*node.Flake {
+			in.NonFlake = true
+		}
+		if node.Original != nil {
+			in.Ref = node.Original.Ref
+			if in.URL == "" {
+				in.URL = node.Original.String()
+			}
+		}
+		if node.Locked != nil {
+			in.Type = node.Locked.Type
+			in.Rev = node.Locked.Rev
+			in.NarHash = node.Locked.NarHash
+			if node.Locked.LastModified > 0 {
+				in.LastModified = time.Unix(node.Locked.LastModified, 0).UTC()
+			}
+		}
+	}
+
+	return nil
+}
+
+// lockInputRef decodes an input reference: a node name, or a follows path
+// returned joined with "/"
+func lockInputRef(raw json.RawMessage) (node, follows string) {
+	if err := json.Unmarshal(raw, &node); err == nil {
+		return node, ""
+	}
+	var path []string
+	if err := json.Unmarshal(raw, &path); err == nil {
+		return "", strings.Join(path, "/")
+	}
+	return "", ""
+}
+
+// String renders a reference in flake URL syntax, e.g. github:NixOS/nixpkgs/nixos-unstable
+func (r *lockRef) String() string {
+	switch r.Type {
+	case "github", "gitlab", "sourcehut":
+		url := fmt.Sprintf("%s:%s/%s", r.Type, r.Owner, r.Repo)
+		if r.Ref != "" {
+			url += "/" + r.Ref
+		}
+		return url
+	case "path":
+		return "path:" + r.Path
+	case "indirect":
+		return "flake:" + r.ID
+	}
+	if r.URL != "" {
+		return r.Type + "+" + r.URL
+	}
+	return r.Type
+}
+
+// loadFlakeInputs reads the inputs of the flake at file and its lock file
+func (c *Config) loadFlakeInputs(file string, expr Expr) {
+	inputs := flakeInputs(expr)
+
+	lockPath := filepath.Join(filepath.Dir(file), LockFile)
+	if data, err := os.ReadFile(lockPath); err == nil {
+		c.digest.Write([]byte(lockPath))
+		c.digest.Write(data)
+		if err := applyLock(inputs, data); err != nil {
+			c.Warnings = append(c.Warnings, err.Error())
+		}
+	}
+
+	c.Inputs = make([]FlakeInput, 0, len(inputs))
+	for _, in := range inputs {
+		c.Inputs = append(c.Inputs, *in)
+	}
+	sort.Slice(c.Inputs, func(i, j int) bool { return c.Inputs[i].Name < c.Inputs[j].Name })
+}
@@ -0,0 +1,90 @@
+package nix
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseConfigFlakeInputs(t *testing.T) {
+	cfg, err := ParseConfig(filepath.Join("testdata", "flake"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	want := []FlakeInput{
+		{
+			Name:         "home-manager",
+			URL:          "github:nix-community/home-manager",
+			InputFollows: map[string]string{"nixpkgs": "nixpkgs"},
+			Type:         "github",
+			Rev:          "a7117efb3725e6197dd95424136f79147aa35e5b",
+			NarHash:      "sha256-5z2422pzWnPXHgq2ms8lcCfttM0dz+hg+x1pCcNkAws=",
+			LastModified: time.Unix(1717525419, 0).UTC(),
+		},
+		{
+			Name:         "nixpkgs",
+			URL:          "github:NixOS/nixpkgs/nixos-unstable",
+			Type:         "github",
+			Ref:          "nixos-unstable",
+			Rev:          "9f4128e00b0ae8ec65918efeba59db998750ead6",
+			NarHash:      "sha256-rwz8NJZV+387rnWpTYcXaRNvzUSnnF9aHONoJIYmiUQ=",
+			LastModified: time.Unix(1720031269, 0).UTC(),
+		},
+		{
+			// Locked but no longer declared: the URL comes from the lock
+			Name:         "nixpkgs-stable",
+			URL:          "github:NixOS/nixpkgs/nixos-24.05",
+			Type:         "github",
+			Ref:          "nixos-24.05",
+			Rev:          "706eef542dec88cc0ed25b9075d3037564b2d164",
+			NarHash:      "sha256-nNJHJ9kfPdzYsCOlHOnbiiyKjZUW5sWbwx3cakg3/C4=",
+			LastModified: time.Unix(1719956923, 0).UTC(),
+		},
+	}
+	if !reflect.DeepEqual(cfg.Inputs, want) {
+		t.Errorf("Inputs = %+v\nwant %+v", cfg.Inputs, want)
+	}
+}
+
+func TestFlakeInputsDeclarations(t *testing.T) {
+	expr, err := Parse(`{
+  inputs.nixpkgs.url = "github:NixOS/nixpkgs/nixos-unstable";
+  inputs.dotfiles = { url = "git+https://example.com/dotfiles"; flake = false; };
+  inputs.unstable.follows = "nixpkgs";
+  inputs.hm = {
+    url = "github:nix-community/home-manager";
+    inputs.nixpkgs.follows = "nixpkgs";
+  };
+  outputs = { self, ... }: { };
+}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := flakeInputs(expr)
+	if len(inputs) != 4 {
+		t.Fatalf("Expected 4 inputs, got %d", len(inputs))
+	}
+	if inputs["nixpkgs"].URL != "github:NixOS/nixpkgs/nixos-unstable" {
+		t.Errorf("Unexpected nixpkgs input: %+v", inputs["nixpkgs"])
+	}
+	if !inputs["dotfiles"].NonFlake {
+		t.Errorf("Expected dotfiles to be a non-flake input")
+	}
+	if inputs["unstable"].Follows != "nixpkgs" {
+		t.Errorf("Expected unstable to follow nixpkgs, got %+v", inputs["unstable"])
+	}
+	if inputs["hm"].InputFollows["nixpkgs"] != "nixpkgs" {
+		t.Errorf("Expected hm's nixpkgs to follow ours, got %+v", inputs["hm"])
+	}
+}
+
+func TestApplyLockErrors(t *testing.T) {
+	for _, data := range []string{`not json`, `{"nodes": {}, "root": "root"}`} {
+		if err := applyLock(map[string]*FlakeInput{}, []byte(data)); err == nil {
+			t.Errorf("Expected an error for %q", data)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package nix
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format renders an expression back to compact, single-line Nix source. It
+// is used to show values codex does not interpret, such as function calls.
+func Format(expr Expr) string {
+	var sb strings.Builder
+	format(&sb, expr)
+	return sb.String()
+}
+
+func format(sb *strings.Builder, expr Expr) {
+	switch e := expr.(type) {
+	case nil:
+		sb.WriteString("null")
+	case *Ident:
+		sb.WriteString(e.Name)
+	case *Int:
+		sb.WriteString(strconv.FormatInt(e.Value, 10))
+	case *Float:
+		sb.WriteString(strconv.FormatFloat(e.Value, 'g', -1, 64))
+	case *String:
+		sb.WriteString(strconv.Quote(e.Value))
+	case *Path:
+		sb.WriteString(e.Value)
+	case *URI:
+		sb.WriteString(e.Value)
+	case *List:
+		sb.WriteString("[")
+		for _, item := range e.Items {
+			sb.WriteString(" ")
+			formatOperand(sb, item)
+		}
+		sb.WriteString(" ]")
+	case *AttrSet:
+		if e.Rec {
+			sb.WriteString("rec ")
+		}
+		sb.WriteString("{")
+		for _, b := range e.Bindings {
+			fmt.Fprintf(sb, " %s = ", strings.Join(b.Path, "."))
+			format(sb, b.Value)
+			sb.WriteString(";")
+		}
+		sb.WriteString(" }")
+	case *Select:
+		formatOperand(sb, e.Expr)
+		sb.WriteString("." + strings.Join(e.Path, "."))
+		if e.Default != nil {
+			sb.WriteString(" or ")
+			formatOperand(sb, e.Default)
+		}
+	case *HasAttr:
+		formatOperand(sb, e.Expr)
+		sb.WriteString(" ? " + strings.Join(e.Path, "."))
+	case *Apply:
+		if _, ok := e.Func.(*Apply); ok {
+			format(sb, e.Func)
+		} else {
+			formatOperand(sb, e.Func)
+		}
+		sb.WriteString(" ")
+		formatOperand(sb, e.Arg)
+	case *Lambda:
+		if e.HasSet {
+			var names []string
+			for _, f := range e.Formals {
+				names = append(names, f.Name)
+			}
+			if e.Ellipsis {
+				names = append(names, "...")
+			}
+			if len(names) == 0 {
+				sb.WriteString("{ }")
+			} else {
+				sb.WriteString("{ " + strings.Join(names, ", ") + " }")
+			}
+			if e.Arg != "" {
+				sb.WriteString("@" + e.Arg)
+			}
+		} else {
+			sb.WriteString(e.Arg)
+		}
+		sb.WriteString(": ")
+		format(sb, e.Body)
+	case *Let:
+		sb.WriteString("let")
+		for _, b := range e.Bindings {
+			fmt.Fprintf(sb, " %s = ", strings.Join(b.Path, "."))
+			format(sb, b.Value)
+			sb.WriteString(";")
+		}
+		sb.WriteString(" in ")
+		format(sb, e.Body)
+	case *With:
+		sb.WriteString("with ")
+		format(sb, e.Env)
+		sb.WriteString("; ")
+		format(sb, e.Body)
+	case *If:
+		sb.WriteString("if ")
+		format(sb, e.Cond)
+		sb.WriteString(" then ")
+		format(sb, e.Then)
+		sb.WriteString(" else ")
+		format(sb, e.Else)
+	case *Assert:
+		sb.WriteString("assert ")
+		format(sb, e.Cond)
+		sb.WriteString("; ")
+		format(sb, e.Body)
+	case *BinaryOp:
+		formatOperand(sb, e.Left)
+		sb.WriteString(" " + e.Op + " ")
+		formatOperand(sb, e.Right)
+	case *UnaryOp:
+		sb.WriteString(e.Op)
+		formatOperand(sb, e.Expr)
+	default:
+		fmt.Fprintf(sb, "<%T>", expr)
+	}
+}
+
+// formatOperand formats an expression, parenthesized unless it is atomic
+func formatOperand(sb *strings.Builder, expr Expr) {
+	switch expr.(type) {
+	case *Ident, *Int, *Float, *String, *Path, *URI, *List, *AttrSet, *Select, nil:
+		format(sb, expr)
+	default:
+		sb.WriteString("(")
+		format(sb, expr)
+		sb.WriteString(")")
+	}
+}
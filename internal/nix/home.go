@@ -0,0 +1,110 @@
+package nix
+
+import (
+	"path"
+	"strings"
+)
+
+// Program is a tool configured through programs.<name> options
+type Program struct {
+	Name     string
+	Settings map[string]any    // Options below programs.<name>, e.g. "userName" or "settings.user.email"
+	Sources  map[string]Source // Where each setting was set
+}
+
+// homeManagerUserPrefix is where a NixOS configuration sets a user's home-manager options
+const homeManagerUserPrefix = "home-manager.users."
+
+// homeConfigurationNames returns the homeConfigurations a flake binding defines,
+// for homeConfigurations.<name> = ... and homeConfigurations = { <name> = ...; }
+func homeConfigurationNames(b *Binding) []string {
+	for i, name := range b.Path {
+		if name != "homeConfigurations" {
+			continue
+		}
+		if i+1 < len(b.Path) {
+			return []string{b.Path[i+1]}
+		}
+
+		var names []string
+		if set, ok := b.Value.(*AttrSet); ok {
+			for _, nested := range set.Bindings {
+				names = append(names, nested.Path[0])
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// IsHomeManager reports whether the configuration uses home-manager: a
+// flake with homeConfigurations, a home.nix, home-manager options in a NixOS
+// configuration, or programs.<name> settings outside of a NixOS configuration
+func (c *Config) IsHomeManager() bool {
+	if len(c.HomeConfigurations) > 0 {
+		return true
+	}
+
+	nixos := false
+	for _, file := range c.Files {
+		switch path.Base(file) {
+		case HomeFile:
+			return true
+		case ConfigurationFile:
+			nixos = true
+		}
+	}
+
+	programs := false
+	for key := range c.Options {
+		if strings.HasPrefix(key, "home.") || strings.HasPrefix(key, homeManagerUserPrefix) {
+			return true
+		}
+		if strings.HasPrefix(key, "programs.") {
+			programs = true
+		}
+	}
+	return programs && !nixos
+}
+
+// Programs returns the programs configured through programs.<name> options,
+// keyed by name, including those set for home-manager users in a NixOS
+// configuration. Programs explicitly disabled are left out.
+func (c *Config) Programs() map[string]*Program {
+	programs := make(map[string]*Program)
+
+	for key, value := range c.Options {
+		option := key
+		if strings.HasPrefix(option, homeManagerUserPrefix) {
+			// home-manager.users.<user>.programs...
+			parts := strings.SplitN(strings.TrimPrefix(option, homeManagerUserPrefix), ".", 2)
+			if len(parts) < 2 {
+				continue
+			}
+			option = parts[1]
+		}
+
+		parts := strings.SplitN(option, ".", 3)
+		if len(parts) < 3 || parts[0] != "programs" {
+			continue
+		}
+		name, setting := parts[1], parts[2]
+
+		program := programs[name]
+		if program == nil {
+			program = &Program{Name: name, Settings: make(map[string]any), Sources: make(map[string]Source)}
+			programs[name] = program
+		}
+		program.Settings[setting] = value
+		if src, ok := c.Sources[key]; ok {
+			program.Sources[setting] = src
+		}
+	}
+
+	for name, program := range programs {
+		if program.Settings["enable"] == false {
+			delete(programs, name)
+		}
+	}
+	return programs
+}
@@ -0,0 +1,110 @@
+package nix
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHomeManagerFlake(t *testing.T) {
+	cfg, err := ParseConfig(filepath.Join("testdata", "home"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.HomeConfigurations, []string{"jeff@laptop"}) {
+		t.Errorf("HomeConfigurations = %v", cfg.HomeConfigurations)
+	}
+	if !cfg.IsHomeManager() {
+		t.Error("Expected a home-manager configuration")
+	}
+
+	programs := cfg.Programs()
+	if _, ok := programs["bat"]; ok {
+		t.Error("Expected disabled program to be left out")
+	}
+
+	var names []string
+	for name := range programs {
+		names = append(names, name)
+	}
+	if len(names) != 3 || programs["git"] == nil || programs["tmux"] == nil || programs["fzf"] == nil {
+		t.Fatalf("Expected git, tmux and fzf, got %v", names)
+	}
+
+	wantGit := map[string]any{
+		"enable":                         true,
+		"userName":                       "Jeff Muter",
+		"userEmail":                      "jeff@example.com",
+		"aliases.co":                     "checkout",
+		"extraConfig.init.defaultBranch": "main",
+	}
+	if !reflect.DeepEqual(programs["git"].Settings, wantGit) {
+		t.Errorf("git settings = %#v, want %#v", programs["git"].Settings, wantGit)
+	}
+	if src := programs["git"].Sources["userName"]; src != (Source{File: "programs/git.nix", Line: 4}) {
+		t.Errorf("Unexpected source for git userName: %+v", src)
+	}
+
+	tmux := programs["tmux"]
+	if tmux.Settings["extraConfig"] != "set -g prefix C-a\nbind r source-file ~/.config/tmux/tmux.conf\n" {
+		t.Errorf("Unexpected tmux extraConfig: %q", tmux.Settings["extraConfig"])
+	}
+	if src := tmux.Sources["extraConfig"]; src != (Source{File: "programs/tmux.nix", Line: 8}) {
+		t.Errorf("Unexpected source for tmux extraConfig: %+v", src)
+	}
+}
+
+func TestHomeManagerInNixOS(t *testing.T) {
+	cfg, err := ParseConfig(filepath.Join("testdata", "flake"))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+
+	if !cfg.IsHomeManager() {
+		t.Error("Expected home-manager users to be detected")
+	}
+
+	programs := cfg.Programs()
+	if programs["git"] == nil || programs["git"].Settings["userName"] != "Jeff" {
+		t.Errorf("Expected git settings from home-manager.users.jeff, got %+v", programs["git"])
+	}
+	if programs["sway"] == nil {
+		t.Error("Expected NixOS programs to be included too")
+	}
+}
+
+func TestIsHomeManager(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  bool
+	}{
+		{"nixos", map[string]string{"configuration.nix": "{ programs.sway.enable = true; }"}, false},
+		{"home.nix", map[string]string{"home.nix": "{ }"}, true},
+		{"programs module", map[string]string{"git.nix": "{ programs.git.enable = true; }"}, true},
+		{"plain module", map[string]string{"git.nix": "{ services.foo.enable = true; }"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var entry string
+			for name, content := range tt.files {
+				entry = filepath.Join(dir, name)
+				if err := os.WriteFile(entry, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := ParseConfig(entry)
+			if err != nil {
+				t.Fatalf("ParseConfig failed: %v", err)
+			}
+			if got := cfg.IsHomeManager(); got != tt.want {
+				t.Errorf("IsHomeManager() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package nix
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tokenKind classifies a lexical token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokInt
+	tokFloat
+	tokString    // "..."; text is the unescaped value with interpolations kept as ${...}
+	tokIndString // ''...''; text has the common indentation removed
+	tokPath      // ./foo, /etc/foo, ~/foo, <nixpkgs>
+	tokURI       // github:owner/repo
+	tokInterp    // ${...} outside a string, as an attribute name
+	tokOp        // Punctuation and operators
+)
+
+// token is one lexical token. Line and col are 1-based.
+type token struct {
+	kind tokenKind
+	text string
+	line int
+	col  int
+
+	// For strings: whether they contain ${...}, and the line their value starts on
+	interpolated bool
+	valueLine    int
+}
+
+// keywords cannot be used as identifiers
+var keywords = map[string]bool{
+	"if": true, "then": true, "else": true, "assert": true, "with": true,
+	"let": true, "in": true, "rec": true, "inherit": true, "or": true,
+}
+
+// Patterns from the Nix lexer; all are anchored to the current position
+var (
+	identRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_'-]*`)
+	floatRe = regexp.MustCompile(`^(([1-9][0-9]*\.[0-9]*)|(0?\.[0-9]+))([Ee][+-]?[0-9]+)?`)
+	intRe   = regexp.MustCompile(`^[0-9]+`)
+	pathRe  = regexp.MustCompile(`^[a-zA-Z0-9._+-]*(/[a-zA-Z0-9._+-]+)+/?`)
+	homeRe  = regexp.MustCompile(`^~(/[a-zA-Z0-9._+-]+)+/?`)
+	spathRe = regexp.MustCompile(`^<[a-zA-Z0-9._+-]+(/[a-zA-Z0-9._+-]+)*>`)
+	uriRe   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:[a-zA-Z0-9%/?:@&=+$,_.!~*'-]+`)
+)
+
+// operators, longest first so "..." wins over "."
+var operators = []string{
+	"...", "++", "//", "==", "!=", "<=", ">=", "&&", "||", "->",
+	"{", "}", "[", "]", "(", ")", ";", ":", ",", ".", "=", "?", "@", "!",
+	"+", "-", "*", "/", "<", ">",
+}
+
+// lexer splits Nix source into tokens
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+// tokenize returns every token in src, ending with tokEOF
+func tokenize(src string) ([]token, error) {
+	l := &lexer{src: src, line: 1, col: 1}
+
+	var tokens []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// errorf returns a lexing error at the current position
+func (l *lexer) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: l.line, Col: l.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// advance moves past n bytes, tracking lines and columns
+func (l *lexer) advance(n int) string {
+	text := l.src[l.pos : l.pos+n]
+	for _, c := range text {
+		if c == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+	l.pos += n
+	return text
+}
+
+// skipSpace skips whitespace and comments
+func (l *lexer) skipSpace() error {
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		switch {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r':
+			l.advance(1)
+		case rest[0] == '#':
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			l.advance(end)
+		case strings.HasPrefix(rest, "/*"):
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				return l.errorf("unterminated comment")
+			}
+			l.advance(end + 4)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// next returns the next token
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpace(); err != nil {
+		return token{}, err
+	}
+
+	tok := token{line: l.line, col: l.col}
+	if l.pos >= len(l.src) {
+		tok.kind = tokEOF
+		return tok, nil
+	}
+
+	rest := l.src[l.pos:]
+	switch {
+	case rest[0] == '"':
+		return l.lexString(tok)
+	case strings.HasPrefix(rest, "''"):
+		return l.lexIndString(tok)
+	case strings.HasPrefix(rest, "${"):
+		inner, err := l.lexInterpolation()
+		if err != nil {
+			return token{}, err
+		}
+		tok.kind, tok.text = tokInterp, inner
+		return tok, nil
+	}
+
+	// Paths and URIs before identifiers and numbers, which they start like
+	for _, m := range []struct {
+		re   *regexp.Regexp
+		kind tokenKind
+	}{
+		{spathRe, tokPath},
+		{homeRe, tokPath},
+		{pathRe, tokPath},
+		{uriRe, tokURI},
+		{floatRe, tokFloat},
+		{intRe, tokInt},
+		{identRe, tokIdent},
+	} {
+		if loc := m.re.FindStringIndex(rest); loc != nil {
+			tok.kind, tok.text = m.kind, l.advance(loc[1])
+			return tok, nil
+		}
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			tok.kind, tok.text = tokOp, l.advance(len(op))
+			return tok, nil
+		}
+	}
+
+	return token{}, l.errorf("unexpected character %q", rest[0])
+}
+
+// lexString reads a double-quoted string
+func (l *lexer) lexString(tok token) (token, error) {
+	l.advance(1)
+	tok.kind = tokString
+	tok.valueLine = l.line
+
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		switch {
+		case rest[0] == '"':
+			l.advance(1)
+			tok.text = sb.String()
+			return tok, nil
+		case rest[0] == '\\' && len(rest) > 1:
+			l.advance(2)
+			switch rest[1] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(rest[1])
+			}
+		case strings.HasPrefix(rest, "$${"):
+			sb.WriteString(l.advance(3))
+		case strings.HasPrefix(rest, "${"):
+			inner, err := l.lexInterpolation()
+			if err != nil {
+				return token{}, err
+			}
+			sb.WriteString("${" + inner + "}")
+			tok.interpolated = true
+		default:
+			sb.WriteString(l.advance(1))
+		}
+	}
+	return token{}, &SyntaxError{Line: tok.line, Col: tok.col, Msg: "unterminated string"}
+}
+
+// lexIndString reads an indented ”string” and strips its common indentation
+func (l *lexer) lexIndString(tok token) (token, error) {
+	l.advance(2)
+	tok.kind = tokIndString
+	tok.valueLine = l.line
+
+	var sb strings.Builder
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		switch {
+		case strings.HasPrefix(rest, "'''"):
+			l.advance(3)
+			sb.WriteString("''")
+		case strings.HasPrefix(rest, "''$"):
+			l.advance(3)
+			sb.WriteString("$")
+		case strings.HasPrefix(rest, `''\`) && len(rest) > 3:
+			l.advance(4)
+			switch rest[3] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(rest[3])
+			}
+		case strings.HasPrefix(rest, "''"):
+			l.advance(2)
+			value, skipped := stripIndentation(sb.String())
+			tok.text = value
+			tok.valueLine += skipped
+			return tok, nil
+		case strings.HasPrefix(rest, "${"):
+			inner, err := l.lexInterpolation()
+			if err != nil {
+				return token{}, err
+			}
+			sb.WriteString("${" + inner + "}")
+			tok.interpolated = true
+		default:
+			sb.WriteString(l.advance(1))
+		}
+	}
+	return token{}, &SyntaxError{Line: tok.line, Col: tok.col, Msg: "unterminated indented string"}
+}
+
+// lexInterpolation reads a ${...} and returns its raw inner text, skipping
+// over nested braces and strings
+func (l *lexer) lexInterpolation() (string, error) {
+	startLine, startCol := l.line, l.col
+	l.advance(2)
+	start := l.pos
+
+	depth := 1
+	for l.pos < len(l.src) {
+		rest := l.src[l.pos:]
+		switch {
+		case rest[0] == '{':
+			depth++
+			l.advance(1)
+		case rest[0] == '}':
+			depth--
+			if depth == 0 {
+				inner := l.src[start:l.pos]
+				l.advance(1)
+				return inner, nil
+			}
+			l.advance(1)
+		case rest[0] == '"':
+			if _, err := l.lexString(token{line: l.line, col: l.col}); err != nil {
+				return "", err
+			}
+		case strings.HasPrefix(rest, "''"):
+			if _, err := l.lexIndString(token{line: l.line, col: l.col}); err != nil {
+				return "", err
+			}
+		default:
+			l.advance(1)
+		}
+	}
+	return "", &SyntaxError{Line: startLine, Col: startCol, Msg: "unterminated ${"}
+}
+
+// stripIndentation removes the indentation shared by every non-blank line,
+// and a first line that is only whitespace, as Nix does for ”strings”. It
+// returns how many leading lines were dropped.
+func stripIndentation(s string) (string, int) {
+	lines := strings.Split(s, "\n")
+
+	// Lines of only spaces do not count towards the common indentation
+	minIndent := -1
+	for _, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" {
+			continue
+		}
+		if indent := len(line) - len(trimmed); minIndent < 0 || indent < minIndent {
+			minIndent = indent
+		}
+	}
+	if minIndent < 0 {
+		minIndent = 0
+	}
+
+	for i, line := range lines {
+		if len(line) >= minIndent {
+			lines[i] = line[minIndent:]
+		} else {
+			lines[i] = strings.TrimLeft(line, " ")
+		}
+	}
+
+	skipped := 0
+	if len(lines) > 1 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+		skipped = 1
+	}
+	// The line holding the closing '' contributes only its indentation
+	if last := len(lines) - 1; last > 0 && strings.TrimSpace(lines[last]) == "" {
+		lines[last] = ""
+	}
+
+	return strings.Join(lines, "\n"), skipped
+}
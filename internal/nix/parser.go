@@ -0,0 +1,602 @@
+package nix
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Parse parses a Nix expression
+func Parse(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q after expression", tok.text)
+	}
+	return expr, nil
+}
+
+// ParseFile parses the Nix file at path. Syntax errors carry the file name.
+func ParseFile(path string) (Expr, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := Parse(string(data))
+	if syntaxErr, ok := err.(*SyntaxError); ok {
+		syntaxErr.File = path
+	}
+	return expr, err
+}
+
+// parser is a recursive descent parser over a token slice
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if i := p.pos + offset; i < len(p.tokens) {
+		return p.tokens[i]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp reports whether the next token is the operator op
+func (p *parser) isOp(op string) bool {
+	tok := p.peek()
+	return tok.kind == tokOp && tok.text == op
+}
+
+// isKeyword reports whether the next token is the keyword kw
+func (p *parser) isKeyword(kw string) bool {
+	tok := p.peek()
+	return tok.kind == tokIdent && tok.text == kw
+}
+
+// expect consumes the operator or keyword text, or fails
+func (p *parser) expect(text string) (token, error) {
+	tok := p.peek()
+	if (tok.kind == tokOp || tok.kind == tokIdent) && tok.text == text {
+		return p.advance(), nil
+	}
+	return tok, p.errorf(tok, "expected %q, found %s", text, describe(tok))
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &SyntaxError{Line: tok.line, Col: tok.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// describe names a token for error messages
+func describe(tok token) string {
+	if tok.kind == tokEOF {
+		return "end of file"
+	}
+	return strconv.Quote(tok.text)
+}
+
+func pos(tok token) Pos {
+	return Pos{Line: tok.line, Col: tok.col}
+}
+
+// parseExpr parses functions, let, with, assert and everything below them
+func (p *parser) parseExpr() (Expr, error) {
+	tok := p.peek()
+
+	switch {
+	case tok.kind == tokIdent && !keywords[tok.text]:
+		next := p.peekAt(1)
+		if next.kind == tokOp && next.text == ":" {
+			p.pos += 2
+			body, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return &Lambda{Pos: pos(tok), Arg: tok.text, Body: body}, nil
+		}
+		if next.kind == tokOp && next.text == "@" {
+			p.pos += 2
+			return p.parseFormals(tok, tok.text)
+		}
+
+	case tok.kind == tokOp && tok.text == "{" && p.isFormals():
+		return p.parseFormals(tok, "")
+
+	case p.isKeyword("let") && !(p.peekAt(1).kind == tokOp && p.peekAt(1).text == "{"):
+		p.advance()
+		bindings, err := p.parseBindings("in")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("in"); err != nil {
+			return nil, err
+		}
+		body, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &Let{Pos: pos(tok), Bindings: bindings, Body: body}, nil
+
+	case p.isKeyword("with"), p.isKeyword("assert"):
+		p.advance()
+		first, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		body, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "with" {
+			return &With{Pos: pos(tok), Env: first, Body: body}, nil
+		}
+		return &Assert{Pos: pos(tok), Cond: first, Body: body}, nil
+
+	case p.isKeyword("if"):
+		p.advance()
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("then"); err != nil {
+			return nil, err
+		}
+		then, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("else"); err != nil {
+			return nil, err
+		}
+		els, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &If{Pos: pos(tok), Cond: cond, Then: then, Else: els}, nil
+	}
+
+	return p.parseBinary(0)
+}
+
+// isFormals looks past a "{" to tell a lambda's argument pattern from an attribute set
+func (p *parser) isFormals() bool {
+	first, second := p.peekAt(1), p.peekAt(2)
+
+	switch {
+	case first.kind == tokOp && first.text == "...":
+		return true
+	case first.kind == tokOp && first.text == "}":
+		// {}: body or {} @ args: body
+		after := p.peekAt(2)
+		return after.kind == tokOp && (after.text == ":" || after.text == "@")
+	case first.kind == tokIdent && !keywords[first.text]:
+		return second.kind == tokOp && (second.text == "," || second.text == "?" || second.text == "}")
+	}
+	return false
+}
+
+// parseFormals parses { a, b ? x, ... } [@ name]: body. arg is the name
+// already bound by a leading name @ pattern.
+func (p *parser) parseFormals(start token, arg string) (Expr, error) {
+	lambda := &Lambda{Pos: pos(start), Arg: arg, HasSet: true}
+
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for !p.isOp("}") {
+		if p.isOp("...") {
+			p.advance()
+			lambda.Ellipsis = true
+		} else {
+			name := p.advance()
+			if name.kind != tokIdent {
+				return nil, p.errorf(name, "expected argument name, found %s", describe(name))
+			}
+			formal := Formal{Name: name.text}
+			if p.isOp("?") {
+				p.advance()
+				def, err := p.parseExpr()
+				if err != nil {
+					return nil, err
+				}
+				formal.Default = def
+			}
+			lambda.Formals = append(lambda.Formals, formal)
+		}
+
+		if p.isOp(",") {
+			p.advance()
+		} else if !p.isOp("}") {
+			tok := p.peek()
+			return nil, p.errorf(tok, "expected \",\" or \"}\" in arguments, found %s", describe(tok))
+		}
+	}
+	p.advance()
+
+	if p.isOp("@") {
+		p.advance()
+		name := p.advance()
+		if name.kind != tokIdent {
+			return nil, p.errorf(name, "expected name after @, found %s", describe(name))
+		}
+		lambda.Arg = name.text
+	}
+
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	body, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	lambda.Body = body
+	return lambda, nil
+}
+
+// binaryLevels lists binary operators from lowest to highest precedence
+var binaryLevels = []struct {
+	ops   []string
+	right bool // Right associative
+}{
+	{[]string{"->"}, true},
+	{[]string{"||"}, false},
+	{[]string{"&&"}, false},
+	{[]string{"==", "!="}, false},
+	{[]string{"<", ">", "<=", ">="}, false},
+	{[]string{"//"}, true},
+	{nil, false}, // ! prefix
+	{[]string{"+", "-"}, false},
+	{[]string{"*", "/"}, false},
+	{[]string{"++"}, true},
+}
+
+// notLevel is the index of the ! prefix operator in binaryLevels
+const notLevel = 6
+
+// parseBinary parses operators at binaryLevels[level] and above
+func (p *parser) parseBinary(level int) (Expr, error) {
+	if level == len(binaryLevels) {
+		return p.parseHasAttr()
+	}
+	if level == notLevel {
+		if p.isOp("!") {
+			tok := p.advance()
+			expr, err := p.parseBinary(level)
+			if err != nil {
+				return nil, err
+			}
+			return &UnaryOp{Pos: pos(tok), Op: "!", Expr: expr}, nil
+		}
+		return p.parseBinary(level + 1)
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		if tok.kind != tokOp || !contains(binaryLevels[level].ops, tok.text) {
+			return left, nil
+		}
+		p.advance()
+
+		next := level + 1
+		if binaryLevels[level].right {
+			next = level
+		}
+		right, err := p.parseBinary(next)
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryOp{Pos: left.Position(), Op: tok.text, Left: left, Right: right}
+	}
+}
+
+// parseHasAttr parses e ? a.b
+func (p *parser) parseHasAttr() (Expr, error) {
+	expr, err := p.parseNegation()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("?") {
+		p.advance()
+		path, err := p.parseAttrPath()
+		if err != nil {
+			return nil, err
+		}
+		expr = &HasAttr{Pos: expr.Position(), Expr: expr, Path: path}
+	}
+	return expr, nil
+}
+
+// parseNegation parses unary minus
+func (p *parser) parseNegation() (Expr, error) {
+	if p.isOp("-") {
+		tok := p.advance()
+		expr, err := p.parseNegation()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryOp{Pos: pos(tok), Op: "-", Expr: expr}, nil
+	}
+	return p.parseApply()
+}
+
+// parseApply parses function application, which binds tighter than any operator
+func (p *parser) parseApply() (Expr, error) {
+	expr, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	for p.startsSimple() {
+		arg, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Apply{Pos: expr.Position(), Func: expr, Arg: arg}
+	}
+	return expr, nil
+}
+
+// startsSimple reports whether the next token can begin an argument
+func (p *parser) startsSimple() bool {
+	tok := p.peek()
+	switch tok.kind {
+	case tokInt, tokFloat, tokString, tokIndString, tokPath, tokURI:
+		return true
+	case tokIdent:
+		return !keywords[tok.text] || tok.text == "rec" || tok.text == "let"
+	case tokOp:
+		return tok.text == "(" || tok.text == "[" || tok.text == "{"
+	}
+	return false
+}
+
+// parseSelect parses e.a.b [or default]
+func (p *parser) parseSelect() (Expr, error) {
+	expr, err := p.parseSimple()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp(".") {
+		return expr, nil
+	}
+
+	p.advance()
+	path, err := p.parseAttrPath()
+	if err != nil {
+		return nil, err
+	}
+	sel := &Select{Pos: expr.Position(), Expr: expr, Path: path}
+	if p.isKeyword("or") {
+		p.advance()
+		def, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		sel.Default = def
+	}
+	return sel, nil
+}
+
+// parseAttrPath parses a.b."c".${d}
+func (p *parser) parseAttrPath() ([]string, error) {
+	var path []string
+	for {
+		tok := p.advance()
+		switch tok.kind {
+		case tokIdent, tokString:
+			path = append(path, tok.text)
+		case tokInterp:
+			path = append(path, "${"+tok.text+"}")
+		default:
+			return nil, p.errorf(tok, "expected attribute name, found %s", describe(tok))
+		}
+
+		if !p.isOp(".") {
+			return path, nil
+		}
+		p.advance()
+	}
+}
+
+// parseSimple parses literals, variables, parenthesized expressions, sets and lists
+func (p *parser) parseSimple() (Expr, error) {
+	tok := p.advance()
+	at := pos(tok)
+
+	switch tok.kind {
+	case tokInt:
+		value, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid integer %s", tok.text)
+		}
+		return &Int{Pos: at, Value: value}, nil
+	case tokFloat:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid float %s", tok.text)
+		}
+		return &Float{Pos: at, Value: value}, nil
+	case tokString, tokIndString:
+		return &String{
+			Pos:          at,
+			Value:        tok.text,
+			Interpolated: tok.interpolated,
+			Indented:     tok.kind == tokIndString,
+			ValueLine:    tok.valueLine,
+		}, nil
+	case tokPath:
+		return &Path{Pos: at, Value: tok.text}, nil
+	case tokURI:
+		return &URI{Pos: at, Value: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "rec":
+			if _, err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			return p.parseAttrSet(at, true)
+		case "let":
+			// Legacy let { ...; body = x; }
+			if _, err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			return p.parseAttrSet(at, true)
+		}
+		if keywords[tok.text] {
+			return nil, p.errorf(tok, "unexpected keyword %q", tok.text)
+		}
+		return &Ident{Pos: at, Name: tok.text}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return expr, nil
+		case "{":
+			return p.parseAttrSet(at, false)
+		case "[":
+			list := &List{Pos: at}
+			for !p.isOp("]") {
+				if p.peek().kind == tokEOF {
+					return nil, p.errorf(p.peek(), "unterminated list")
+				}
+				item, err := p.parseSelect()
+				if err != nil {
+					return nil, err
+				}
+				list.Items = append(list.Items, item)
+			}
+			p.advance()
+			return list, nil
+		}
+	}
+
+	return nil, p.errorf(tok, "unexpected %s", describe(tok))
+}
+
+// parseAttrSet parses the bindings of a set after its opening brace
+func (p *parser) parseAttrSet(at Pos, rec bool) (Expr, error) {
+	bindings, err := p.parseBindings("}")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("}"); err != nil {
+		return nil, err
+	}
+	return &AttrSet{Pos: at, Rec: rec, Bindings: bindings}, nil
+}
+
+// parseBindings parses "path = value;" and "inherit ...;" entries up to end
+func (p *parser) parseBindings(end string) ([]*Binding, error) {
+	var bindings []*Binding
+	for {
+		tok := p.peek()
+		if (tok.kind == tokOp || tok.kind == tokIdent) && tok.text == end {
+			return bindings, nil
+		}
+		if tok.kind == tokEOF {
+			return nil, p.errorf(tok, "expected %q, found end of file", end)
+		}
+
+		if p.isKeyword("inherit") {
+			inherited, err := p.parseInherit()
+			if err != nil {
+				return nil, err
+			}
+			bindings = append(bindings, inherited...)
+			continue
+		}
+
+		path, err := p.parseAttrPath()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, &Binding{Pos: pos(tok), Path: path, Value: value})
+	}
+}
+
+// parseInherit parses inherit a b; and inherit (src) a b; into bindings
+func (p *parser) parseInherit() ([]*Binding, error) {
+	p.advance()
+
+	var from Expr
+	if p.isOp("(") {
+		p.advance()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		from = expr
+	}
+
+	var bindings []*Binding
+	for !p.isOp(";") {
+		tok := p.advance()
+		if tok.kind != tokIdent && tok.kind != tokString {
+			return nil, p.errorf(tok, "expected attribute name in inherit, found %s", describe(tok))
+		}
+
+		var value Expr = &Ident{Pos: pos(tok), Name: tok.text}
+		if from != nil {
+			value = &Select{Pos: pos(tok), Expr: from, Path: []string{tok.text}}
+		}
+		bindings = append(bindings, &Binding{Pos: pos(tok), Path: []string{tok.text}, Value: value})
+	}
+	p.advance()
+	return bindings, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
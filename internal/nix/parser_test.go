@@ -0,0 +1,98 @@
+package nix
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`{ a = 1; b.c = "x"; }`, `{ a = 1; b.c = "x"; }`},
+		{`rec { inherit x; inherit (pkgs) vim git; }`, `rec { x = x; vim = pkgs.vim; git = pkgs.git; }`},
+		{`[ 1 2.5 ./foo.nix <nixpkgs> ~/bin /etc/hosts github:NixOS/nixpkgs ]`, `[ 1 2.5 ./foo.nix <nixpkgs> ~/bin /etc/hosts github:NixOS/nixpkgs ]`},
+		{`{ config, pkgs ? null, ... }@args: config`, `{ config, pkgs, ... }@args: config`},
+		{`x: y: x + y * 2`, `x: y: x + (y * 2)`},
+		{`f a.b (g c) [ d ]`, `f a.b (g c) [ d ]`},
+		{`let a = 1; in a`, `let a = 1; in a`},
+		{`with pkgs; [ vim ]`, `with pkgs; [ vim ]`},
+		{`if a then b else c`, `if a then b else c`},
+		{`a // b // c`, `a // (b // c)`},
+		{`a ++ b ++ c`, `a ++ (b ++ c)`},
+		{`!a && b || c -> d`, `(((!a) && b) || c) -> d`},
+		{`x.y or z`, `x.y or z`},
+		{`s ? a.b`, `s ? a.b`},
+		{`-1`, `-1`},
+		{`a.${b}."c"`, `a.${b}.c`},
+		{`{}: {}`, `{ }: { }`},
+		{`/* block */ a # line`, `a`},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.src, err)
+			continue
+		}
+		if got := Format(expr); got != tt.want {
+			t.Errorf("Parse(%q) formats as %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseStrings(t *testing.T) {
+	tests := []struct {
+		src          string
+		want         string
+		interpolated bool
+		valueLine    int
+	}{
+		{`"a\"b\n\${c}"`, "a\"b\n${c}", false, 1},
+		{`"x ${pkgs.foo}/bin"`, "x ${pkgs.foo}/bin", true, 1},
+		{"''\n    set -g prefix C-a\n      bind x kill\n  ''", "set -g prefix C-a\n  bind x kill\n", false, 2},
+		{"''a'''b''$c''\\td''", "a''b$c\td", false, 1},
+		{"''\n  ${x} \"{}\"\n''", "${x} \"{}\"\n", true, 2},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.src, err)
+			continue
+		}
+		s, ok := expr.(*String)
+		if !ok {
+			t.Errorf("Parse(%q) = %T, want *String", tt.src, expr)
+			continue
+		}
+		if s.Value != tt.want || s.Interpolated != tt.interpolated || s.ValueLine != tt.valueLine {
+			t.Errorf("Parse(%q) = %q (interpolated %v, line %d), want %q (%v, %d)",
+				tt.src, s.Value, s.Interpolated, s.ValueLine, tt.want, tt.interpolated, tt.valueLine)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"{ a = 1 }", `1:9: expected ";", found "}"`},
+		{"{\n  a = \"x;\n}", "2:7: unterminated string"},
+		{"[ 1 2", "1:6: unterminated list"},
+		{"let a = 1;", `1:11: expected "in", found end of file`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", tt.src)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %q, want %q", tt.src, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+{ config, ... }: {
+  services.foo.enable = true
+}
@@ -0,0 +1,15 @@
+# Edit this configuration file to define what should be installed on
+# your system.
+{ config, pkgs, ... }:
+
+{
+  imports = [
+    ./hardware-configuration.nix
+    (import ./extra.nix)
+    ./broken.nix
+    <home-manager/nixos>
+  ];
+
+  environment.systemPackages = [ pkgs.htop pkgs.git ];
+  i18n.defaultLocale = "en_US.UTF-8";
+}
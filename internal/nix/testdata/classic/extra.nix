@@ -0,0 +1,5 @@
+{ pkgs, ... }: {
+  environment.systemPackages = [ pkgs.git pkgs.jq ];
+  boot.kernelModules = [ "wl" ];
+  services.printing.enable = false;
+}
@@ -0,0 +1 @@
+{ ... }: { boot.kernelModules = [ "kvm-intel" ]; }
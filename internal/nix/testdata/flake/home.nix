@@ -0,0 +1,18 @@
+{ pkgs, ... }:
+
+{
+  home.packages = with pkgs; [ ripgrep fd ];
+
+  programs.git = {
+    enable = true;
+    userName = "Jeff";
+  };
+
+  programs.tmux = {
+    enable = true;
+    extraConfig = ''
+      set -g prefix C-a
+      bind | split-window -h
+    '';
+  };
+}
@@ -0,0 +1,35 @@
+{ config, pkgs, lib, ... }:
+
+let
+  user = "jeff";
+in
+{
+  imports = [
+    ./hardware-configuration.nix
+    ../../modules
+  ];
+
+  boot.loader.systemd-boot.enable = true;
+  networking.hostName = "laptop"; # Define your hostname.
+
+  services.openssh = {
+    enable = true;
+    settings.PasswordAuthentication = false;
+  };
+
+  users.users.${user} = {
+    isNormalUser = true;
+    extraGroups = [ "wheel" "networkmanager" ];
+    packages = with pkgs; [ firefox ];
+  };
+
+  environment.systemPackages = with pkgs; [
+    vim
+    git
+    (python3.withPackages (ps: [ ps.requests ]))
+  ] ++ lib.optionals config.services.xserver.enable [ pkgs.xclip ];
+
+  time.timeZone = "America/Chicago";
+  nix.settings.max-jobs = 8;
+  system.stateVersion = "24.05";
+}
@@ -0,0 +1,13 @@
+{ config, lib, pkgs, ... }:
+
+with lib;
+
+{
+  options.my.desktop.enable = mkEnableOption "desktop environment";
+
+  config = mkIf config.my.desktop.enable {
+    services.xserver.enable = mkDefault true;
+    programs.sway.enable = true;
+    fonts.packages = [ pkgs.fira-code ];
+  };
+}
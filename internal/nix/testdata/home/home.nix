@@ -0,0 +1,15 @@
+{ config, pkgs, ... }:
+
+{
+  imports = [
+    ./programs/git.nix
+    ./programs/tmux.nix
+  ];
+
+  home.username = "jeff";
+  home.homeDirectory = "/home/jeff";
+  home.stateVersion = "24.05";
+
+  programs.bat.enable = false;
+  programs.fzf.enable = true;
+}
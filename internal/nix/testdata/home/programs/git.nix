@@ -0,0 +1,9 @@
+{
+  programs.git = {
+    enable = true;
+    userName = "Jeff Muter";
+    userEmail = "jeff@example.com";
+    aliases.co = "checkout";
+    extraConfig.init.defaultBranch = "main";
+  };
+}
@@ -0,0 +1,12 @@
+{ pkgs, ... }:
+
+{
+  programs.tmux = {
+    enable = true;
+    keyMode = "vi";
+    extraConfig = ''
+      set -g prefix C-a
+      bind r source-file ~/.config/tmux/tmux.conf
+    '';
+  };
+}
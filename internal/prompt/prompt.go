@@ -0,0 +1,435 @@
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	codexContext "codex/internal/context"
+)
+
+// DefaultSystem holds the answer-style rules sent to every provider
+const DefaultSystem = "You are Codex, a ruthlessly concise CLI assistant.\n\n" +
+	"**ABSOLUTE RULES - VIOLATING THESE IS UNACCEPTABLE**:\n" +
+	"1. ANSWER IN 5 WORDS OR LESS when possible.\n" +
+	"2. NO introductions. NO explanations. NO context. NO examples. NO code blocks.\n" +
+	"3. Format for lookups: `value` (file:line)\n" +
+	"4. DO NOT explain what the user will do with the answer.\n" +
+	"5. DO NOT restate the question.\n" +
+	"6. If you write more than one sentence, you have FAILED.\n"
+
+// Section names used to group context documents
+const (
+	SectionConfiguredRepos = "Configured Repositories"
+	SectionCurrentRepo     = "Current Repository"
+	SectionFilesystem      = "Filesystem Context"
+	SectionNixConfig       = "Nix Configuration"
+	SectionDotfiles        = "Dotfiles"
+)
+
+// Prompt is a provider-neutral rendering of a query and its gathered context.
+// Providers map it onto their own message formats so every backend sees the
+// same instructions, documents and question. Stable documents always come
+// first so providers can cache them as a prefix.
+type Prompt struct {
+	System    string     // Instructions for the model
+	Documents []Document // Context, in the order it should be presented
+	User      string     // The user's question
+}
+
+// Document is one self-contained piece of context, such as a repository
+type Document struct {
+	Section string // Heading the document is grouped under
+	Title   string // Short label, e.g. the repository source
+	Content string // Rendered markdown body
+	Stable  bool   // Unlikely to change between questions, safe to cache
+}
+
+// Builder renders queries and context into Prompts with a fixed system prompt
+type Builder struct {
+	system string
+}
+
+// NewBuilder creates a builder using system as the system prompt.
+// An empty system prompt selects DefaultSystem.
+func NewBuilder(system string) *Builder {
+	if system == "" {
+		system = DefaultSystem
+	}
+	return &Builder{system: system}
+}
+
+// Build renders a query and its context into a Prompt using the default system prompt
+func Build(query string, ctx *codexContext.Context) *Prompt {
+	return NewBuilder("").Build(query, ctx)
+}
+
+// Build renders a query and its context into a Prompt
+func (b *Builder) Build(query string, ctx *codexContext.Context) *Prompt {
+	p := &Prompt{
+		System: b.system,
+		User:   query,
+	}
+
+	if ctx == nil {
+		return p
+	}
+
+	for _, repo := range ctx.ConfiguredRepos {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("### Repository: %s (%s)\n", repo.Source, repo.Type))
+		if repo.Remote != "" {
+			sb.WriteString(fmt.Sprintf("Remote: %s\n", repo.Remote))
+		}
+		sb.WriteString(fmt.Sprintf("Path: %s\n", repo.Path))
+		writeFiles(&sb, repo.Contents)
+
+		p.Documents = append(p.Documents, Document{
+			Section: SectionConfiguredRepos,
+			Title:   repo.Source,
+			Content: sb.String(),
+			Stable:  true,
+		})
+	}
+
+	if ctx.NixConfig != nil {
+		p.Documents = append(p.Documents, Document{
+			Section: SectionNixConfig,
+			Title:   ctx.NixConfig.ConfigPath,
+			Content: renderNixConfig(ctx.NixConfig),
+			Stable:  true,
+		})
+	}
+
+	if ctx.Dotfiles != nil && (len(ctx.Dotfiles.Configs) > 0 || len(ctx.Dotfiles.Keybindings) > 0 || len(ctx.Dotfiles.Shells) > 0) {
+		p.Documents = append(p.Documents, Document{
+			Section: SectionDotfiles,
+			Title:   ctx.Dotfiles.DotfilesPath,
+			Content: renderDotfiles(ctx.Dotfiles),
+			Stable:  true,
+		})
+	}
+
+	if ctx.CurrentRepo != nil {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Path: %s\n", ctx.CurrentRepo.Path))
+		if ctx.CurrentRepo.Remote != "" {
+			sb.WriteString(fmt.Sprintf("Remote: %s\n", ctx.CurrentRepo.Remote))
+		}
+		if focus := ctx.CurrentRepo.FocusDir; focus != "" && focus != "." {
+			sb.WriteString(fmt.Sprintf("Working Directory: %s\n", focus))
+		}
+		writeFiles(&sb, ctx.CurrentRepo.Contents)
+
+		p.Documents = append(p.Documents, Document{
+			Section: SectionCurrentRepo,
+			Title:   ctx.CurrentRepo.Path,
+			Content: sb.String(),
+		})
+	}
+
+	if ctx.Filesystem != nil {
+		p.Documents = append(p.Documents, Document{
+			Section: SectionFilesystem,
+			Title:   ctx.Filesystem.CurrentDir,
+			Content: fmt.Sprintf("Current Directory: %s\n", ctx.Filesystem.CurrentDir),
+		})
+	}
+
+	return p
+}
+
+// RenderSettings renders the Nix configuration and dotfiles documents of ctx
+// as Build does, so the tokens they take can be budgeted before repository
+// files are summarized
+func RenderSettings(ctx *codexContext.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	settings := &codexContext.Context{NixConfig: ctx.NixConfig, Dotfiles: ctx.Dotfiles}
+	for _, doc := range Build("", settings).Documents {
+		sb.WriteString(doc.Content)
+	}
+	return sb.String()
+}
+
+// writeFiles renders repository file contents as fenced blocks
+func writeFiles(sb *strings.Builder, contents *codexContext.RepoContents) {
+	if contents == nil {
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("\n**Files: %d files, %d bytes total**\n\n",
+		contents.TotalFiles, contents.TotalSize))
+
+	for _, file := range contents.Files {
+		sb.WriteString(fmt.Sprintf("#### File: %s\n", file.RelativePath))
+		sb.WriteString("```\n")
+		sb.WriteString(file.Content)
+		if !strings.HasSuffix(file.Content, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```\n\n")
+	}
+}
+
+// renderNixConfig renders parsed packages and options, each option with the
+// file and line it was set on
+func renderNixConfig(nix *codexContext.NixContext) string {
+	var sb strings.Builder
+
+	kind := "configuration.nix"
+	if nix.IsFlake {
+		kind = "flake"
+	}
+	sb.WriteString(fmt.Sprintf("### %s (%s)\n", nix.ConfigPath, kind))
+	if len(nix.Files) > 0 {
+		sb.WriteString(fmt.Sprintf("Files: %s\n", strings.Join(nix.Files, ", ")))
+	}
+
+	if len(nix.Packages) > 0 {
+		sb.WriteString(fmt.Sprintf("\n**Packages (%d):** %s\n", len(nix.Packages), strings.Join(nix.Packages, ", ")))
+	}
+
+	if len(nix.Inputs) > 0 {
+		sb.WriteString("\n**Flake inputs:**\n")
+		for _, in := range nix.Inputs {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", in.Name, describeInput(in, nix.StaleAfter)))
+		}
+	}
+
+	if len(nix.SystemConfig) > 0 {
+		sb.WriteString("\n**Options:**\n")
+		for _, key := range sortedKeys(nix.SystemConfig) {
+			src, ok := nix.Sources[key]
+			writeOption(&sb, key, nix.SystemConfig[key], src, ok)
+		}
+	}
+
+	return sb.String()
+}
+
+// renderDotfiles renders the settings of each configured program
+func renderDotfiles(dotfiles *codexContext.DotfilesContext) string {
+	var sb strings.Builder
+
+	if dotfiles.IsHomeManager {
+		sb.WriteString(fmt.Sprintf("### %s (home-manager)\n", dotfiles.DotfilesPath))
+	} else {
+		sb.WriteString(fmt.Sprintf("### %s\n", dotfiles.DotfilesPath))
+	}
+
+	for _, name := range sortedKeys(dotfiles.Configs) {
+		settings, ok := dotfiles.Configs[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n#### %s\n", name))
+		for _, key := range sortedKeys(settings) {
+			src, ok := dotfiles.Sources[name+"."+key]
+			writeOption(&sb, key, settings[key], src, ok)
+		}
+	}
+
+	tools := make([]string, 0, len(dotfiles.Keybindings))
+	for tool := range dotfiles.Keybindings {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	for _, tool := range tools {
+		sb.WriteString(fmt.Sprintf("\n#### %s key bindings\n", tool))
+		for _, bind := range dotfiles.Keybindings[tool] {
+			sb.WriteString(describeKeybind(bind))
+		}
+	}
+
+	names := make([]string, 0, len(dotfiles.Shells))
+	for name := range dotfiles.Shells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		shell := dotfiles.Shells[name]
+		writeDefinitions(&sb, name+" aliases", shell.Aliases)
+		writeDefinitions(&sb, name+" functions", shell.Functions)
+		writeDefinitions(&sb, name+" environment", shell.Exports)
+		if len(shell.Plugins) > 0 {
+			sb.WriteString(fmt.Sprintf("\n#### %s plugins\n", name))
+			for _, plugin := range shell.Plugins {
+				sb.WriteString("- " + plugin.Manager)
+				if plugin.Name != "" {
+					sb.WriteString(": " + plugin.Name)
+				}
+				if plugin.File != "" {
+					sb.WriteString(fmt.Sprintf(" (%s:%d)", plugin.File, plugin.Line))
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// writeDefinitions renders shell aliases, functions or variables under a
+// heading, e.g. "- `gl`: git log --oneline (.zshrc:3)"
+func writeDefinitions(sb *strings.Builder, heading string, defs []codexContext.ShellDefinition) {
+	if len(defs) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n#### %s\n", heading))
+	for _, d := range defs {
+		sb.WriteString(fmt.Sprintf("- `%s`", d.Name))
+		if d.Value != "" {
+			sb.WriteString(": " + d.Value)
+		}
+		if d.File != "" {
+			sb.WriteString(fmt.Sprintf(" (%s:%d)", d.File, d.Line))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// describeKeybind renders a key binding as a list item, e.g.
+// "- `|` (prefix): split-window -h (.tmux.conf:7)"
+func describeKeybind(bind codexContext.Keybind) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- `%s`", bind.Key))
+	if bind.Mode != "" {
+		sb.WriteString(fmt.Sprintf(" (%s)", bind.Mode))
+	}
+	sb.WriteString(": " + bind.Command)
+	if bind.Description != "" {
+		sb.WriteString(" - " + bind.Description)
+	}
+	if bind.File != "" {
+		sb.WriteString(fmt.Sprintf(" (%s:%d)", bind.File, bind.Line))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// writeOption renders one setting as a list item, with the file and line it
+// was set on if known. Multi-line strings become fenced blocks.
+func writeOption(sb *strings.Builder, key string, value any, src codexContext.OptionSource, hasSource bool) {
+	location := ""
+	if hasSource {
+		location = fmt.Sprintf(" (%s:%d)", src.File, src.Line)
+	}
+
+	if text, ok := value.(string); ok && strings.Contains(text, "\n") {
+		sb.WriteString(fmt.Sprintf("- %s%s:\n```\n%s", key, location, text))
+		if !strings.HasSuffix(text, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```\n")
+		return
+	}
+	sb.WriteString(fmt.Sprintf("- %s = %s%s\n", key, formatValue(value), location))
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// describeInput summarizes where a flake input comes from and what it is locked to
+func describeInput(in codexContext.FlakeInput, staleAfter int) string {
+	if in.Follows != "" {
+		return "follows " + in.Follows
+	}
+
+	var parts []string
+	if in.URL != "" {
+		parts = append(parts, in.URL)
+	}
+
+	follows := make([]string, 0, len(in.InputFollows))
+	for name, target := range in.InputFollows {
+		follows = append(follows, fmt.Sprintf("%s follows %s", name, target))
+	}
+	sort.Strings(follows)
+	parts = append(parts, follows...)
+
+	switch {
+	case in.Rev == "":
+		parts = append(parts, "not locked")
+	case in.LastModified.IsZero():
+		parts = append(parts, "locked at "+in.Rev)
+	default:
+		parts = append(parts, fmt.Sprintf("locked at %s (%s)", in.Rev, in.LastModified.Format("2006-01-02")))
+	}
+	if in.Stale {
+		parts = append(parts, fmt.Sprintf("STALE: locked more than %d days ago", staleAfter))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// formatValue renders a parsed option value in Nix-like syntax
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = formatValue(item)
+		}
+		return "[ " + strings.Join(items, " ") + " ]"
+	case map[string]any:
+		var sb strings.Builder
+		sb.WriteString("{")
+		for _, key := range sortedKeys(v) {
+			sb.WriteString(fmt.Sprintf(" %s = %s;", key, formatValue(v[key])))
+		}
+		sb.WriteString(" }")
+		return sb.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// RenderDocuments renders the context documents as markdown, one heading per section
+func (p *Prompt) RenderDocuments() string {
+	var sb strings.Builder
+
+	section := ""
+	for _, doc := range p.Documents {
+		if doc.Section != section {
+			section = doc.Section
+			sb.WriteString(fmt.Sprintf("## %s\n\n", section))
+		}
+		sb.WriteString(doc.Content)
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// RenderQuery renders the user's question section
+func (p *Prompt) RenderQuery() string {
+	return fmt.Sprintf("## User Query\n%s\n", p.User)
+}
+
+// RenderUser renders the context followed by the question, for providers
+// that take the system prompt separately and everything else as one message
+func (p *Prompt) RenderUser() string {
+	return p.RenderDocuments() + p.RenderQuery()
+}
+
+// Render flattens the whole prompt into a single text block
+func (p *Prompt) Render() string {
+	return p.System + "\n" + p.RenderUser()
+}
@@ -0,0 +1,182 @@
+package prompt
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	codexContext "codex/internal/context"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// fixtureContext returns a context with every section populated
+func fixtureContext() *codexContext.Context {
+	return &codexContext.Context{
+		ConfiguredRepos: []*codexContext.RepositoryContext{
+			{
+				Path:   "/home/user/dotfiles",
+				Remote: "git@github.com:user/dotfiles.git",
+				Source: "/home/user/dotfiles",
+				Type:   "local",
+				Contents: &codexContext.RepoContents{
+					Files: []codexContext.FileContent{
+						{RelativePath: ".tmux.conf", Content: "set -g prefix C-a\n", Size: 18},
+						{RelativePath: "nvim/init.lua", Content: "vim.g.mapleader = \" \"", Size: 21},
+					},
+					TotalFiles: 2,
+					TotalSize:  39,
+				},
+			},
+			{
+				Path:   "/home/user/.local/share/codex/repos/templates",
+				Source: "https://github.com/user/templates",
+				Type:   "remote",
+			},
+		},
+		CurrentRepo: &codexContext.RepositoryContext{
+			Path: "/home/user/src/codex",
+			Type: "current",
+			Contents: &codexContext.RepoContents{
+				Files: []codexContext.FileContent{
+					{RelativePath: "main.go", Content: "package main\n", Size: 13},
+				},
+				TotalFiles: 1,
+				TotalSize:  13,
+			},
+		},
+		Filesystem: &codexContext.FilesystemContext{CurrentDir: "/home/user/src/codex/cmd"},
+		NixConfig: &codexContext.NixContext{
+			ConfigPath: "/home/user/nixos",
+			IsFlake:    true,
+			Files:      []string{"flake.nix", "configuration.nix", "home.nix"},
+			Packages:   []string{"git", "ripgrep", "vim"},
+			Inputs: []codexContext.FlakeInput{
+				{
+					Name:         "home-manager",
+					URL:          "github:nix-community/home-manager",
+					InputFollows: map[string]string{"nixpkgs": "nixpkgs"},
+					Rev:          "a7117efb3725e6197dd95424136f79147aa35e5b",
+					LastModified: time.Date(2024, 6, 4, 18, 23, 39, 0, time.UTC),
+					Stale:        true,
+				},
+				{Name: "nixpkgs-stable", Follows: "nixpkgs"},
+			},
+			StaleAfter: 90,
+			SystemConfig: map[string]any{
+				"networking.hostName":                             "laptop",
+				"services.openssh.enable":                         true,
+				"users.users.me.extraGroups":                      []any{"wheel", "video"},
+				"home-manager.users.me.programs.tmux.extraConfig": "set -g prefix C-a\n",
+			},
+			Sources: map[string]codexContext.OptionSource{
+				"networking.hostName":                             {File: "configuration.nix", Line: 12},
+				"home-manager.users.me.programs.tmux.extraConfig": {File: "home.nix", Line: 8},
+			},
+		},
+		Dotfiles: &codexContext.DotfilesContext{
+			DotfilesPath:  "/home/user/nixos",
+			IsHomeManager: true,
+			Configs: map[string]any{
+				"git":  map[string]any{"userName": "Me", "extraConfig": map[string]any{"pull.rebase": true}},
+				"tmux": map[string]any{"extraConfig": "set -g prefix C-a\n"},
+			},
+			Sources: map[string]codexContext.OptionSource{
+				"git.userName":     {File: "home.nix", Line: 4},
+				"tmux.extraConfig": {File: "home.nix", Line: 8},
+			},
+			Keybindings: map[string][]codexContext.Keybind{
+				"tmux": {
+					{Key: "C-a", Command: "switch-client -T prefix", Description: "Prefix key", Mode: "root", File: "home.nix", Line: 8},
+					{Key: "|", Command: "split-window -h", Mode: "prefix"},
+				},
+			},
+			Shells: map[string]*codexContext.ShellContext{
+				"zsh": {
+					Aliases: []codexContext.ShellDefinition{{Name: "gl", Value: "git log --oneline", File: ".zshrc", Line: 3}},
+					Exports: []codexContext.ShellDefinition{{Name: "EDITOR", Value: "nvim"}},
+					Plugins: []codexContext.ShellPlugin{{Manager: "oh-my-zsh", Name: "git", File: ".zshrc", Line: 1}},
+				},
+			},
+		},
+	}
+}
+
+// checkGolden compares got against testdata/name, rewriting it with -update
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Output does not match %s (run with -update to accept)\n--- got ---\n%s", path, got)
+	}
+}
+
+func TestBuildGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		ctx    *codexContext.Context
+	}{
+		{"empty.golden", nil},
+		{"full.golden", fixtureContext()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			checkGolden(t, tt.golden, Build("What's my tmux prefix key?", tt.ctx).Render())
+		})
+	}
+}
+
+func TestBuildDocuments(t *testing.T) {
+	p := Build("q", fixtureContext())
+
+	sections := []string{SectionConfiguredRepos, SectionConfiguredRepos, SectionNixConfig, SectionDotfiles, SectionCurrentRepo, SectionFilesystem}
+	if len(p.Documents) != len(sections) {
+		t.Fatalf("Expected %d documents, got %d", len(sections), len(p.Documents))
+	}
+	for i, section := range sections {
+		if p.Documents[i].Section != section {
+			t.Errorf("Document %d: expected section %q, got %q", i, section, p.Documents[i].Section)
+		}
+	}
+
+	user := p.RenderUser()
+	if strings.Contains(user, p.System) {
+		t.Error("Expected user message to exclude the system prompt")
+	}
+	if !strings.HasSuffix(user, "## User Query\nq\n") {
+		t.Errorf("Expected query last in user message, got %q", user)
+	}
+}
+
+func TestRenderSettings(t *testing.T) {
+	ctx := fixtureContext()
+	p := Build("q", ctx)
+
+	var want string
+	for _, doc := range p.Documents {
+		if doc.Section == SectionNixConfig || doc.Section == SectionDotfiles {
+			want += doc.Content
+		}
+	}
+	if got := RenderSettings(ctx); got != want || got == "" {
+		t.Errorf("Expected the Nix and dotfiles documents, got %q", got)
+	}
+	if RenderSettings(nil) != "" {
+		t.Error("Expected nothing for a nil context")
+	}
+}
@@ -0,0 +1,126 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultStyle is the preset used when none is configured
+const DefaultStyle = "terse"
+
+// Sources a style can be loaded from
+const (
+	SourceBuiltin = "builtin"
+	SourceConfig  = "config"
+)
+
+// builtinStyles maps preset names to their system prompts
+var builtinStyles = map[string]string{
+	// Keybind and config lookups: a value and where it came from
+	"terse": DefaultSystem,
+
+	// Codebase and setup explanations, typically with --current-repo
+	"explain": "You are Codex, a CLI assistant that explains the user's development environment and code.\n\n" +
+		"**GUIDELINES**:\n" +
+		"1. Answer the question directly in the first sentence, then explain.\n" +
+		"2. Ground every claim in the provided context and cite sources as (file:line) where possible.\n" +
+		"3. Use short paragraphs and bullet lists. Include code only when it clarifies.\n" +
+		"4. If the context does not contain the answer, say so plainly instead of guessing.\n",
+
+	// Snippets and commands that fit the user's existing setup
+	"code": "You are Codex, a CLI assistant that answers with code.\n\n" +
+		"**RULES**:\n" +
+		"1. Reply with a single code block or shell command that solves the request.\n" +
+		"2. Match the languages, tools and conventions found in the provided context.\n" +
+		"3. Add at most one short sentence after the code, only if something must be explained.\n" +
+		"4. NO introductions. DO NOT restate the question.\n",
+}
+
+// presetExtensions are the file extensions recognised for custom presets
+var presetExtensions = []string{".md", ".txt", ""}
+
+// Style is a resolved system prompt preset
+type Style struct {
+	Name   string // Preset name, e.g. "terse"
+	System string // System prompt text
+	Source string // SourceBuiltin, SourceConfig, or the preset file path
+}
+
+// LoadStyle resolves a preset by name. A file named <name>.md, <name>.txt or
+// <name> in presetDir takes precedence over a built-in preset of the same name.
+// Names are looked up only in presetDir, so a path is an unknown style.
+func LoadStyle(name, presetDir string) (*Style, error) {
+	if name == "" {
+		name = DefaultStyle
+	}
+
+	if !isStyleName(name) {
+		return nil, unknownStyle(name, presetDir)
+	}
+
+	if presetDir != "" {
+		for _, ext := range presetExtensions {
+			path := filepath.Join(presetDir, name+ext)
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				continue
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read style preset %s: %w", path, err)
+			}
+			return &Style{Name: name, System: strings.TrimSpace(string(data)) + "\n", Source: path}, nil
+		}
+	}
+
+	if system, ok := builtinStyles[name]; ok {
+		return &Style{Name: name, System: system, Source: SourceBuiltin}, nil
+	}
+
+	return nil, unknownStyle(name, presetDir)
+}
+
+// isStyleName reports whether name can name a preset file in the preset
+// directory rather than a path leading out of it
+func isStyleName(name string) bool {
+	return name != "." && !strings.Contains(name, "..") && !strings.ContainsAny(name, `/\`)
+}
+
+// unknownStyle is the error for a name that matches no preset
+func unknownStyle(name, presetDir string) error {
+	return fmt.Errorf("unknown style %q (available: %s)", name, strings.Join(StyleNames(presetDir), ", "))
+}
+
+// StyleNames returns the built-in preset names plus any custom presets in presetDir, sorted
+func StyleNames(presetDir string) []string {
+	seen := make(map[string]bool)
+	for name := range builtinStyles {
+		seen[name] = true
+	}
+
+	if entries, err := os.ReadDir(presetDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			ext := filepath.Ext(entry.Name())
+			for _, known := range presetExtensions {
+				if ext == known {
+					seen[strings.TrimSuffix(entry.Name(), ext)] = true
+					break
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadStyleBuiltin(t *testing.T) {
+	for _, name := range []string{"terse", "explain", "code"} {
+		style, err := LoadStyle(name, t.TempDir())
+		if err != nil {
+			t.Errorf("%s: LoadStyle failed: %v", name, err)
+			continue
+		}
+		if style.Source != SourceBuiltin || style.System == "" {
+			t.Errorf("%s: unexpected style %+v", name, style)
+		}
+	}
+
+	style, err := LoadStyle("", "")
+	if err != nil || style.Name != DefaultStyle || style.System != DefaultSystem {
+		t.Errorf("Expected default style, got %+v (%v)", style, err)
+	}
+}
+
+func TestLoadStyleCustomFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pirate.md"), []byte("Answer like a pirate.\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "terse.txt"), []byte("Custom terse."), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	style, err := LoadStyle("pirate", dir)
+	if err != nil {
+		t.Fatalf("LoadStyle failed: %v", err)
+	}
+	if style.System != "Answer like a pirate.\n" || style.Source != filepath.Join(dir, "pirate.md") {
+		t.Errorf("Unexpected custom style %+v", style)
+	}
+
+	style, err = LoadStyle("terse", dir)
+	if err != nil || style.System != "Custom terse.\n" {
+		t.Errorf("Expected custom file to override builtin, got %+v (%v)", style, err)
+	}
+
+	names := strings.Join(StyleNames(dir), ",")
+	if names != "code,explain,pirate,terse" {
+		t.Errorf("Unexpected style names %s", names)
+	}
+}
+
+func TestLoadStyleUnknown(t *testing.T) {
+	_, err := LoadStyle("nope", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "explain") {
+		t.Errorf("Expected unknown style error listing presets, got %v", err)
+	}
+}
+
+func TestLoadStyleRejectsPaths(t *testing.T) {
+	root := t.TempDir()
+	presets := filepath.Join(root, "prompts")
+	if err := os.Mkdir(presets, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "secret.md"), []byte("outside the preset directory"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"../secret", "sub/terse", `..\secret`, "..", filepath.Join(root, "secret")} {
+		style, err := LoadStyle(name, presets)
+		if err == nil || !strings.Contains(err.Error(), "unknown style") {
+			t.Errorf("%q: expected unknown style error, got %+v, %v", name, style, err)
+		}
+	}
+}
@@ -4,19 +4,43 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
 
 	codexContext "codex/internal/context"
+	"codex/internal/logging"
+	"codex/internal/prompt"
 )
 
+// DefaultAnthropicModel is used when no model is configured
+const DefaultAnthropicModel = "claude-3-5-haiku-20241022" // Claude 3.5 Haiku - fast and efficient
+
+func init() {
+	Register(Backend{
+		Name:         "anthropic",
+		Description:  "Anthropic Claude API",
+		DefaultModel: DefaultAnthropicModel,
+		Requires: []Requirement{
+			{Key: "anthropic_key", Env: "ANTHROPIC_API_KEY", Value: func(cfg *Config) string { return cfg.AnthropicKey }},
+		},
+		Factory: func(cfg *Config) (Provider, error) {
+			p := NewAnthropicProviderWithModel(cfg.AnthropicKey, cfg.Model)
+			p.SetSystemPrompt(cfg.SystemPrompt)
+			p.SetPricing(cfg.Pricing)
+			return p, nil
+		},
+	})
+}
+
 // AnthropicProvider implements the Provider interface for Anthropic Claude
 type AnthropicProvider struct {
-	apiKey string
-	model  string
-	client *anthropic.Client
+	apiKey  string
+	model   string
+	client  *anthropic.Client
+	prompts *prompt.Builder
+	usage   Usage              // Token usage of the most recent query
+	pricing map[string]Pricing // Overrides of the built-in pricing table
 }
 
 // NewAnthropicProvider creates a new Anthropic provider
@@ -26,9 +50,10 @@ func NewAnthropicProvider(apiKey string) *AnthropicProvider {
 	)
 
 	return &AnthropicProvider{
-		apiKey: apiKey,
-		model:  "claude-3-5-haiku-20241022", // Claude 3.5 Haiku - fast and efficient
-		client: &client,
+		apiKey:  apiKey,
+		model:   DefaultAnthropicModel,
+		client:  &client,
+		prompts: prompt.NewBuilder(""),
 	}
 }
 
@@ -39,13 +64,14 @@ func NewAnthropicProviderWithModel(apiKey string, model string) *AnthropicProvid
 	)
 
 	if model == "" {
-		model = "claude-3-5-haiku-20241022" // Default to Haiku
+		model = DefaultAnthropicModel
 	}
 
 	return &AnthropicProvider{
-		apiKey: apiKey,
-		model:  model,
-		client: &client,
+		apiKey:  apiKey,
+		model:   model,
+		client:  &client,
+		prompts: prompt.NewBuilder(""),
 	}
 }
 
@@ -56,28 +82,30 @@ func (p *AnthropicProvider) Name() string {
 
 // SendQuery sends a query to Anthropic Claude API
 func (p *AnthropicProvider) SendQuery(ctx context.Context, query string, contextData *codexContext.Context, writer io.Writer) error {
-	// Calculate and print context memory usage
-	memoryBytes := calculateContextMemory(contextData)
-	fmt.Fprintf(writer, "Context Memory: %s\n\n", formatBytes(memoryBytes))
-
 	// Build the prompt with context
-	prompt := p.buildPrompt(query, contextData)
+	built := p.prompts.Build(query, contextData)
 
 	// Create the message request
-	stream := p.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(p.model),
-		MaxTokens: 4096,
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
-		},
-	})
+	stream := p.client.Messages.NewStreaming(ctx, p.buildParams(built))
 
 	// Stream the response
+	p.usage = Usage{}
 	for stream.Next() {
 		event := stream.Current()
 
-		// Handle content block delta events - check the type string
-		if event.Type == "content_block_delta" {
+		switch event.Type {
+		case "message_start":
+			// Input and cache usage is reported once, when the message starts
+			p.usage.InputTokens = event.Message.Usage.InputTokens
+			p.usage.CacheReadTokens = event.Message.Usage.CacheReadInputTokens
+			p.usage.CacheWriteTokens = event.Message.Usage.CacheCreationInputTokens
+			p.usage.OutputTokens = event.Message.Usage.OutputTokens
+
+		case "message_delta":
+			// Output usage is cumulative
+			p.usage.OutputTokens = event.Usage.OutputTokens
+
+		case "content_block_delta":
 			// Access the Text field from the Delta
 			if event.Delta.Text != "" {
 				if _, err := writer.Write([]byte(event.Delta.Text)); err != nil {
@@ -91,93 +119,56 @@ func (p *AnthropicProvider) SendQuery(ctx context.Context, query string, context
 		return fmt.Errorf("stream error: %w", err)
 	}
 
+	logging.Logger.Debug().
+		Int64("input_tokens", p.usage.InputTokens).
+		Int64("output_tokens", p.usage.OutputTokens).
+		Int64("cache_read_tokens", p.usage.CacheReadTokens).
+		Int64("cache_write_tokens", p.usage.CacheWriteTokens).
+		Msg("Anthropic usage")
+
 	// Add newline at the end
 	writer.Write([]byte("\n"))
 
 	return nil
 }
 
-// buildPrompt constructs the prompt from query and context
-func (p *AnthropicProvider) buildPrompt(query string, ctx *codexContext.Context) string {
-	var sb strings.Builder
-
-	sb.WriteString("You are Codex, a ruthlessly concise CLI assistant.\n\n")
-	sb.WriteString("**ABSOLUTE RULES - VIOLATING THESE IS UNACCEPTABLE**:\n")
-	sb.WriteString("1. ANSWER IN 5 WORDS OR LESS when possible.\n")
-	sb.WriteString("2. NO introductions. NO explanations. NO context. NO examples. NO code blocks.\n")
-	sb.WriteString("3. Format for lookups: `value` (file:line)\n")
-	sb.WriteString("4. DO NOT explain what the user will do with the answer.\n")
-	sb.WriteString("5. DO NOT restate the question.\n")
-	sb.WriteString("6. If you write more than one sentence, you have FAILED.\n\n")
-
-	// Add context sections
-	if ctx != nil {
-		if len(ctx.ConfiguredRepos) > 0 {
-			sb.WriteString("## Configured Repositories\n\n")
-			for _, repo := range ctx.ConfiguredRepos {
-				sb.WriteString(fmt.Sprintf("### Repository: %s (%s)\n", repo.Source, repo.Type))
-				if repo.Remote != "" {
-					sb.WriteString(fmt.Sprintf("Remote: %s\n", repo.Remote))
-				}
-				sb.WriteString(fmt.Sprintf("Path: %s\n", repo.Path))
-
-				// Include file contents if available
-				if repo.Contents != nil {
-					sb.WriteString(fmt.Sprintf("\n**Files: %d files, %d bytes total**\n\n",
-						repo.Contents.TotalFiles, repo.Contents.TotalSize))
-
-					for _, file := range repo.Contents.Files {
-						sb.WriteString(fmt.Sprintf("#### File: %s\n", file.RelativePath))
-						sb.WriteString("```\n")
-						sb.WriteString(file.Content)
-						if !strings.HasSuffix(file.Content, "\n") {
-							sb.WriteString("\n")
-						}
-						sb.WriteString("```\n\n")
-					}
-				}
-				sb.WriteString("\n")
-			}
-		}
-
-		if ctx.CurrentRepo != nil {
-			sb.WriteString("## Current Repository\n")
-			sb.WriteString(fmt.Sprintf("Path: %s\n", ctx.CurrentRepo.Path))
-			if ctx.CurrentRepo.Remote != "" {
-				sb.WriteString(fmt.Sprintf("Remote: %s\n", ctx.CurrentRepo.Remote))
-			}
-
-			// Include file contents if available
-			if ctx.CurrentRepo.Contents != nil {
-				sb.WriteString(fmt.Sprintf("\n**Files: %d files, %d bytes total**\n\n",
-					ctx.CurrentRepo.Contents.TotalFiles, ctx.CurrentRepo.Contents.TotalSize))
-
-				for _, file := range ctx.CurrentRepo.Contents.Files {
-					sb.WriteString(fmt.Sprintf("#### File: %s\n", file.RelativePath))
-					sb.WriteString("```\n")
-					sb.WriteString(file.Content)
-					if !strings.HasSuffix(file.Content, "\n") {
-						sb.WriteString("\n")
-					}
-					sb.WriteString("```\n\n")
-				}
-			}
-			sb.WriteString("\n")
+// buildParams maps a prompt onto a Messages API request. The rules go in the
+// system field, each context document becomes its own document block, and
+// the question is the last block of the user turn.
+//
+// A cache breakpoint is placed on the last stable document, so the system
+// prompt and configured repositories are cached as one prefix and reused by
+// consecutive questions.
+func (p *AnthropicProvider) buildParams(built *prompt.Prompt) anthropic.MessageNewParams {
+	lastStable := -1
+	for i, doc := range built.Documents {
+		if doc.Stable {
+			lastStable = i
 		}
+	}
 
-		if ctx.Filesystem != nil {
-			sb.WriteString("## Filesystem Context\n")
-			sb.WriteString(fmt.Sprintf("Current Directory: %s\n", ctx.Filesystem.CurrentDir))
-			sb.WriteString("\n")
+	blocks := make([]anthropic.ContentBlockParamUnion, 0, len(built.Documents)+1)
+	for i, doc := range built.Documents {
+		block := anthropic.NewDocumentBlock(anthropic.PlainTextSourceParam{Data: doc.Content})
+		block.OfDocument.Title = anthropic.String(doc.Title)
+		block.OfDocument.Context = anthropic.String(doc.Section)
+		if i == lastStable {
+			block.OfDocument.CacheControl = anthropic.NewCacheControlEphemeralParam()
 		}
+		blocks = append(blocks, block)
 	}
+	blocks = append(blocks, anthropic.NewTextBlock(built.RenderQuery()))
 
-	// Add the user's query
-	sb.WriteString("## User Query\n")
-	sb.WriteString(query)
-	sb.WriteString("\n")
-
-	return sb.String()
+	return anthropic.MessageNewParams{
+		Model:     anthropic.Model(p.model),
+		MaxTokens: 4096,
+		System: []anthropic.TextBlockParam{
+			{Text: built.System},
+		},
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(blocks...),
+		},
+	}
 }
 
 // Validate checks if the provider is properly configured
@@ -190,110 +181,37 @@ func (p *AnthropicProvider) Validate() error {
 
 // EstimateTokens estimates token count for a query
 func (p *AnthropicProvider) EstimateTokens(query string, context *codexContext.Context) (int, error) {
-	// Rough estimation: ~4 characters per token
-	prompt := p.buildPrompt(query, context)
-	estimatedTokens := len(prompt) / 4
-	return estimatedTokens, nil
+	rendered := p.prompts.Build(query, context).Render()
+	return TokenizerFor(p.model).Count(rendered), nil
 }
 
-// GetCostEstimate returns estimated cost in USD
+// GetCostEstimate returns estimated cost in USD for a query of tokens input
+// tokens, priced for the configured model as if nothing were cached
 func (p *AnthropicProvider) GetCostEstimate(tokens int) (float64, error) {
-	// Claude 3.5 Sonnet pricing (as of late 2024):
-	// Input: $3 per million tokens
-	// Output: $15 per million tokens
-	// For estimation, assume 1:1 input/output ratio
-	const avgCostPerToken = (3.0 + 15.0) / 2.0 / 1_000_000
-	return float64(tokens) * avgCostPerToken, nil
+	return estimateCost(p.model, p.pricing, tokens)
 }
 
-// SetModel allows changing the model
-func (p *AnthropicProvider) SetModel(model string) {
-	p.model = model
+// LastUsage returns the token usage reported for the most recent query
+func (p *AnthropicProvider) LastUsage() Usage {
+	return p.usage
 }
 
-// calculateContextMemory calculates the total memory usage of the context data
-func calculateContextMemory(ctx *codexContext.Context) int64 {
-	if ctx == nil {
-		return 0
-	}
-
-	var totalBytes int64
-
-	// Calculate configured repos memory
-	for _, repo := range ctx.ConfiguredRepos {
-		totalBytes += calculateRepoMemory(repo)
-	}
-
-	// Calculate current repo memory
-	if ctx.CurrentRepo != nil {
-		totalBytes += calculateRepoMemory(ctx.CurrentRepo)
-	}
-
-	// Calculate filesystem context
-	if ctx.Filesystem != nil {
-		totalBytes += int64(len(ctx.Filesystem.CurrentDir))
-		for _, dir := range ctx.Filesystem.ParentDirs {
-			totalBytes += int64(len(dir))
-		}
-		for _, file := range ctx.Filesystem.Files {
-			totalBytes += int64(len(file))
-		}
-	}
-
-	// Calculate screenshot memory
-	if ctx.Screenshot != nil {
-		totalBytes += int64(len(ctx.Screenshot.Path))
-		totalBytes += int64(len(ctx.Screenshot.Data))
-		totalBytes += int64(len(ctx.Screenshot.MimeType))
-		totalBytes += int64(len(ctx.Screenshot.Tool))
-	}
-
-	return totalBytes
+// GetUsageCost returns the cost in USD of the given token usage
+func (p *AnthropicProvider) GetUsageCost(usage Usage) (float64, error) {
+	return usageCost(p.model, p.pricing, usage)
 }
 
-// calculateRepoMemory calculates memory usage for a single repository context
-func calculateRepoMemory(repo *codexContext.RepositoryContext) int64 {
-	if repo == nil {
-		return 0
-	}
-
-	var totalBytes int64
-
-	// Basic strings
-	totalBytes += int64(len(repo.Path))
-	totalBytes += int64(len(repo.Remote))
-	totalBytes += int64(len(repo.Source))
-	totalBytes += int64(len(repo.Type))
-
-	// Calculate contents memory
-	if repo.Contents != nil {
-		for _, file := range repo.Contents.Files {
-			totalBytes += int64(len(file.Path))
-			totalBytes += int64(len(file.RelativePath))
-			totalBytes += int64(len(file.Content))
-			totalBytes += int64(file.Size) // This counts actual file size
-		}
-	}
-
-	return totalBytes
+// SetModel allows changing the model
+func (p *AnthropicProvider) SetModel(model string) {
+	p.model = model
 }
 
-// formatBytes formats a byte count into a human-readable string
-func formatBytes(bytes int64) string {
-	const (
-		KB = 1024
-		MB = KB * 1024
-		GB = MB * 1024
-	)
+// SetSystemPrompt replaces the system prompt (empty restores the default)
+func (p *AnthropicProvider) SetSystemPrompt(system string) {
+	p.prompts = prompt.NewBuilder(system)
+}
 
-	switch {
-	case bytes >= GB:
-		return fmt.Sprintf("%.2f GB (%d bytes)", float64(bytes)/float64(GB), bytes)
-	case bytes >= MB:
-		return fmt.Sprintf("%.2f MB (%d bytes)", float64(bytes)/float64(MB), bytes)
-	case bytes >= KB:
-		return fmt.Sprintf("%.2f KB (%d bytes)", float64(bytes)/float64(KB), bytes)
-	default:
-		return fmt.Sprintf("%d bytes", bytes)
-	}
+// SetPricing sets per-model overrides of the built-in pricing table
+func (p *AnthropicProvider) SetPricing(overrides map[string]Pricing) {
+	p.pricing = overrides
 }
@@ -0,0 +1,159 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+
+	codexContext "codex/internal/context"
+)
+
+// anthropicRequest is the subset of a Messages API request inspected by tests
+type anthropicRequest struct {
+	System []struct {
+		Text string `json:"text"`
+	} `json:"system"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type   string `json:"type"`
+			Text   string `json:"text"`
+			Title  string `json:"title"`
+			Source struct {
+				Data string `json:"data"`
+			} `json:"source"`
+			CacheControl *struct {
+				Type string `json:"type"`
+			} `json:"cache_control"`
+		} `json:"content"`
+	} `json:"messages"`
+}
+
+// marshalAnthropicParams builds and decodes the request for a query and context
+func marshalAnthropicParams(t *testing.T, p *AnthropicProvider, query string, ctx *codexContext.Context) anthropicRequest {
+	t.Helper()
+
+	data, err := json.Marshal(p.buildParams(p.prompts.Build(query, ctx)))
+	if err != nil {
+		t.Fatalf("Failed to marshal params: %v", err)
+	}
+
+	var req anthropicRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("Failed to decode params: %v", err)
+	}
+	return req
+}
+
+func TestAnthropicBuildParamsSeparatesBlocks(t *testing.T) {
+	p := NewAnthropicProvider("key")
+	p.SetSystemPrompt("Be brief.")
+
+	ctx := &codexContext.Context{
+		ConfiguredRepos: []*codexContext.RepositoryContext{
+			{Path: "/a", Source: "/a", Type: "local"},
+			{Path: "/b", Source: "/b", Type: "local"},
+		},
+		Filesystem: &codexContext.FilesystemContext{CurrentDir: "/home"},
+	}
+
+	req := marshalAnthropicParams(t, p, "tmux prefix?", ctx)
+
+	if len(req.System) != 1 || req.System[0].Text != "Be brief." {
+		t.Errorf("Expected rules in system field, got %+v", req.System)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+		t.Fatalf("Expected a single user message, got %+v", req.Messages)
+	}
+
+	content := req.Messages[0].Content
+	if len(content) != 4 {
+		t.Fatalf("Expected 3 documents and the question, got %d blocks", len(content))
+	}
+	for i, title := range []string{"/a", "/b", "/home"} {
+		if content[i].Type != "document" || content[i].Title != title {
+			t.Errorf("Block %d: expected document %q, got %s %q", i, title, content[i].Type, content[i].Title)
+		}
+	}
+
+	last := content[len(content)-1]
+	if last.Type != "text" || last.Text != "## User Query\ntmux prefix?\n" {
+		t.Errorf("Expected question as last text block, got %+v", last)
+	}
+}
+
+func TestAnthropicBuildParamsCachesConfiguredRepos(t *testing.T) {
+	p := NewAnthropicProvider("key")
+
+	ctx := &codexContext.Context{
+		ConfiguredRepos: []*codexContext.RepositoryContext{
+			{Path: "/a", Source: "/a", Type: "local"},
+			{Path: "/b", Source: "/b", Type: "local"},
+		},
+		CurrentRepo: &codexContext.RepositoryContext{Path: "/work", Type: "current"},
+	}
+
+	content := marshalAnthropicParams(t, p, "q", ctx).Messages[0].Content
+	for i, block := range content {
+		cached := block.CacheControl != nil && block.CacheControl.Type == "ephemeral"
+		if want := i == 1; cached != want {
+			t.Errorf("Block %d (%s): expected cache breakpoint %v, got %v", i, block.Title, want, cached)
+		}
+	}
+
+	// Without configured repos there is nothing stable worth caching
+	content = marshalAnthropicParams(t, p, "q", &codexContext.Context{CurrentRepo: ctx.CurrentRepo}).Messages[0].Content
+	for i, block := range content {
+		if block.CacheControl != nil {
+			t.Errorf("Block %d: unexpected cache breakpoint", i)
+		}
+	}
+}
+
+func TestAnthropicSendQueryRecordsUsage(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"m","usage":{"input_tokens":12,"cache_read_input_tokens":3400,"cache_creation_input_tokens":0,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"C-a"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var typed struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal([]byte(event), &typed)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, event)
+		}
+	}))
+	defer server.Close()
+
+	p := NewAnthropicProvider("key")
+	client := anthropic.NewClient(option.WithAPIKey("key"), option.WithBaseURL(server.URL))
+	p.client = &client
+
+	var out bytes.Buffer
+	if err := p.SendQuery(context.Background(), "q", nil, &out); err != nil {
+		t.Fatalf("SendQuery failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "C-a\n") {
+		t.Errorf("Expected streamed answer, got %q", out.String())
+	}
+
+	want := Usage{InputTokens: 12, OutputTokens: 4, CacheReadTokens: 3400}
+	if p.usage != want {
+		t.Errorf("Expected usage %+v, got %+v", want, p.usage)
+	}
+}
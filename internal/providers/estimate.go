@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"fmt"
+
+	codexContext "codex/internal/context"
+	"codex/internal/prompt"
+)
+
+// Estimate is the size and expected cost of a query, computed before it is sent
+type Estimate struct {
+	ContextBytes int64
+	Tokens       int
+	Cost         float64 // USD, including a typical answer
+	CostErr      error   // Set when the model has no known pricing
+}
+
+// EstimateQuery computes the pre-flight size and cost of sending query with contextData
+func EstimateQuery(p Provider, query string, contextData *codexContext.Context) (*Estimate, error) {
+	tokens, err := p.EstimateTokens(query, contextData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate tokens: %w", err)
+	}
+
+	est := &Estimate{
+		ContextBytes: ContextMemory(contextData),
+		Tokens:       tokens,
+	}
+	est.Cost, est.CostErr = p.GetCostEstimate(tokens)
+	return est, nil
+}
+
+// Exceeds reports whether the estimate is above either limit (0 disables a limit).
+// An unknown cost never exceeds the cost limit.
+func (e *Estimate) Exceeds(maxUSD float64, maxTokens int) bool {
+	if maxTokens > 0 && e.Tokens > maxTokens {
+		return true
+	}
+	return maxUSD > 0 && e.CostErr == nil && e.Cost > maxUSD
+}
+
+// String summarizes the estimate for display
+func (e *Estimate) String() string {
+	cost := "unknown cost"
+	if e.CostErr == nil {
+		cost = fmt.Sprintf("~$%.4f", e.Cost)
+	}
+	return fmt.Sprintf("%s, ~%d tokens, %s", FormatBytes(e.ContextBytes), e.Tokens, cost)
+}
+
+// ContextMemory returns the size in bytes of the context as it is rendered
+// into the prompt: every file counted once, with the Nix configuration,
+// dotfiles and filesystem sections. A screenshot's data is added on top.
+func ContextMemory(ctx *codexContext.Context) int64 {
+	if ctx == nil {
+		return 0
+	}
+
+	totalBytes := int64(len(prompt.Build("", ctx).RenderDocuments()))
+	if ctx.Screenshot != nil {
+		totalBytes += int64(len(ctx.Screenshot.Data))
+	}
+	return totalBytes
+}
+
+// FormatBytes formats a byte count into a human-readable string
+func FormatBytes(bytes int64) string {
+	const (
+		KB = 1024
+		MB = KB * 1024
+		GB = MB * 1024
+	)
+
+	switch {
+	case bytes >= GB:
+		return fmt.Sprintf("%.2f GB (%d bytes)", float64(bytes)/float64(GB), bytes)
+	case bytes >= MB:
+		return fmt.Sprintf("%.2f MB (%d bytes)", float64(bytes)/float64(MB), bytes)
+	case bytes >= KB:
+		return fmt.Sprintf("%.2f KB (%d bytes)", float64(bytes)/float64(KB), bytes)
+	default:
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+}
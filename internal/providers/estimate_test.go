@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	codexContext "codex/internal/context"
+	"codex/internal/prompt"
+)
+
+// errUnpriced stands in for the error of a model with no pricing
+var errUnpriced = errors.New("no pricing")
+
+func TestEstimateQuery(t *testing.T) {
+	ctx := &codexContext.Context{
+		Filesystem: &codexContext.FilesystemContext{CurrentDir: "/home/user"},
+	}
+
+	est, err := EstimateQuery(NewAnthropicProvider("k"), "tmux prefix?", ctx)
+	if err != nil {
+		t.Fatalf("EstimateQuery failed: %v", err)
+	}
+	if want := int64(len(prompt.Build("", ctx).RenderDocuments())); est.ContextBytes != want {
+		t.Errorf("Expected context bytes %d, got %d", want, est.ContextBytes)
+	}
+	if est.Tokens == 0 || est.CostErr != nil || est.Cost <= 0 {
+		t.Errorf("Expected priced token estimate, got %+v", est)
+	}
+
+	est, _ = EstimateQuery(NewOpenAIProviderWithModel("k", "unpriced-model"), "q", ctx)
+	if est.CostErr == nil || !strings.Contains(est.String(), "unknown cost") {
+		t.Errorf("Expected unknown cost, got %q (%v)", est.String(), est.CostErr)
+	}
+}
+
+func TestContextMemory(t *testing.T) {
+	content := strings.Repeat("x", 1000)
+	ctx := &codexContext.Context{
+		CurrentRepo: &codexContext.RepositoryContext{Path: "/src/app", Contents: &codexContext.RepoContents{
+			Files: []codexContext.FileContent{{Path: "/src/app/main.go", RelativePath: "main.go", Content: content, Size: len(content)}},
+		}},
+		NixConfig: &codexContext.NixContext{ConfigPath: "/etc/nixos", Packages: []string{strings.Repeat("p", 500)}},
+		Dotfiles: &codexContext.DotfilesContext{
+			DotfilesPath: "/dotfiles",
+			Keybindings:  map[string][]codexContext.Keybind{"tmux": {{Key: "C-a", Command: strings.Repeat("c", 300)}}},
+		},
+	}
+
+	// The file is counted once, alongside the Nix and dotfiles sections
+	got := ContextMemory(ctx)
+	if got < 1800 || got >= 2000+300 {
+		t.Errorf("Expected the file, package and binding counted once each, got %d bytes", got)
+	}
+	if ContextMemory(nil) != 0 {
+		t.Error("Expected no bytes for a nil context")
+	}
+}
+
+func TestEstimateExceeds(t *testing.T) {
+	tests := []struct {
+		est       Estimate
+		maxUSD    float64
+		maxTokens int
+		want      bool
+	}{
+		{Estimate{Tokens: 1000, Cost: 0.05}, 0.10, 0, false},
+		{Estimate{Tokens: 1000, Cost: 0.50}, 0.10, 0, true},
+		{Estimate{Tokens: 1000, Cost: 0.50}, 0, 0, false},
+		{Estimate{Tokens: 200000, Cost: 0.05}, 0.10, 100000, true},
+		{Estimate{Tokens: 1000, CostErr: errUnpriced}, 0.10, 0, false},
+		{Estimate{Tokens: 200000, CostErr: errUnpriced}, 0.10, 100000, true},
+	}
+
+	for i, tt := range tests {
+		if got := tt.est.Exceeds(tt.maxUSD, tt.maxTokens); got != tt.want {
+			t.Errorf("case %d: expected Exceeds=%v, got %v", i, tt.want, got)
+		}
+	}
+}
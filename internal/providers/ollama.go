@@ -1,25 +1,71 @@
 package providers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
+	"time"
 
+	"codex/internal/config"
 	codexContext "codex/internal/context"
+	codexErrors "codex/internal/errors"
+	"codex/internal/prompt"
 )
 
+// ollamaPingTimeout bounds how long Validate waits for the local daemon
+const ollamaPingTimeout = 5 * time.Second
+
+// DefaultOllamaModel is used when no model is configured
+const DefaultOllamaModel = "llama2"
+
+func init() {
+	Register(Backend{
+		Name:         "ollama",
+		Description:  "Local models served by the Ollama daemon",
+		DefaultModel: DefaultOllamaModel,
+		Factory: func(cfg *Config) (Provider, error) {
+			baseURL := cfg.OllamaURL
+			if baseURL == "" {
+				baseURL = config.DefaultOllamaURL
+			}
+			p := NewOllamaProviderWithModel(baseURL, cfg.Model)
+			p.SetSystemPrompt(cfg.SystemPrompt)
+			return p, nil
+		},
+	})
+}
+
 // OllamaProvider implements the Provider interface for local Ollama
 type OllamaProvider struct {
 	baseURL string
 	model   string
+	client  *http.Client
+	prompts *prompt.Builder
+	usage   Usage // Token usage of the most recent query
 }
 
 // NewOllamaProvider creates a new Ollama provider
 func NewOllamaProvider(baseURL string) *OllamaProvider {
 	return &OllamaProvider{
 		baseURL: baseURL,
-		model:   "llama2", // Default model
+		model:   DefaultOllamaModel,
+		client:  http.DefaultClient,
+		prompts: prompt.NewBuilder(""),
+	}
+}
+
+// NewOllamaProviderWithModel creates a new Ollama provider with a specific model
+func NewOllamaProviderWithModel(baseURL string, model string) *OllamaProvider {
+	p := NewOllamaProvider(baseURL)
+	if model != "" {
+		p.model = model
 	}
+	return p
 }
 
 // Name returns the provider name
@@ -27,29 +73,147 @@ func (p *OllamaProvider) Name() string {
 	return "ollama"
 }
 
+// ollamaMessage is a single chat message in an /api/chat request or response
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ollamaChatRequest is the body of an /api/chat request
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+}
+
+// ollamaChatChunk is one NDJSON line of a streaming /api/chat response
+type ollamaChatChunk struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+
+	// Token counts, reported on the final chunk
+	PromptEvalCount int64 `json:"prompt_eval_count,omitempty"`
+	EvalCount       int64 `json:"eval_count,omitempty"`
+}
+
+// ollamaTagsResponse is the body of an /api/tags response
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	} `json:"models"`
+}
+
 // SendQuery sends a query to Ollama
-func (p *OllamaProvider) SendQuery(ctx context.Context, query string, context *codexContext.Context, writer io.Writer) error {
-	// TODO: Implement actual API call
-	// - Build prompt with context
-	// - Make request to Ollama API
-	// - Stream response to writer
-	// - Handle errors
-	return fmt.Errorf("not yet implemented")
+func (p *OllamaProvider) SendQuery(ctx context.Context, query string, contextData *codexContext.Context, writer io.Writer) error {
+	built := p.prompts.Build(query, contextData)
+	body, err := json.Marshal(ollamaChatRequest{
+		Model: p.model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: built.System},
+			{Role: "user", Content: built.RenderUser()},
+		},
+		Stream: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint("api/chat"), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return p.unavailable(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ollamaResponseError(resp)
+	}
+
+	// Stream the response
+	p.usage = Usage{}
+	if err := p.streamResponse(resp.Body, writer); err != nil {
+		return err
+	}
+
+	// Add newline at the end
+	writer.Write([]byte("\n"))
+
+	return nil
 }
 
-// Validate checks if Ollama is accessible
-func (p *OllamaProvider) Validate() error {
-	// TODO: Ping Ollama to check if it's running
-	// - Make a simple request to /api/tags or similar
-	// - Check if the model is available
+// streamResponse copies the message content of an NDJSON stream to writer
+func (p *OllamaProvider) streamResponse(body io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var chunk ollamaChatChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return codexErrors.Wrap(codexErrors.ErrProviderAPIError, codexErrors.CodeProviderAPIError,
+				fmt.Sprintf("malformed stream line: %v", err))
+		}
+
+		if chunk.Error != "" {
+			return codexErrors.Wrap(codexErrors.ErrProviderAPIError, codexErrors.CodeProviderAPIError,
+				fmt.Sprintf("ollama stream error: %s", chunk.Error))
+		}
+
+		if chunk.Message.Content != "" {
+			if _, err := writer.Write([]byte(chunk.Message.Content)); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+
+		if chunk.Done {
+			p.usage = Usage{InputTokens: chunk.PromptEvalCount, OutputTokens: chunk.EvalCount}
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream error: %w", err)
+	}
+
 	return nil
 }
 
+// Validate checks if Ollama is accessible and the configured model is pulled
+func (p *OllamaProvider) Validate() error {
+	if p.model == "" {
+		return fmt.Errorf("ollama model is required")
+	}
+
+	models, err := p.ListModels()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range models {
+		if ollamaModelMatches(name, p.model) {
+			return nil
+		}
+	}
+
+	return codexErrors.Wrap(codexErrors.ErrProviderInvalid, codexErrors.CodeProviderInvalid,
+		fmt.Sprintf("ollama model %q is not pulled (run `ollama pull %s`)", p.model, p.model))
+}
+
 // EstimateTokens estimates token count for a query
 func (p *OllamaProvider) EstimateTokens(query string, context *codexContext.Context) (int, error) {
-	// For local models, we can be more lenient with estimation
-	// TODO: Implement basic token estimation
-	return 0, fmt.Errorf("not yet implemented")
+	rendered := p.prompts.Build(query, context).Render()
+	return TokenizerFor(p.model).Count(rendered), nil
 }
 
 // GetCostEstimate returns estimated cost (always 0 for local)
@@ -58,14 +222,106 @@ func (p *OllamaProvider) GetCostEstimate(tokens int) (float64, error) {
 	return 0.0, nil
 }
 
+// LastUsage returns the token usage reported for the most recent query
+func (p *OllamaProvider) LastUsage() Usage {
+	return p.usage
+}
+
+// GetUsageCost returns the cost of the given usage (always 0 for local)
+func (p *OllamaProvider) GetUsageCost(usage Usage) (float64, error) {
+	return 0.0, nil
+}
+
 // SetModel allows changing the model
 func (p *OllamaProvider) SetModel(model string) {
 	p.model = model
 }
 
+// SetSystemPrompt replaces the system prompt (empty restores the default)
+func (p *OllamaProvider) SetSystemPrompt(system string) {
+	p.prompts = prompt.NewBuilder(system)
+}
+
 // ListModels returns available Ollama models
 func (p *OllamaProvider) ListModels() ([]string, error) {
-	// TODO: Query Ollama for available models
-	// - GET /api/tags
-	return nil, fmt.Errorf("not yet implemented")
+	ctx, cancel := context.WithTimeout(context.Background(), ollamaPingTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint("api/tags"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, p.unavailable(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ollamaResponseError(resp)
+	}
+
+	var tags ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, codexErrors.Wrap(codexErrors.ErrProviderAPIError, codexErrors.CodeProviderAPIError,
+			fmt.Sprintf("failed to decode model list: %v", err))
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		name := m.Name
+		if name == "" {
+			name = m.Model
+		}
+		models = append(models, name)
+	}
+
+	return models, nil
+}
+
+// endpoint joins a path onto the configured base URL
+func (p *OllamaProvider) endpoint(path string) string {
+	return strings.TrimRight(p.baseURL, "/") + "/" + path
+}
+
+// unavailable wraps a transport error as ErrProviderUnavailable
+func (p *OllamaProvider) unavailable(err error) error {
+	return codexErrors.Wrap(codexErrors.ErrProviderUnavailable, codexErrors.CodeProviderUnavailable,
+		fmt.Sprintf("cannot reach ollama at %s (is `ollama serve` running?): %v", p.baseURL, err))
+}
+
+// ollamaResponseError maps a non-200 HTTP response onto a provider error
+func ollamaResponseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	detail := strings.TrimSpace(string(data))
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Error != "" {
+		detail = apiErr.Error
+	}
+	message := fmt.Sprintf("ollama returned %s: %s", resp.Status, detail)
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return codexErrors.Wrap(codexErrors.ErrProviderRateLimit, codexErrors.CodeProviderRateLimit, message)
+	case http.StatusServiceUnavailable:
+		return codexErrors.Wrap(codexErrors.ErrProviderUnavailable, codexErrors.CodeProviderUnavailable, message)
+	default:
+		return codexErrors.Wrap(codexErrors.ErrProviderAPIError, codexErrors.CodeProviderAPIError, message)
+	}
+}
+
+// ollamaModelMatches reports whether a pulled model name satisfies the configured model.
+// Ollama tags untagged pulls as ":latest", so "llama3" matches "llama3:latest".
+func ollamaModelMatches(pulled, configured string) bool {
+	if pulled == configured {
+		return true
+	}
+	if !strings.Contains(configured, ":") {
+		return pulled == configured+":latest"
+	}
+	return false
 }
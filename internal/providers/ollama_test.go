@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	codexErrors "codex/internal/errors"
+)
+
+// newOllamaTestServer serves /api/tags with the given models and streams answer from /api/chat
+func newOllamaTestServer(t *testing.T, models []string, answer []string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		var tags ollamaTagsResponse
+		for _, m := range models {
+			tags.Models = append(tags.Models, struct {
+				Name  string `json:"name"`
+				Model string `json:"model"`
+			}{Name: m, Model: m})
+		}
+		json.NewEncoder(w).Encode(tags)
+	})
+	mux.HandleFunc("/api/chat", func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+		if !req.Stream || len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			t.Errorf("Unexpected chat request: %+v", req)
+		}
+		for _, part := range answer {
+			fmt.Fprintf(w, "{\"message\":{\"role\":\"assistant\",\"content\":%q},\"done\":false}\n", part)
+		}
+		fmt.Fprint(w, "{\"message\":{\"role\":\"assistant\",\"content\":\"\"},\"done\":true,\"prompt_eval_count\":812,\"eval_count\":4}\n")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOllamaSendQueryStreamsNDJSON(t *testing.T) {
+	server := newOllamaTestServer(t, []string{"llama3:latest"}, []string{"C-", "a"})
+
+	p := NewOllamaProviderWithModel(server.URL, "llama3")
+
+	var out bytes.Buffer
+	if err := p.SendQuery(context.Background(), "tmux prefix?", nil, &out); err != nil {
+		t.Fatalf("SendQuery failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "C-a\n") {
+		t.Errorf("Expected streamed answer at end of output, got %q", out.String())
+	}
+	if want := (Usage{InputTokens: 812, OutputTokens: 4}); p.LastUsage() != want {
+		t.Errorf("Expected usage %+v, got %+v", want, p.LastUsage())
+	}
+}
+
+func TestOllamaValidate(t *testing.T) {
+	server := newOllamaTestServer(t, []string{"llama3:latest", "qwen2.5-coder:7b"}, nil)
+
+	if err := NewOllamaProviderWithModel(server.URL, "llama3").Validate(); err != nil {
+		t.Errorf("Expected untagged model to match :latest, got %v", err)
+	}
+	if err := NewOllamaProviderWithModel(server.URL, "qwen2.5-coder:7b").Validate(); err != nil {
+		t.Errorf("Expected tagged model to match, got %v", err)
+	}
+
+	err := NewOllamaProviderWithModel(server.URL, "mistral").Validate()
+	if !errors.Is(err, codexErrors.ErrProviderInvalid) {
+		t.Errorf("Expected ErrProviderInvalid for missing model, got %v", err)
+	}
+}
+
+func TestOllamaValidateDaemonDown(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	err := NewOllamaProvider(url).Validate()
+	if !errors.Is(err, codexErrors.ErrProviderUnavailable) {
+		t.Errorf("Expected ErrProviderUnavailable, got %v", err)
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	server := newOllamaTestServer(t, []string{"llama3:latest", "phi3:mini"}, nil)
+
+	models, err := NewOllamaProvider(server.URL).ListModels()
+	if err != nil {
+		t.Fatalf("ListModels failed: %v", err)
+	}
+	if len(models) != 2 || models[0] != "llama3:latest" || models[1] != "phi3:mini" {
+		t.Errorf("Unexpected models: %v", models)
+	}
+}
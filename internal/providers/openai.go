@@ -1,40 +1,346 @@
 package providers
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strings"
 
 	codexContext "codex/internal/context"
+	codexErrors "codex/internal/errors"
+	"codex/internal/logging"
+	"codex/internal/prompt"
 )
 
+// DefaultOpenAIBaseURL is the base URL of the public OpenAI API
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// DefaultOpenAIModel is used when no model is configured
+const DefaultOpenAIModel = "gpt-4-turbo-preview"
+
+// openAIMaxTokens caps the length of an answer
+const openAIMaxTokens = 4096
+
+// openAIReasoningModels are the model families that reject max_tokens and
+// take max_completion_tokens instead
+var openAIReasoningModels = []string{"o1", "o3", "o4", "gpt-5"}
+
+func init() {
+	Register(Backend{
+		Name:         "openai",
+		Description:  "OpenAI chat completions API",
+		DefaultModel: DefaultOpenAIModel,
+		Requires: []Requirement{
+			{Key: "openai_key", Env: "OPENAI_API_KEY", Value: func(cfg *Config) string { return cfg.OpenAIKey }},
+		},
+		Factory: func(cfg *Config) (Provider, error) {
+			p := NewOpenAIProviderWithModel(cfg.OpenAIKey, cfg.Model)
+			if cfg.OpenAIURL != "" {
+				p.SetBaseURL(cfg.OpenAIURL)
+			}
+			p.SetSystemPrompt(cfg.SystemPrompt)
+			p.SetPricing(cfg.Pricing)
+			return p, nil
+		},
+	})
+}
+
 // OpenAIProvider implements the Provider interface for OpenAI
 type OpenAIProvider struct {
-	apiKey string
-	model  string
+	name    string
+	apiKey  string
+	model   string
+	baseURL string
+	headers map[string]string
+	client  *http.Client
+	prompts *prompt.Builder
+	usage   Usage              // Token usage of the most recent query
+	pricing map[string]Pricing // Overrides of the built-in pricing table
+
+	// streamUsage asks for usage in the stream with stream_options, which
+	// some compatible servers reject; it is turned off after such a 400
+	streamUsage bool
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
 func NewOpenAIProvider(apiKey string) *OpenAIProvider {
 	return &OpenAIProvider{
-		apiKey: apiKey,
-		model:  "gpt-4-turbo-preview", // Default model
+		name:    "openai",
+		apiKey:  apiKey,
+		model:   DefaultOpenAIModel,
+		baseURL: DefaultOpenAIBaseURL,
+		client:  http.DefaultClient,
+		prompts: prompt.NewBuilder(""),
+
+		streamUsage: true,
+	}
+}
+
+// NewOpenAIProviderWithModel creates a new OpenAI provider with a specific model
+func NewOpenAIProviderWithModel(apiKey string, model string) *OpenAIProvider {
+	p := NewOpenAIProvider(apiKey)
+	if model != "" {
+		p.model = model
 	}
+	return p
 }
 
 // Name returns the provider name
 func (p *OpenAIProvider) Name() string {
-	return "openai"
+	return p.name
+}
+
+// openAIMessage is a single chat message in a completions request
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// openAIChatRequest is the body of a /chat/completions request
+type openAIChatRequest struct {
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Stream        bool                 `json:"stream"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+
+	// MaxCompletionTokens replaces MaxTokens for reasoning models
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+}
+
+// openAIStreamOptions asks for a final chunk carrying the request's token usage
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAIUsage is the token usage reported in the final stream chunk
+type openAIUsage struct {
+	PromptTokens        int64 `json:"prompt_tokens"`
+	CompletionTokens    int64 `json:"completion_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int64 `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// openAIStreamChunk is a single server-sent event payload from a streaming completion
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage     `json:"usage,omitempty"`
+	Error *openAIErrorBody `json:"error,omitempty"`
+}
+
+// openAIErrorBody is the error object returned by the API
+type openAIErrorBody struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    any    `json:"code"`
 }
 
 // SendQuery sends a query to OpenAI API
-func (p *OpenAIProvider) SendQuery(ctx context.Context, query string, context *codexContext.Context, writer io.Writer) error {
-	// TODO: Implement actual API call
-	// - Build messages with context
-	// - Make API request using OpenAI SDK or HTTP client
-	// - Stream response to writer
-	// - Handle errors and retries
-	return fmt.Errorf("not yet implemented")
+func (p *OpenAIProvider) SendQuery(ctx context.Context, query string, contextData *codexContext.Context, writer io.Writer) error {
+	// Rules go in the system message, context and query in the user message
+	built := p.prompts.Build(query, contextData)
+	request := openAIChatRequest{
+		Model: p.model,
+		Messages: []openAIMessage{
+			{Role: "system", Content: built.System},
+			{Role: "user", Content: built.RenderUser()},
+		},
+		Stream: true,
+	}
+	if isOpenAIReasoningModel(p.model) {
+		request.MaxCompletionTokens = openAIMaxTokens
+	} else {
+		request.MaxTokens = openAIMaxTokens
+	}
+
+	resp, err := p.post(ctx, request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return openAIResponseError(p.name, resp)
+	}
+
+	// Stream the response
+	p.usage = Usage{}
+	if err := p.streamResponse(resp.Body, writer); err != nil {
+		return err
+	}
+
+	logging.Logger.Debug().
+		Int64("input_tokens", p.usage.InputTokens).
+		Int64("output_tokens", p.usage.OutputTokens).
+		Int64("cache_read_tokens", p.usage.CacheReadTokens).
+		Msgf("%s usage", p.name)
+
+	// Add newline at the end
+	writer.Write([]byte("\n"))
+
+	return nil
+}
+
+// post sends a chat request, asking for usage in the stream unless the
+// server has rejected that before. A server whose 400 names stream_options
+// is asked again without it, and never asked with it again; any other 400
+// is returned as it came.
+func (p *OpenAIProvider) post(ctx context.Context, request openAIChatRequest) (*http.Response, error) {
+	if p.streamUsage {
+		request.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	resp, err := p.do(ctx, request)
+	if err != nil || resp.StatusCode != http.StatusBadRequest || request.StreamOptions == nil {
+		return resp, err
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	if !strings.Contains(string(data), "stream_options") {
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		return resp, nil
+	}
+
+	logging.Logger.Debug().Msgf("%s rejected stream_options, retrying without usage", p.name)
+	p.streamUsage = false
+	request.StreamOptions = nil
+	return p.do(ctx, request)
+}
+
+// do sends one chat request
+func (p *OpenAIProvider) do(ctx context.Context, request openAIChatRequest) (*http.Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint("chat/completions"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	for key, value := range p.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, codexErrors.Wrap(codexErrors.ErrProviderUnavailable, codexErrors.CodeProviderUnavailable,
+			fmt.Sprintf("%s request failed: %v", p.name, err))
+	}
+	return resp, nil
+}
+
+// isOpenAIReasoningModel reports whether model is in a reasoning family,
+// e.g. o3-mini or gpt-5-nano, allowing a gateway prefix such as openai/
+func isOpenAIReasoningModel(model string) bool {
+	model = strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	for _, family := range openAIReasoningModels {
+		if model == family || strings.HasPrefix(model, family+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// streamResponse copies the content deltas of a server-sent event stream to writer
+func (p *OpenAIProvider) streamResponse(body io.Reader, writer io.Writer) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip blank separators, comments and non-data fields
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return codexErrors.Wrap(codexErrors.ErrProviderAPIError, codexErrors.CodeProviderAPIError,
+				fmt.Sprintf("malformed stream event: %v", err))
+		}
+
+		if chunk.Error != nil {
+			return codexErrors.Wrap(codexErrors.ErrProviderAPIError, codexErrors.CodeProviderAPIError,
+				fmt.Sprintf("%s stream error: %s", p.name, chunk.Error.Message))
+		}
+
+		if chunk.Usage != nil {
+			// Cached tokens are a subset of the prompt tokens
+			cached := chunk.Usage.PromptTokensDetails.CachedTokens
+			p.usage = Usage{
+				InputTokens:     chunk.Usage.PromptTokens - cached,
+				OutputTokens:    chunk.Usage.CompletionTokens,
+				CacheReadTokens: cached,
+			}
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if _, err := writer.Write([]byte(choice.Delta.Content)); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("stream error: %w", err)
+	}
+
+	return nil
+}
+
+// endpoint joins a path onto the configured base URL
+func (p *OpenAIProvider) endpoint(path string) string {
+	return strings.TrimRight(p.baseURL, "/") + "/" + path
+}
+
+// openAIResponseError maps a non-200 HTTP response onto a provider error
+func openAIResponseError(name string, resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	detail := strings.TrimSpace(string(data))
+	var apiErr struct {
+		Error openAIErrorBody `json:"error"`
+	}
+	if err := json.Unmarshal(data, &apiErr); err == nil && apiErr.Error.Message != "" {
+		detail = apiErr.Error.Message
+	}
+	message := fmt.Sprintf("%s returned %s: %s", name, resp.Status, detail)
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return codexErrors.Wrap(codexErrors.ErrProviderRateLimit, codexErrors.CodeProviderRateLimit, message)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return codexErrors.Wrap(codexErrors.ErrProviderInvalid, codexErrors.CodeProviderInvalid, message)
+	default:
+		return codexErrors.Wrap(codexErrors.ErrProviderAPIError, codexErrors.CodeProviderAPIError, message)
+	}
 }
 
 // Validate checks if the provider is properly configured
@@ -42,29 +348,61 @@ func (p *OpenAIProvider) Validate() error {
 	if p.apiKey == "" {
 		return fmt.Errorf("openai API key is required")
 	}
-	// TODO: Optionally test API key with a test request
+	if p.model == "" {
+		return fmt.Errorf("openai model is required")
+	}
+	if _, err := url.ParseRequestURI(p.baseURL); err != nil {
+		return fmt.Errorf("invalid openai base URL %q: %w", p.baseURL, err)
+	}
 	return nil
 }
 
 // EstimateTokens estimates token count for a query
 func (p *OpenAIProvider) EstimateTokens(query string, context *codexContext.Context) (int, error) {
-	// TODO: Implement token estimation
-	// - Use tiktoken or approximation
-	// - Count tokens in query and context
-	return 0, fmt.Errorf("not yet implemented")
+	rendered := p.prompts.Build(query, context).Render()
+	return TokenizerFor(p.model).Count(rendered), nil
 }
 
-// GetCostEstimate returns estimated cost in USD
+// GetCostEstimate returns estimated cost in USD for a query of tokens input
+// tokens, priced for the configured model
 func (p *OpenAIProvider) GetCostEstimate(tokens int) (float64, error) {
-	// GPT-4 Turbo pricing (as of late 2024):
-	// Input: $10 per million tokens
-	// Output: $30 per million tokens
-	// For estimation, assume 1:1 input/output ratio
-	const avgCostPerToken = (10.0 + 30.0) / 2.0 / 1_000_000
-	return float64(tokens) * avgCostPerToken, nil
+	return estimateCost(p.model, p.pricing, tokens)
+}
+
+// LastUsage returns the token usage reported for the most recent query
+func (p *OpenAIProvider) LastUsage() Usage {
+	return p.usage
+}
+
+// GetUsageCost returns the cost in USD of the given token usage
+func (p *OpenAIProvider) GetUsageCost(usage Usage) (float64, error) {
+	return usageCost(p.model, p.pricing, usage)
 }
 
 // SetModel allows changing the model
 func (p *OpenAIProvider) SetModel(model string) {
 	p.model = model
 }
+
+// SetSystemPrompt replaces the system prompt (empty restores the default)
+func (p *OpenAIProvider) SetSystemPrompt(system string) {
+	p.prompts = prompt.NewBuilder(system)
+}
+
+// SetPricing sets per-model overrides of the built-in pricing table
+func (p *OpenAIProvider) SetPricing(overrides map[string]Pricing) {
+	p.pricing = overrides
+}
+
+// SetBaseURL points the provider at a different API endpoint (proxies, test servers)
+func (p *OpenAIProvider) SetBaseURL(baseURL string) {
+	p.baseURL = baseURL
+}
+
+// SetHeader adds a header sent with every request (gateway auth, routing, etc.)
+func (p *OpenAIProvider) SetHeader(key, value string) {
+	if p.headers == nil {
+		p.headers = make(map[string]string)
+	}
+	p.headers[key] = value
+}
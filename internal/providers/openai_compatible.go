@@ -0,0 +1,60 @@
+package providers
+
+import (
+	"fmt"
+	"net/url"
+)
+
+func init() {
+	Register(Backend{
+		Name:        "openai-compatible",
+		Description: "Any OpenAI-style /v1/chat/completions endpoint (llama.cpp, vLLM, LM Studio, gateways)",
+		Requires: []Requirement{
+			{Key: "base_url", Env: "CODEX_BASE_URL", Value: func(cfg *Config) string { return cfg.BaseURL }},
+			{Key: "model", Value: func(cfg *Config) string { return cfg.Model }},
+		},
+		Factory: func(cfg *Config) (Provider, error) {
+			p := NewOpenAICompatibleProvider(cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.Headers)
+			p.SetSystemPrompt(cfg.SystemPrompt)
+			p.SetPricing(cfg.Pricing)
+			return p, nil
+		},
+	})
+}
+
+// OpenAICompatibleProvider talks to any endpoint that speaks the OpenAI
+// /v1/chat/completions dialect (llama.cpp, vLLM, LM Studio, gateways).
+// Requests, streaming, prompt building and pricing are shared with
+// OpenAIProvider, so a model with no built-in or configured price has an
+// unknown cost; price a self-hosted model at 0 to report it as free.
+type OpenAICompatibleProvider struct {
+	*OpenAIProvider
+}
+
+// NewOpenAICompatibleProvider creates a provider for a self-hosted or gateway endpoint.
+// The API key is optional and only sent when non-empty.
+func NewOpenAICompatibleProvider(baseURL, apiKey, model string, headers map[string]string) *OpenAICompatibleProvider {
+	p := NewOpenAIProvider(apiKey)
+	p.name = "openai-compatible"
+	p.baseURL = baseURL
+	p.model = model
+	for key, value := range headers {
+		p.SetHeader(key, value)
+	}
+
+	return &OpenAICompatibleProvider{OpenAIProvider: p}
+}
+
+// Validate checks if the provider is properly configured
+func (p *OpenAICompatibleProvider) Validate() error {
+	if p.baseURL == "" {
+		return fmt.Errorf("openai-compatible provider requires base_url")
+	}
+	if _, err := url.ParseRequestURI(p.baseURL); err != nil {
+		return fmt.Errorf("invalid base_url %q: %w", p.baseURL, err)
+	}
+	if p.model == "" {
+		return fmt.Errorf("openai-compatible provider requires model")
+	}
+	return nil
+}
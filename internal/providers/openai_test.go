@@ -0,0 +1,243 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	codexContext "codex/internal/context"
+	codexErrors "codex/internal/errors"
+)
+
+func TestOpenAISendQueryStreams(t *testing.T) {
+	var got openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Expected path /chat/completions, got %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			t.Errorf("Expected bearer auth header, got %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("Failed to decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{"C-", "b"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", part)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1200,\"completion_tokens\":5,\"prompt_tokens_details\":{\"cached_tokens\":1000}}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProviderWithModel("test-key", "gpt-test")
+	p.SetBaseURL(server.URL)
+
+	ctx := &codexContext.Context{
+		Filesystem: &codexContext.FilesystemContext{CurrentDir: "/home/user"},
+	}
+
+	var out bytes.Buffer
+	if err := p.SendQuery(context.Background(), "tmux prefix?", ctx, &out); err != nil {
+		t.Fatalf("SendQuery failed: %v", err)
+	}
+
+	if !strings.HasSuffix(out.String(), "C-b\n") {
+		t.Errorf("Expected streamed answer at end of output, got %q", out.String())
+	}
+	if got.Model != "gpt-test" || !got.Stream {
+		t.Errorf("Unexpected request: model=%q stream=%v", got.Model, got.Stream)
+	}
+	if got.MaxTokens != openAIMaxTokens || got.MaxCompletionTokens != 0 {
+		t.Errorf("Expected max_tokens only, got max_tokens=%d max_completion_tokens=%d", got.MaxTokens, got.MaxCompletionTokens)
+	}
+	if got.StreamOptions == nil || !got.StreamOptions.IncludeUsage {
+		t.Errorf("Expected stream usage to be requested, got %+v", got.StreamOptions)
+	}
+	want := Usage{InputTokens: 200, OutputTokens: 5, CacheReadTokens: 1000}
+	if p.LastUsage() != want {
+		t.Errorf("Expected usage %+v, got %+v", want, p.LastUsage())
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[1].Role != "user" {
+		t.Fatalf("Expected system and user messages, got %+v", got.Messages)
+	}
+	if !strings.Contains(got.Messages[1].Content, "Current Directory: /home/user") {
+		t.Errorf("Expected filesystem context in user message, got %q", got.Messages[1].Content)
+	}
+	if !strings.HasSuffix(got.Messages[1].Content, "## User Query\ntmux prefix?\n") {
+		t.Errorf("Expected query at end of user message, got %q", got.Messages[1].Content)
+	}
+}
+
+func TestOpenAISendQueryMapsHTTPErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusTooManyRequests, codexErrors.ErrProviderRateLimit},
+		{http.StatusInternalServerError, codexErrors.ErrProviderAPIError},
+		{http.StatusBadRequest, codexErrors.ErrProviderAPIError},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			fmt.Fprint(w, `{"error":{"message":"nope","type":"test"}}`)
+		}))
+
+		p := NewOpenAIProvider("test-key")
+		p.SetBaseURL(server.URL)
+
+		err := p.SendQuery(context.Background(), "q", nil, &bytes.Buffer{})
+		server.Close()
+
+		if !errors.Is(err, tt.want) {
+			t.Errorf("status %d: expected %v, got %v", tt.status, tt.want, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "nope") {
+			t.Errorf("status %d: expected API message in error, got %v", tt.status, err)
+		}
+	}
+}
+
+func TestOpenAIReasoningModelsUseMaxCompletionTokens(t *testing.T) {
+	for model, want := range map[string]bool{
+		"o1": true, "o3-mini": true, "o4-mini": true, "gpt-5": true, "gpt-5-nano": true, "openai/o3": true,
+		"gpt-4o": false, "gpt-4.1-mini": false, "o1x": false, "llama3": false,
+	} {
+		var raw map[string]any
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&raw)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}))
+
+		p := NewOpenAIProviderWithModel("test-key", model)
+		p.SetBaseURL(server.URL)
+		err := p.SendQuery(context.Background(), "q", nil, &bytes.Buffer{})
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: SendQuery failed: %v", model, err)
+		}
+
+		_, completion := raw["max_completion_tokens"]
+		_, legacy := raw["max_tokens"]
+		if completion != want || legacy == want {
+			t.Errorf("%s: expected max_completion_tokens=%v, got request %v", model, want, raw)
+		}
+	}
+}
+
+func TestOpenAIRetriesWithoutStreamOptions(t *testing.T) {
+	var requests []openAIChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if req.StreamOptions != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"unknown field stream_options"}}`)
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewOpenAICompatibleProvider(server.URL, "", "local-model", nil)
+	for i := 0; i < 2; i++ {
+		var out bytes.Buffer
+		if err := p.SendQuery(context.Background(), "q", nil, &out); err != nil {
+			t.Fatalf("SendQuery failed: %v", err)
+		}
+		if !strings.HasSuffix(out.String(), "ok\n") {
+			t.Errorf("Expected streamed answer, got %q", out.String())
+		}
+	}
+
+	// Rejected once, then never asked again
+	if len(requests) != 3 || requests[0].StreamOptions == nil || requests[1].StreamOptions != nil || requests[2].StreamOptions != nil {
+		t.Errorf("Expected one request with stream_options and two without, got %+v", requests)
+	}
+}
+
+func TestOpenAIReturnsOtherBadRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"maximum context length exceeded","code":"context_length_exceeded"}}`)
+	}))
+	defer server.Close()
+
+	p := NewOpenAICompatibleProvider(server.URL, "", "local-model", nil)
+	err := p.SendQuery(context.Background(), "q", nil, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "maximum context length exceeded") {
+		t.Errorf("Expected the original error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected no retry, got %d requests", requests)
+	}
+	if !p.streamUsage {
+		t.Error("Expected usage to still be requested")
+	}
+}
+
+func TestOpenAIValidate(t *testing.T) {
+	if err := NewOpenAIProvider("").Validate(); err == nil {
+		t.Error("Expected error for missing API key")
+	}
+
+	p := NewOpenAIProvider("key")
+	p.SetModel("")
+	if err := p.Validate(); err == nil {
+		t.Error("Expected error for missing model")
+	}
+
+	if err := NewOpenAIProvider("key").Validate(); err != nil {
+		t.Errorf("Expected valid provider, got %v", err)
+	}
+}
+
+func TestOpenAICompatibleSendsHeadersWithoutKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Expected no Authorization header without API key, got %q", auth)
+		}
+		if route := r.Header.Get("X-Gateway-Route"); route != "codex" {
+			t.Errorf("Expected custom header, got %q", route)
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewOpenAICompatibleProvider(server.URL+"/", "", "local-model", map[string]string{"X-Gateway-Route": "codex"})
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if p.Name() != "openai-compatible" {
+		t.Errorf("Expected name openai-compatible, got %s", p.Name())
+	}
+
+	var out bytes.Buffer
+	if err := p.SendQuery(context.Background(), "q", nil, &out); err != nil {
+		t.Fatalf("SendQuery failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "ok\n") {
+		t.Errorf("Expected streamed answer, got %q", out.String())
+	}
+}
+
+func TestOpenAICompatibleValidate(t *testing.T) {
+	if err := NewOpenAICompatibleProvider("", "", "m", nil).Validate(); err == nil {
+		t.Error("Expected error for missing base URL")
+	}
+	if err := NewOpenAICompatibleProvider("http://localhost:8080/v1", "", "", nil).Validate(); err == nil {
+		t.Error("Expected error for missing model")
+	}
+}
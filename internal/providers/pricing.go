@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PricingVersion identifies the snapshot of published list prices in pricingTable.
+// Bump it whenever prices are updated.
+const PricingVersion = "2025-10"
+
+// estimatedOutputTokens is the answer length assumed for pre-flight cost estimates
+const estimatedOutputTokens = 500
+
+// Pricing holds a model's prices in USD per million tokens
+type Pricing struct {
+	Input      float64 // Uncached input
+	Output     float64
+	CacheRead  float64 // Input served from the prompt cache
+	CacheWrite float64 // Input written to the prompt cache
+}
+
+// Cost returns the price in USD of the given usage
+func (pr Pricing) Cost(usage Usage) float64 {
+	total := float64(usage.InputTokens)*pr.Input +
+		float64(usage.OutputTokens)*pr.Output +
+		float64(usage.CacheReadTokens)*pr.CacheRead +
+		float64(usage.CacheWriteTokens)*pr.CacheWrite
+	return total / 1_000_000
+}
+
+// pricingTable holds list prices keyed by model name prefix. Dated model IDs
+// (claude-3-5-haiku-20241022) resolve to their family via the longest prefix.
+var pricingTable = map[string]Pricing{
+	// Anthropic: cache writes cost 1.25x input, cache reads 0.1x input
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.30},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4.00, CacheRead: 0.08, CacheWrite: 1.00},
+	"claude-haiku-4-5":  {Input: 1.00, Output: 5.00, CacheRead: 0.10, CacheWrite: 1.25},
+	"claude-3-5-sonnet": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-7-sonnet": {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-sonnet-4":   {Input: 3.00, Output: 15.00, CacheRead: 0.30, CacheWrite: 3.75},
+	"claude-3-opus":     {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-opus-4":     {Input: 15.00, Output: 75.00, CacheRead: 1.50, CacheWrite: 18.75},
+	"claude-opus-4-5":   {Input: 5.00, Output: 25.00, CacheRead: 0.50, CacheWrite: 6.25},
+
+	// OpenAI: caching is automatic, so there is no write premium
+	"gpt-3.5-turbo": {Input: 0.50, Output: 1.50, CacheRead: 0.50, CacheWrite: 0.50},
+	"gpt-4":         {Input: 30.00, Output: 60.00, CacheRead: 30.00, CacheWrite: 30.00},
+	"gpt-4-turbo":   {Input: 10.00, Output: 30.00, CacheRead: 10.00, CacheWrite: 10.00},
+	"gpt-4o":        {Input: 2.50, Output: 10.00, CacheRead: 1.25, CacheWrite: 2.50},
+	"gpt-4o-mini":   {Input: 0.15, Output: 0.60, CacheRead: 0.075, CacheWrite: 0.15},
+	"gpt-4.1":       {Input: 2.00, Output: 8.00, CacheRead: 0.50, CacheWrite: 2.00},
+	"gpt-4.1-mini":  {Input: 0.40, Output: 1.60, CacheRead: 0.10, CacheWrite: 0.40},
+	"gpt-4.1-nano":  {Input: 0.10, Output: 0.40, CacheRead: 0.025, CacheWrite: 0.10},
+	"gpt-5":         {Input: 1.25, Output: 10.00, CacheRead: 0.125, CacheWrite: 1.25},
+	"gpt-5-mini":    {Input: 0.25, Output: 2.00, CacheRead: 0.025, CacheWrite: 0.25},
+	"gpt-5-nano":    {Input: 0.05, Output: 0.40, CacheRead: 0.005, CacheWrite: 0.05},
+	"o1":            {Input: 15.00, Output: 60.00, CacheRead: 7.50, CacheWrite: 15.00},
+	"o3":            {Input: 2.00, Output: 8.00, CacheRead: 0.50, CacheWrite: 2.00},
+	"o3-mini":       {Input: 1.10, Output: 4.40, CacheRead: 0.55, CacheWrite: 1.10},
+	"o4-mini":       {Input: 1.10, Output: 4.40, CacheRead: 0.275, CacheWrite: 1.10},
+}
+
+// PricingFor returns the pricing of a model. User overrides win over the
+// built-in table; both match the exact name first, then the longest prefix.
+// A gateway namespace such as openai/ is dropped when the full name has no
+// price, so openai/gpt-4o is priced as gpt-4o.
+func PricingFor(model string, overrides map[string]Pricing) (Pricing, bool) {
+	names := []string{model}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		names = append(names, model[i+1:])
+	}
+
+	for _, table := range []map[string]Pricing{overrides, pricingTable} {
+		for _, name := range names {
+			if pr, ok := lookupPricing(table, name); ok {
+				return pr, true
+			}
+		}
+	}
+	return Pricing{}, false
+}
+
+// lookupPricing finds the entry for model by exact name or longest prefix
+func lookupPricing(table map[string]Pricing, model string) (Pricing, bool) {
+	if pr, ok := table[model]; ok {
+		return pr, true
+	}
+
+	best := ""
+	for prefix := range table {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Pricing{}, false
+	}
+	return table[best], true
+}
+
+// usageCost prices usage for a model, failing if the model has no known pricing
+func usageCost(model string, overrides map[string]Pricing, usage Usage) (float64, error) {
+	pr, ok := PricingFor(model, overrides)
+	if !ok {
+		return 0, fmt.Errorf("no pricing known for model %s (add it under 'pricing:' in the config file)", model)
+	}
+	return pr.Cost(usage), nil
+}
+
+// estimateCost prices a request of inputTokens uncached input plus a typical answer
+func estimateCost(model string, overrides map[string]Pricing, inputTokens int) (float64, error) {
+	return usageCost(model, overrides, Usage{
+		InputTokens:  int64(inputTokens),
+		OutputTokens: estimatedOutputTokens,
+	})
+}
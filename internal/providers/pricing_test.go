@@ -0,0 +1,123 @@
+package providers
+
+import (
+	"math"
+	"testing"
+
+	"codex/internal/config"
+)
+
+func TestPricingForMatchesLongestPrefix(t *testing.T) {
+	tests := []struct {
+		model string
+		input float64
+	}{
+		{"claude-3-5-haiku-20241022", 0.80},
+		{"claude-3-5-sonnet-20241022", 3.00},
+		{"claude-opus-4-5-20251101", 5.00},
+		{"claude-opus-4-1-20250805", 15.00},
+		{"gpt-4o-mini-2024-07-18", 0.15},
+		{"gpt-4o", 2.50},
+		{"gpt-4-turbo-preview", 10.00},
+		{"gpt-4-0613", 30.00},
+		{"openai/gpt-4o", 2.50},
+		{"anthropic/claude-3-5-sonnet", 3.00},
+		{"openrouter/openai/gpt-4o-mini", 0.15},
+	}
+
+	for _, tt := range tests {
+		pr, ok := PricingFor(tt.model, nil)
+		if !ok {
+			t.Errorf("%s: expected pricing", tt.model)
+			continue
+		}
+		if pr.Input != tt.input {
+			t.Errorf("%s: expected input price %.2f, got %.2f", tt.model, tt.input, pr.Input)
+		}
+	}
+
+	if _, ok := PricingFor("llama3", nil); ok {
+		t.Error("Expected no pricing for an unknown model")
+	}
+}
+
+func TestPricingForPrefersOverrides(t *testing.T) {
+	overrides := map[string]Pricing{"claude-3-5-haiku": {Input: 1, Output: 2}}
+
+	pr, _ := PricingFor("claude-3-5-haiku-20241022", overrides)
+	if pr.Input != 1 || pr.Output != 2 {
+		t.Errorf("Expected override pricing, got %+v", pr)
+	}
+
+	// An override for the namespaced name wins over the built-in price
+	pr, _ = PricingFor("anthropic/claude-3-5-haiku", map[string]Pricing{"anthropic/claude-3-5-haiku": {Input: 9}})
+	if pr.Input != 9 {
+		t.Errorf("Expected namespaced override pricing, got %+v", pr)
+	}
+
+	pr, _ = PricingFor("claude-3-5-sonnet-20241022", overrides)
+	if pr.Input != 3.00 {
+		t.Errorf("Expected built-in pricing for other models, got %+v", pr)
+	}
+}
+
+func TestPricingCost(t *testing.T) {
+	pr := Pricing{Input: 0.80, Output: 4.00, CacheRead: 0.08, CacheWrite: 1.00}
+	usage := Usage{InputTokens: 1_000_000, OutputTokens: 500_000, CacheReadTokens: 2_000_000, CacheWriteTokens: 100_000}
+
+	// 0.80 + 2.00 + 0.16 + 0.10
+	if got := pr.Cost(usage); math.Abs(got-3.06) > 1e-9 {
+		t.Errorf("Expected cost 3.06, got %f", got)
+	}
+}
+
+func TestAnthropicCostUsesConfiguredModel(t *testing.T) {
+	p := NewAnthropicProviderWithModel("k", "")
+
+	// Default model is Haiku: 10k input at $0.80/M plus a 500 token answer at $4/M
+	got, err := p.GetCostEstimate(10_000)
+	if err != nil {
+		t.Fatalf("GetCostEstimate failed: %v", err)
+	}
+	if want := 0.008 + 0.002; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected estimate %f, got %f", want, got)
+	}
+
+	p.SetModel("claude-unreleased")
+	if _, err := p.GetCostEstimate(10_000); err == nil {
+		t.Error("Expected error for a model with no pricing")
+	}
+}
+
+func TestOpenAICompatibleUnknownModelIsUnpriced(t *testing.T) {
+	p := NewOpenAICompatibleProvider("https://openrouter.ai/api/v1", "k", "qwen/qwen2.5-coder-32b", nil)
+	if _, err := p.GetUsageCost(Usage{InputTokens: 1000, OutputTokens: 1000}); err == nil {
+		t.Error("Expected unknown pricing to be an error")
+	}
+	if _, err := p.GetCostEstimate(1000); err == nil {
+		t.Error("Expected an unknown estimate so the token threshold still applies")
+	}
+
+	p.SetPricing(map[string]Pricing{"qwen/qwen2.5-coder-32b": {Input: 1, Output: 1}})
+	if cost, _ := p.GetUsageCost(Usage{InputTokens: 1_000_000}); cost != 1 {
+		t.Errorf("Expected configured pricing to apply, got %f", cost)
+	}
+
+	// A self-hosted model priced at 0 is free
+	p.SetPricing(map[string]Pricing{"qwen/qwen2.5-coder-32b": {}})
+	if cost, err := p.GetUsageCost(Usage{InputTokens: 1000, OutputTokens: 1000}); err != nil || cost != 0 {
+		t.Errorf("Expected free usage, got %f, %v", cost, err)
+	}
+}
+
+func TestConfigFromAppConvertsPricing(t *testing.T) {
+	cfg := &config.Config{
+		Provider: "anthropic",
+		Pricing:  map[string]config.ModelPricing{"claude-3-5-haiku": {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25}},
+	}
+
+	got := ConfigFromApp(cfg).Pricing["claude-3-5-haiku"]
+	if got != (Pricing{Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25}) {
+		t.Errorf("Unexpected converted pricing: %+v", got)
+	}
+}
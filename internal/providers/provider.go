@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
+	"codex/internal/config"
 	codexContext "codex/internal/context"
 )
 
@@ -24,40 +26,98 @@ type Provider interface {
 
 	// GetCostEstimate returns estimated cost in USD for a query
 	GetCostEstimate(tokens int) (float64, error)
+
+	// LastUsage returns the token usage reported for the most recent query
+	LastUsage() Usage
+
+	// GetUsageCost returns the cost in USD of the given token usage
+	GetUsageCost(usage Usage) (float64, error)
 }
 
 // Config holds provider configuration
 type Config struct {
 	Provider     string
+	Model        string // Optional, uses the backend's default model if empty
+	SystemPrompt string // Optional, uses prompt.DefaultSystem if empty
 	AnthropicKey string
 	OpenAIKey    string
+	OpenAIURL    string
 	OllamaURL    string
+
+	// OpenAI-compatible endpoint settings
+	BaseURL string
+	APIKey  string
+	Headers map[string]string
+
+	// Pricing overrides keyed by model name or prefix
+	Pricing map[string]Pricing
 }
 
-// NewProvider creates a provider based on configuration
-func NewProvider(cfg *Config) (Provider, error) {
-	switch cfg.Provider {
-	case "anthropic":
-		if cfg.AnthropicKey == "" {
-			return nil, fmt.Errorf("anthropic provider requires API key")
-		}
-		return NewAnthropicProvider(cfg.AnthropicKey), nil
+// ConfigFromApp extracts the provider settings from the application configuration
+func ConfigFromApp(cfg *config.Config) *Config {
+	return &Config{
+		Provider:     cfg.Provider,
+		Model:        cfg.Model,
+		SystemPrompt: cfg.SystemPrompt,
+		AnthropicKey: cfg.AnthropicKey,
+		OpenAIKey:    cfg.OpenAIKey,
+		OpenAIURL:    cfg.OpenAIURL,
+		OllamaURL:    cfg.OllamaURL,
+		BaseURL:      cfg.BaseURL,
+		APIKey:       cfg.APIKey,
+		Headers:      cfg.Headers,
+		Pricing:      pricingFromApp(cfg.Pricing),
+	}
+}
 
-	case "openai":
-		if cfg.OpenAIKey == "" {
-			return nil, fmt.Errorf("openai provider requires API key")
+// pricingFromApp converts configured pricing overrides to provider pricing
+func pricingFromApp(overrides map[string]config.ModelPricing) map[string]Pricing {
+	if len(overrides) == 0 {
+		return nil
+	}
+	pricing := make(map[string]Pricing, len(overrides))
+	for model, pr := range overrides {
+		pricing[model] = Pricing{
+			Input:      pr.Input,
+			Output:     pr.Output,
+			CacheRead:  pr.CacheRead,
+			CacheWrite: pr.CacheWrite,
 		}
-		return NewOpenAIProvider(cfg.OpenAIKey), nil
+	}
+	return pricing
+}
 
-	case "ollama":
-		if cfg.OllamaURL == "" {
-			cfg.OllamaURL = "http://localhost:11434"
-		}
-		return NewOllamaProvider(cfg.OllamaURL), nil
+// EffectiveModel returns the configured model, or the backend's default if none is set
+func (c *Config) EffectiveModel() string {
+	if c.Model != "" {
+		return c.Model
+	}
+	if backend, ok := Lookup(c.Provider); ok {
+		return backend.DefaultModel
+	}
+	return ""
+}
 
-	default:
-		return nil, fmt.Errorf("unknown provider: %s", cfg.Provider)
+// NewProvider creates a provider based on configuration
+func NewProvider(cfg *Config) (Provider, error) {
+	backend, ok := Lookup(cfg.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider: %s (must be one of: %s)", cfg.Provider, strings.Join(Names(), ", "))
 	}
+
+	if err := backend.Check(cfg); err != nil {
+		return nil, err
+	}
+
+	return backend.Factory(cfg)
+}
+
+// Usage holds the token counts a provider reported for one request
+type Usage struct {
+	InputTokens      int64 // Uncached input tokens
+	OutputTokens     int64
+	CacheReadTokens  int64 // Input tokens served from the prompt cache
+	CacheWriteTokens int64 // Input tokens written to the prompt cache
 }
 
 // Response represents a streaming response chunk
@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+
+	"codex/internal/config"
+)
+
+// Factory builds a provider from configuration
+type Factory func(cfg *Config) (Provider, error)
+
+// Requirement describes a configuration value a backend cannot work without
+type Requirement struct {
+	Key   string                   // Config file key, e.g. "anthropic_key"
+	Env   string                   // Environment variable that also sets it (optional)
+	Value func(cfg *Config) string // Reads the current value from configuration
+}
+
+// Describe returns the human-readable name of the setting, e.g. "anthropic_key (or ANTHROPIC_API_KEY)"
+func (r Requirement) Describe() string {
+	if r.Env == "" {
+		return r.Key
+	}
+	return fmt.Sprintf("%s (or %s)", r.Key, r.Env)
+}
+
+// Backend describes a registered provider implementation
+type Backend struct {
+	Name         string
+	Description  string
+	DefaultModel string // Empty if the user must always choose a model
+	Requires     []Requirement
+	Factory      Factory
+}
+
+// Check verifies that every requirement of the backend is satisfied
+func (b Backend) Check(cfg *Config) error {
+	for _, req := range b.Requires {
+		if req.Value(cfg) == "" {
+			return fmt.Errorf("%s provider requires %s", b.Name, req.Describe())
+		}
+	}
+	return nil
+}
+
+// registry holds every backend, keyed by provider name
+var registry = make(map[string]Backend)
+
+// Register makes a backend available by name. Each backend registers itself
+// from an init function in its own file. Register panics if the name is
+// already taken or the backend has no factory.
+func Register(b Backend) {
+	if b.Name == "" || b.Factory == nil {
+		panic("providers: Register requires a name and a factory")
+	}
+	if _, exists := registry[b.Name]; exists {
+		panic("providers: Register called twice for " + b.Name)
+	}
+	registry[b.Name] = b
+
+	// Let config.Validate check provider settings without importing this package
+	config.RegisterProvider(b.Name, func(cfg *config.Config) error {
+		return b.Check(ConfigFromApp(cfg))
+	})
+}
+
+// Lookup returns the backend registered under name
+func Lookup(name string) (Backend, bool) {
+	b, ok := registry[name]
+	return b, ok
+}
+
+// Backends returns all registered backends sorted by name
+func Backends() []Backend {
+	backends := make([]Backend, 0, len(registry))
+	for _, b := range registry {
+		backends = append(backends, b)
+	}
+	sort.Slice(backends, func(i, j int) bool {
+		return backends[i].Name < backends[j].Name
+	})
+	return backends
+}
+
+// Names returns the names of all registered backends, sorted
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for _, b := range Backends() {
+		names = append(names, b.Name)
+	}
+	return names
+}
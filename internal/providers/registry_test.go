@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"codex/internal/config"
+)
+
+func TestBackendsRegistered(t *testing.T) {
+	want := []string{"anthropic", "ollama", "openai", "openai-compatible"}
+	got := Names()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected backends %v, got %v", want, got)
+	}
+}
+
+func TestNewProviderUsesModel(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		name string
+	}{
+		{Config{Provider: "anthropic", AnthropicKey: "k", Model: "claude-x"}, "anthropic"},
+		{Config{Provider: "openai", OpenAIKey: "k", Model: "gpt-x"}, "openai"},
+		{Config{Provider: "ollama", Model: "llama3"}, "ollama"},
+		{Config{Provider: "openai-compatible", BaseURL: "http://localhost:8080/v1", Model: "local"}, "openai-compatible"},
+	}
+
+	for _, tt := range tests {
+		p, err := NewProvider(&tt.cfg)
+		if err != nil {
+			t.Errorf("%s: NewProvider failed: %v", tt.name, err)
+			continue
+		}
+		if p.Name() != tt.name {
+			t.Errorf("Expected provider %s, got %s", tt.name, p.Name())
+		}
+	}
+
+	p, _ := NewProvider(&Config{Provider: "openai", OpenAIKey: "k", Model: "gpt-x"})
+	if p.(*OpenAIProvider).model != "gpt-x" {
+		t.Errorf("Expected configured model to be used, got %s", p.(*OpenAIProvider).model)
+	}
+}
+
+func TestNewProviderChecksRequirements(t *testing.T) {
+	if _, err := NewProvider(&Config{Provider: "anthropic"}); err == nil || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+		t.Errorf("Expected missing key error, got %v", err)
+	}
+	if _, err := NewProvider(&Config{Provider: "openai-compatible", BaseURL: "http://x/v1"}); err == nil || !strings.Contains(err.Error(), "model") {
+		t.Errorf("Expected missing model error, got %v", err)
+	}
+	if _, err := NewProvider(&Config{Provider: "nope"}); err == nil || !strings.Contains(err.Error(), "unknown provider") {
+		t.Errorf("Expected unknown provider error, got %v", err)
+	}
+}
+
+func TestConfigValidateUsesRegistry(t *testing.T) {
+	cfg := &config.Config{Provider: "openai", OpenAIKey: "k"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	cfg = &config.Config{Provider: "openai"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "OPENAI_API_KEY") {
+		t.Errorf("Expected missing key error, got %v", err)
+	}
+
+	cfg = &config.Config{Provider: "bogus"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "openai-compatible") {
+		t.Errorf("Expected unknown provider error listing backends, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package providers
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// Tokenizer counts tokens the way a model family does
+type Tokenizer interface {
+	// Name identifies the vocabulary or heuristic, e.g. "cl100k_base"
+	Name() string
+
+	// Count returns the number of tokens in text
+	Count(text string) int
+}
+
+// bpeTokenizer counts tokens with an embedded BPE vocabulary
+type bpeTokenizer struct {
+	name  string
+	codec tokenizer.Codec
+}
+
+// Name returns the vocabulary name
+func (t *bpeTokenizer) Name() string {
+	return t.name
+}
+
+// Count returns the exact number of BPE tokens in text
+func (t *bpeTokenizer) Count(text string) int {
+	n, err := t.codec.Count(text)
+	if err != nil {
+		// The vocabulary covers all bytes, so this should not happen
+		return defaultHeuristic.Count(text)
+	}
+	return n
+}
+
+// heuristicTokenizer estimates tokens from byte length for model families
+// whose vocabulary is not available offline
+type heuristicTokenizer struct {
+	family        string
+	bytesPerToken float64
+}
+
+// Name returns the heuristic's model family
+func (t *heuristicTokenizer) Name() string {
+	return fmt.Sprintf("heuristic/%s", t.family)
+}
+
+// Count estimates the number of tokens in text
+func (t *heuristicTokenizer) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return int(math.Ceil(float64(len(text)) / t.bytesPerToken))
+}
+
+// defaultHeuristic is used for models from unknown families
+var defaultHeuristic = &heuristicTokenizer{family: "default", bytesPerToken: 4.0}
+
+// heuristicFamilies maps model name prefixes to bytes-per-token ratios,
+// calibrated on mixed source code, Nix and dotfiles against each family's
+// own tokenizer. Claude's vocabulary is denser on code than cl100k.
+var heuristicFamilies = []struct {
+	prefix        string
+	bytesPerToken float64
+}{
+	{"claude", 3.5},
+	{"llama", 3.8},
+	{"codellama", 3.6},
+	{"mistral", 3.6},
+	{"mixtral", 3.6},
+	{"codestral", 3.6},
+	{"qwen", 3.9},
+	{"deepseek", 3.7},
+	{"gemma", 4.0},
+	{"phi", 3.8},
+}
+
+// openAIEncodings maps OpenAI model prefixes to their BPE vocabulary. Longer
+// prefixes are listed first so "gpt-4o" wins over "gpt-4".
+var openAIEncodings = []struct {
+	prefix   string
+	encoding tokenizer.Encoding
+}{
+	{"gpt-4o", tokenizer.O200kBase},
+	{"gpt-4.1", tokenizer.O200kBase},
+	{"gpt-4.5", tokenizer.O200kBase},
+	{"gpt-5", tokenizer.O200kBase},
+	{"o1", tokenizer.O200kBase},
+	{"o3", tokenizer.O200kBase},
+	{"o4", tokenizer.O200kBase},
+	{"gpt-4", tokenizer.Cl100kBase},
+	{"gpt-3.5", tokenizer.Cl100kBase},
+	{"text-embedding-3", tokenizer.Cl100kBase},
+	{"text-embedding-ada", tokenizer.Cl100kBase},
+}
+
+// codecs caches loaded vocabularies, which are expensive to build
+var codecs sync.Map // tokenizer.Encoding -> *bpeTokenizer
+
+// TokenizerFor returns the most accurate tokenizer available offline for a model.
+// OpenAI models use their embedded BPE vocabulary; other families fall back
+// to a calibrated heuristic.
+func TokenizerFor(model string) Tokenizer {
+	name := strings.ToLower(model)
+
+	// Ollama and gateway model names may carry a namespace or tag, e.g. "openai/gpt-4o" or "llama3:8b"
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	for _, enc := range openAIEncodings {
+		if strings.HasPrefix(name, enc.prefix) {
+			if t, err := loadBPE(enc.encoding); err == nil {
+				return t
+			}
+			break
+		}
+	}
+
+	for _, family := range heuristicFamilies {
+		if strings.HasPrefix(name, family.prefix) {
+			return &heuristicTokenizer{family: family.prefix, bytesPerToken: family.bytesPerToken}
+		}
+	}
+
+	return defaultHeuristic
+}
+
+// loadBPE returns the cached tokenizer for an encoding, loading it on first use
+func loadBPE(encoding tokenizer.Encoding) (Tokenizer, error) {
+	if t, ok := codecs.Load(encoding); ok {
+		return t.(*bpeTokenizer), nil
+	}
+
+	codec, err := tokenizer.Get(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s vocabulary: %w", encoding, err)
+	}
+
+	t, _ := codecs.LoadOrStore(encoding, &bpeTokenizer{name: string(encoding), codec: codec})
+	return t.(*bpeTokenizer), nil
+}
@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenizerForSelectsVocabulary(t *testing.T) {
+	tests := []struct {
+		model string
+		name  string
+	}{
+		{"gpt-4-turbo-preview", "cl100k_base"},
+		{"gpt-3.5-turbo", "cl100k_base"},
+		{"gpt-4o-mini", "o200k_base"},
+		{"openai/gpt-4.1", "o200k_base"},
+		{"claude-3-5-haiku-20241022", "heuristic/claude"},
+		{"llama3:8b", "heuristic/llama"},
+		{"qwen2.5-coder:7b", "heuristic/qwen"},
+		{"something-else", "heuristic/default"},
+		{"", "heuristic/default"},
+	}
+
+	for _, tt := range tests {
+		if got := TokenizerFor(tt.model).Name(); got != tt.name {
+			t.Errorf("%q: expected tokenizer %s, got %s", tt.model, tt.name, got)
+		}
+	}
+}
+
+func TestBPETokenizerCounts(t *testing.T) {
+	// Reference counts from OpenAI's tiktoken
+	tests := []struct {
+		model string
+		text  string
+		want  int
+	}{
+		{"gpt-4", "hello world", 2},
+		{"gpt-4", "set -g prefix C-a", 6},
+		{"gpt-4o", "hello world", 2},
+	}
+
+	for _, tt := range tests {
+		if got := TokenizerFor(tt.model).Count(tt.text); got != tt.want {
+			t.Errorf("%s %q: expected %d tokens, got %d", tt.model, tt.text, tt.want, got)
+		}
+	}
+}
+
+func TestHeuristicTokenizerCounts(t *testing.T) {
+	claude := TokenizerFor("claude-3-opus")
+	if got := claude.Count(""); got != 0 {
+		t.Errorf("Expected 0 tokens for empty text, got %d", got)
+	}
+	if got := claude.Count(strings.Repeat("a", 35)); got != 10 {
+		t.Errorf("Expected 10 tokens for 35 bytes at 3.5 bytes/token, got %d", got)
+	}
+	if got := TokenizerFor("unknown").Count("abcde"); got != 2 {
+		t.Errorf("Expected default heuristic to round up, got %d", got)
+	}
+}
+
+func TestEstimateTokensUsesModelTokenizer(t *testing.T) {
+	p := NewOpenAIProviderWithModel("key", "gpt-4")
+	got, err := p.EstimateTokens("q", nil)
+	if err != nil {
+		t.Fatalf("EstimateTokens failed: %v", err)
+	}
+
+	want := TokenizerFor("gpt-4").Count(p.prompts.Build("q", nil).Render())
+	if got != want {
+		t.Errorf("Expected %d tokens, got %d", want, got)
+	}
+}
@@ -0,0 +1,397 @@
+// Package redact replaces credentials in gathered context with placeholders
+// before it is sent to a provider.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	codexContext "codex/internal/context"
+)
+
+// Rule names, used in placeholders and reports
+const (
+	RulePrivateKey  = "private-key"
+	RuleAWSAccess   = "aws-access-key"
+	RuleAWSSecret   = "aws-secret-key"
+	RuleGitHub      = "github-token"
+	RuleAnthropic   = "anthropic-key"
+	RuleOpenAI      = "openai-key"
+	RuleSlack       = "slack-token"
+	RuleGoogle      = "google-api-key"
+	RuleAssignment  = "secret-assignment"
+	RuleHighEntropy = "high-entropy"
+)
+
+// minSecretValue is the shortest assignment value treated as a real secret;
+// shorter values are usually placeholders or examples
+const minSecretValue = 8
+
+// High-entropy detection: long runs of base64-ish characters with mixed case
+// and digits, random enough to be a key rather than a word or identifier
+const (
+	minEntropyLength = 32
+	minEntropy       = 4.5 // Shannon entropy in bits per character
+)
+
+// rule detects one kind of credential. If group is non-zero, only that
+// submatch is replaced, so surrounding syntax like "KEY=" stays readable.
+type rule struct {
+	name  string
+	re    *regexp.Regexp
+	group int
+}
+
+// rules are applied in order; earlier, more specific rules win over later ones
+var rules = []rule{
+	{RulePrivateKey, regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`), 0},
+	{RuleAWSAccess, regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`), 0},
+	{RuleAWSSecret, regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`), 1},
+	{RuleGitHub, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`), 0},
+	{RuleAnthropic, regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`), 0},
+	{RuleOpenAI, regexp.MustCompile(`\bsk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,}`), 0},
+	{RuleSlack, regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), 0},
+	{RuleGoogle, regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), 0},
+
+	// Environment-style assignments: FOO_API_KEY=..., export GITHUB_TOKEN="...", DB_PASSWORD: ...
+	{RuleAssignment, regexp.MustCompile(`\b[A-Z][A-Z0-9_]*(?:API_KEY|APIKEY|_KEY|_SECRET|_TOKEN|_PASSWORD|_PASSWD)["']?\s*[:=]\s*("[^"\n]*"|'[^'\n]*'|[^\s#;,]+)`), 1},
+}
+
+// candidateRe finds tokens worth checking for entropy; "=" only as base64 padding,
+// so "NAME=value" is not one token
+var candidateRe = regexp.MustCompile(`[A-Za-z0-9+/_-]{32,}={0,2}`)
+
+// hashPrefixes precede content hashes (Nix SRI hashes, lock files), which are not secrets
+var hashPrefixes = []string{"sha1-", "sha256-", "sha384-", "sha512-", "sha256:", "sha512:"}
+
+// placeholder is the text a secret is replaced with
+func placeholder(ruleName string) string {
+	return fmt.Sprintf("[REDACTED:%s]", ruleName)
+}
+
+// match is a secret's position in the text
+type match struct {
+	start, end int
+	rule       string
+}
+
+// Text replaces the secrets in text with placeholders and reports where they were
+func Text(text string) (string, []codexContext.Redaction) {
+	var matches []match
+	for _, r := range rules {
+		for _, loc := range r.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[0], loc[1]
+			if r.group > 0 {
+				start, end = loc[2*r.group], loc[2*r.group+1]
+				if start < 0 {
+					continue
+				}
+				value := strings.Trim(text[start:end], `"'`)
+				if isPlaceholderValue(value) {
+					continue
+				}
+			}
+			matches = append(matches, match{start, end, r.name})
+		}
+	}
+
+	// The entropy check is a fallback for text no specific rule matched
+	for _, loc := range candidateRe.FindAllStringIndex(text, -1) {
+		if !overlaps(matches, loc[0], loc[1]) && isHighEntropySecret(text, loc[0], loc[1]) {
+			matches = append(matches, match{loc[0], loc[1], RuleHighEntropy})
+		}
+	}
+
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	// Keep the first match at each position, dropping any that overlap it
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	var sb strings.Builder
+	var found []codexContext.Redaction
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue
+		}
+		sb.WriteString(text[last:m.start])
+		sb.WriteString(placeholder(m.rule))
+		found = append(found, codexContext.Redaction{
+			Rule: m.rule,
+			Line: strings.Count(text[:m.start], "\n") + 1,
+		})
+		last = m.end
+	}
+	sb.WriteString(text[last:])
+
+	return sb.String(), found
+}
+
+// Context redacts ctx in place and returns the number of secrets replaced:
+// repository files, Nix options, packages and flake inputs, dotfiles
+// settings, key bindings and shell definitions. Files record their own
+// redactions; the rest are recorded on the Nix and dotfiles contexts with
+// where each secret was set.
+func Context(ctx *codexContext.Context) int {
+	if ctx == nil {
+		return 0
+	}
+
+	repos := append([]*codexContext.RepositoryContext{}, ctx.ConfiguredRepos...)
+	if ctx.CurrentRepo != nil {
+		repos = append(repos, ctx.CurrentRepo)
+	}
+
+	total := 0
+	for _, repo := range repos {
+		if repo == nil || repo.Contents == nil {
+			continue
+		}
+
+		size := 0
+		for i := range repo.Contents.Files {
+			file := &repo.Contents.Files[i]
+			redacted, found := Text(file.Content)
+			if len(found) > 0 {
+				file.Content = redacted
+				file.Size = len(redacted)
+				file.Tokens = 0 // Stale; recounted by whoever needs it
+				file.Redactions = found
+				total += len(found)
+			}
+			size += file.Size
+		}
+		repo.Contents.TotalSize = size
+	}
+
+	if nix := ctx.NixConfig; nix != nil {
+		r := &recorder{}
+		for key, value := range nix.SystemConfig {
+			nix.SystemConfig[key] = r.value(value, attrName(key), locate(nix.Sources, key))
+		}
+		for i := range nix.Packages {
+			nix.Packages[i] = r.text(nix.Packages[i], "", location{source: "packages"})
+		}
+		for i := range nix.Inputs {
+			in := &nix.Inputs[i]
+			in.URL = r.text(in.URL, "", location{source: "inputs." + in.Name + ".url"})
+		}
+		nix.Redactions = r.sorted()
+		total += len(nix.Redactions)
+	}
+
+	if dotfiles := ctx.Dotfiles; dotfiles != nil {
+		r := &recorder{}
+		for program, settings := range dotfiles.Configs {
+			table, ok := settings.(map[string]any)
+			if !ok {
+				dotfiles.Configs[program] = r.value(settings, "", location{source: program})
+				continue
+			}
+			for setting, value := range table {
+				key := program + "." + setting
+				table[setting] = r.value(value, attrName(setting), locate(dotfiles.Sources, key))
+			}
+		}
+		for tool, binds := range dotfiles.Keybindings {
+			for i := range binds {
+				at := location{source: binds[i].File, line: binds[i].Line}
+				if at.source == "" {
+					at = location{source: tool + " " + binds[i].Key}
+				}
+				binds[i].Command = r.text(binds[i].Command, "", at)
+				binds[i].Description = r.text(binds[i].Description, "", at)
+			}
+		}
+		for name, shell := range dotfiles.Shells {
+			r.definitions(name, shell.Aliases)
+			r.definitions(name, shell.Functions)
+			r.definitions(name, shell.Exports)
+		}
+		dotfiles.Redactions = r.sorted()
+		total += len(dotfiles.Redactions)
+	}
+
+	return total
+}
+
+// location is where a value outside a repository file was set: its file and
+// line when known, otherwise its option or setting path
+type location struct {
+	source string
+	line   int
+}
+
+// locate returns where the option key was set, or the key itself if its
+// source is unknown
+func locate(sources map[string]codexContext.OptionSource, key string) location {
+	if src, ok := sources[key]; ok && src.File != "" {
+		return location{source: src.File, line: src.Line}
+	}
+	return location{source: key}
+}
+
+// attrName returns the last attribute of a dotted option path, such as
+// API_TOKEN for services.app.environment.API_TOKEN
+func attrName(key string) string {
+	return key[strings.LastIndex(key, ".")+1:]
+}
+
+// recorder redacts parsed values, recording where each secret was
+type recorder struct {
+	found []codexContext.Redaction
+}
+
+// text redacts s, recording its secrets at at. A multi-line value's secrets
+// are placed on the lines after at.line that they were found on. If name is
+// set it is checked with the value, so "API_TOKEN = ..." is caught by the
+// assignment rule as "API_TOKEN=..." would be.
+func (r *recorder) text(s, name string, at location) string {
+	if s == "" {
+		return s
+	}
+	prefix := ""
+	if name != "" {
+		prefix = name + "="
+	}
+
+	redacted, found := Text(prefix + s)
+	for _, f := range found {
+		line := 0
+		if at.line > 0 {
+			line = at.line + f.Line - 1
+		}
+		r.found = append(r.found, codexContext.Redaction{Rule: f.Rule, Line: line, Source: at.source})
+	}
+	return strings.TrimPrefix(redacted, prefix)
+}
+
+// value redacts the strings in a parsed configuration value, named by the
+// attribute that holds it
+func (r *recorder) value(value any, name string, at location) any {
+	switch v := value.(type) {
+	case string:
+		return r.text(v, name, at)
+	case []any:
+		for i, item := range v {
+			v[i] = r.value(item, name, at)
+		}
+		return v
+	case map[string]any:
+		for key, item := range v {
+			v[key] = r.value(item, attrName(key), at)
+		}
+		return v
+	}
+	return value
+}
+
+// definitions redacts shell definition values in place. The name is checked
+// with the value, so "export GITHUB_TOKEN=..." is caught by the assignment
+// rule.
+func (r *recorder) definitions(shell string, defs []codexContext.ShellDefinition) {
+	for i := range defs {
+		at := location{source: defs[i].File, line: defs[i].Line}
+		if at.source == "" {
+			at = location{source: shell + " " + defs[i].Name}
+		}
+		defs[i].Value = r.text(defs[i].Value, defs[i].Name, at)
+	}
+}
+
+// sorted returns the recorded redactions by source and line, as map order
+// would otherwise shuffle them
+func (r *recorder) sorted() []codexContext.Redaction {
+	sort.SliceStable(r.found, func(i, j int) bool {
+		a, b := r.found[i], r.found[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		return a.Line < b.Line
+	})
+	return r.found
+}
+
+// overlaps reports whether text[start:end] overlaps any of the matches
+func overlaps(matches []match, start, end int) bool {
+	for _, m := range matches {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholderValue reports whether an assigned value is an example or a
+// reference to another variable rather than a literal secret
+func isPlaceholderValue(value string) bool {
+	if len(value) < minSecretValue {
+		return true
+	}
+	if strings.HasPrefix(value, "$") || strings.HasPrefix(value, "<") || strings.HasPrefix(value, "[REDACTED") {
+		return true
+	}
+
+	lower := strings.ToLower(value)
+	for _, example := range []string{"your", "example", "changeme", "placeholder", "xxxxxxxx", "dummy"} {
+		if strings.Contains(lower, example) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHighEntropySecret reports whether text[start:end] looks like a random key
+func isHighEntropySecret(text string, start, end int) bool {
+	token := text[start:end]
+	if len(token) < minEntropyLength {
+		return false
+	}
+
+	// Content hashes are random but public
+	for _, prefix := range hashPrefixes {
+		if strings.HasPrefix(token, prefix) || strings.HasSuffix(text[:start], prefix) {
+			return false
+		}
+	}
+
+	// Require upper, lower and digits: rules out hex digests, Nix store
+	// hashes (lowercase base32) and long identifiers
+	var upper, lower, digit bool
+	for _, c := range token {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+
+	return shannonEntropy(token) >= minEntropy
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+
+	entropy := 0.0
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}